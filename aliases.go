@@ -5,6 +5,7 @@ import (
 	"github.com/kjkrol/goke/v3/internal/comp"
 	"github.com/kjkrol/goke/v3/internal/ent"
 	"github.com/kjkrol/goke/v3/internal/orch"
+	"github.com/kjkrol/goke/v3/internal/query"
 	"github.com/kjkrol/goke/v3/internal/reg"
 	"github.com/kjkrol/goke/v3/iter"
 )
@@ -15,6 +16,11 @@ type (
 	// to Comp[T].Slice and Comp[T].At.
 	Cursor = iter.Cursor
 
+	// EntitySet is a reusable batch of entity IDs for Query.PickSet, grouped
+	// by archetype and chunk on first use and regrouped only after the
+	// entities it holds move or are removed. Create via NewEntitySet.
+	EntitySet = query.EntitySet
+
 	// CompID is the unique integer identifier for a registered component type.
 	CompID = comp.ID
 
@@ -34,7 +40,7 @@ type (
 
 	// Factory bulk-spawns entities for a single archetype using a chunk-based iterator.
 	// Call Create to set the count, then loop with Next; access entities via Entity and
-	// components via col.Slice(&factory.Cursor).
+	// components via col.Slice(&factory.Cursor). Or range over Batches(count).
 	Factory = ent.Factory
 
 	// Editor applies a fixed set of structural changes to a batch of entities
@@ -53,6 +59,10 @@ type (
 	// accesses components within an entity's existing structure.)
 	EditOpt = comp.EditOpt
 
+	// CompOpt configures how a component type is registered — see
+	// ECS.RegComp, WithName, and WithAliases.
+	CompOpt = comp.RegOpt
+
 	// ChunkSnapshot is a point-in-time capture of a single table chunk.
 	// Obtained from Query.ChunkSnapshot() during Query.All() iteration; passed
 	// to cb.Migrate so the Editor can skip per-entity addr.Book lookups.
@@ -72,6 +82,15 @@ type (
 	// not by position — pass them to Load in any order.
 	CompToken = reg.CompToken
 
+	// SaveJob is a save started by ECS.SaveAsync — Wait for its result, or
+	// Cancel it.
+	SaveJob = reg.SaveJob
+
+	// SectionError is returned by Load and [Verify] for a damaged save
+	// file: it names the section and, within entity data, the archetype's
+	// components and the component column. Match it with errors.As.
+	SectionError = reg.SectionError
+
 	// CompProvider is implemented by systems or modules that register their
 	// own components, exposing them for callers assembling an ECS.Load call
 	// without needing to know a module's internal component types by name.
@@ -28,7 +28,7 @@ import (
 //
 // Matchers are created once outside b.Run: with -count=N each b.Run callback is
 // called N times, so creating a new Matcher inside would accumulate N matchers per
-// sub-benchmark on the same ECS and eventually exceed MaxMatchers.
+// sub-benchmark on the same ECS, each baked into every new archetype.
 func Benchmark_Matcher_All(b *testing.B) {
 	ecs := setupECS()
 
@@ -131,6 +131,28 @@ func Benchmark_Matcher_All(b *testing.B) {
 		})
 	})
 
+	// Same loop as comp=2 through Query.Chunks: range-over-func must inline
+	// down to the manual All/Next form.
+	b.Run(fmt.Sprintf("pop=%d/comp=2/chunks", entitiesNumber), func(b *testing.B) {
+		fn := func() {
+			for cursor := range matcher2.Chunks() {
+				posSlice := pos.Slice(cursor)
+				velSlice := vel.Slice(cursor)
+				for i, entityID := range cursor.IDs {
+					_ = entityID
+					velSlice[i].X += velSlice[i].Y
+					posSlice[i].X += velSlice[i].X
+				}
+			}
+		}
+
+		measurePerEntity(b, entitiesNumber, func() {
+			for b.Loop() {
+				fn()
+			}
+		})
+	})
+
 	b.Run(fmt.Sprintf("pop=%d/comp=3", entitiesNumber), func(b *testing.B) {
 		cursor := matcher3.Cursor()
 		fn := func() {
@@ -23,7 +23,7 @@ import (
 //
 // Matchers are created once outside b.Run: with -count=N each b.Run callback is
 // called N times, so creating a new Matcher inside would accumulate N matchers per
-// sub-benchmark on the same ECS and eventually exceed MaxMatchers.
+// sub-benchmark on the same ECS, each baked into every new archetype.
 func Benchmark_Matcher_Pick(b *testing.B) {
 	ecs := setupECS()
 
@@ -0,0 +1,37 @@
+package goke
+
+import (
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/query"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// ChunkComp gives access to a component registered with [WithPerChunk],
+// stored once per chunk instead of once per entity — a chunk's bounding
+// box for culling, its LOD level.
+//
+// Pass &chunkComp to NewQueryBuilder to require T and bind the handle; Get
+// then returns the current chunk's value, read and written once per chunk
+// outside the inner loop. Pass it to NewFactory or NewEditorBuilder to add
+// T; each chunk's value starts zeroed, and is zeroed again once the chunk
+// empties. Entities don't carry the value when they move: it belongs to the
+// chunk, so an entity that migrates or is compacted into another chunk
+// sees that chunk's value. WhereChunk skips whole chunks by it.
+type ChunkComp[T any] struct {
+	ref iter.ChunkRef[T]
+}
+
+// Get returns the current chunk's (or Pick/Seek entity's chunk's) T —
+// writable, and the same for every entity in the chunk.
+func (c *ChunkComp[T]) Get(cur *Cursor) *T { return c.ref.Get(cur) }
+
+func (c *ChunkComp[T]) asTrack() Opt   { return comp.TrackChunk[T](&c.ref) }
+func (c *ChunkComp[T]) asAdd() EditOpt { return comp.AddChunk[T](&c.ref) }
+
+// WhereChunk returns a Predicate matching every entity of the chunks whose
+// T, read through c, satisfies pred — e.g. a bounding box against the
+// view frustum. A chunk that fails is skipped without testing any of its
+// entities. c must be tracked by the same Query.
+func WhereChunk[T any](c *ChunkComp[T], pred func(*T) bool) Predicate {
+	return query.ChunkFunc(func(cur *Cursor) bool { return pred(c.Get(cur)) })
+}
@@ -0,0 +1,151 @@
+package goke_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kjkrol/uid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kjkrol/goke/v3"
+)
+
+type chunkBounds struct{ MinX, MaxX float32 }
+
+func TestChunkComp_OneValuePerChunk(t *testing.T) {
+	ecs := goke.New(goke.WithChunkBytes(1024))
+	boundsID := ecs.RegComp[chunkBounds](goke.WithPerChunk())
+
+	var pos goke.Comp[Position]
+	var bounds goke.ChunkComp[chunkBounds]
+	var chunks int
+	var all, visible, plainQ, seekQ *goke.Query
+	var plain []uid.UID64
+	var addBounds *goke.Editor
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for cur := range si.NewFactory(&pos, &bounds).Batches(200) {
+			b := bounds.Get(cur)
+			b.MinX, b.MaxX = float32(chunks*100), float32(chunks*100+99)
+			ps := pos.Slice(cur)
+			for i := range ps {
+				ps[i].X = b.MinX + float32(i)
+			}
+			chunks++
+		}
+		for cur := range si.NewFactory(&pos).Batches(2) {
+			plain = append(plain, cur.IDs...)
+		}
+		all = si.NewQueryBuilder(&pos, &bounds).Build()
+		visible = si.NewQueryBuilder(&pos, &bounds).
+			Where(goke.WhereChunk(&bounds, func(b *chunkBounds) bool { return b.MinX >= 100 })).
+			Build()
+		plainQ = si.NewQueryBuilder(&pos).Exclude(goke.Exclude[chunkBounds]()).Build()
+		addBounds = plainQ.NewEditorBuilder(&bounds).Build()
+		seekQ = si.NewQueryBuilder(&bounds).Build()
+	}})
+	require.Greater(t, chunks, 1, "expected the entities to span several chunks")
+
+	for cur := range all.Chunks() {
+		b := bounds.Get(cur)
+		for _, p := range pos.Slice(cur) {
+			assert.True(t, p.X >= b.MinX && p.X <= b.MaxX, "%v outside %v", p, *b)
+		}
+	}
+	seen := 0
+	for cur := range visible.Chunks() {
+		assert.GreaterOrEqual(t, bounds.Get(cur).MinX, float32(100))
+		seen += len(visible.Slots())
+	}
+	assert.Less(t, seen, 200)
+	assert.Equal(t, 200, all.Count())
+
+	// AddOne writes the value of the chunk the entity lands in; an Editor
+	// adds the component with whatever value that chunk holds.
+	var step int
+	sys := ecs.RegSys(goke.SystemFn{OnUpdate: func(cb *goke.CmdBuf, d time.Duration) {
+		switch step {
+		case 0:
+			cb.AddOne(plain[0], boundsID, chunkBounds{MinX: -1, MaxX: -1})
+		case 1:
+			for cur := range plainQ.Chunks() {
+				buf := plainQ.BeginMigrate(cb)
+				for _, id := range cur.IDs {
+					buf.Add(id)
+				}
+				buf.Commit(addBounds)
+			}
+		}
+	}})
+	ecs.SetPlan(func(ctx goke.RunCtx, d time.Duration) {
+		ctx.Run(sys, d)
+		ctx.Sync()
+	})
+
+	ecs.Tick(time.Millisecond)
+	require.True(t, seekQ.Seek(plain[0]))
+	assert.Equal(t, chunkBounds{MinX: -1, MaxX: -1}, *bounds.Get(seekQ.Cursor()))
+
+	step = 1
+	ecs.Tick(time.Millisecond)
+	assert.Equal(t, 202, all.Count())
+	assert.Equal(t, 0, plainQ.Count())
+}
+
+// boundsOf reads every entity's chunk bounds through q.
+func boundsOf(q *goke.Query, bounds *goke.ChunkComp[chunkBounds]) map[uid.UID64]chunkBounds {
+	out := map[uid.UID64]chunkBounds{}
+	for cur := range q.Chunks() {
+		b := *bounds.Get(cur)
+		for _, id := range cur.IDs {
+			out[id] = b
+		}
+	}
+	return out
+}
+
+func TestChunkComp_SaveLoad(t *testing.T) {
+	ecs := goke.New(goke.WithChunkBytes(1024))
+	ecs.RegComp[chunkBounds](goke.WithPerChunk())
+	var pos goke.Comp[Position]
+	var bounds goke.ChunkComp[chunkBounds]
+	var q *goke.Query
+	chunks := 0
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for cur := range si.NewFactory(&pos, &bounds).Batches(200) {
+			*bounds.Get(cur) = chunkBounds{MinX: float32(chunks), MaxX: float32(chunks + 1)}
+			chunks++
+		}
+		q = si.NewQueryBuilder(&pos, &bounds).Build()
+	}})
+	require.Greater(t, chunks, 1, "expected the entities to span several chunks")
+	want := boundsOf(q, &bounds)
+
+	// Loaded with the same chunk size, each chunk comes back with its value.
+	path := filepath.Join(t.TempDir(), "save.bin")
+	var doc bytes.Buffer
+	ecs.Pause()
+	require.NoError(t, ecs.Save(path))
+	require.NoError(t, ecs.ExportJSON(&doc))
+	loads := map[string]func(*goke.ECS) error{
+		"Save": func(e *goke.ECS) error {
+			return e.Load(path, goke.LoadComp[Position](), goke.LoadComp[chunkBounds](goke.WithPerChunk()))
+		},
+		"ExportJSON": func(e *goke.ECS) error {
+			return e.ImportJSON(&doc, goke.LoadComp[Position](), goke.LoadComp[chunkBounds](goke.WithPerChunk()))
+		},
+	}
+	for name, load := range loads {
+		ecs2 := goke.New(goke.WithChunkBytes(1024))
+		require.NoError(t, load(ecs2), name)
+		var pos2 goke.Comp[Position]
+		var bounds2 goke.ChunkComp[chunkBounds]
+		var q2 *goke.Query
+		ecs2.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+			q2 = si.NewQueryBuilder(&pos2, &bounds2).Build()
+		}})
+		assert.Equal(t, want, boundsOf(q2, &bounds2), name)
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/persist"
+)
+
+func inspectFile(path string) (*persist.FileInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return persist.Inspect(f)
+}
+
+func runInfo(args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("info takes exactly one FILE\n" + usage)
+	}
+	info, err := inspectFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	kind := "full snapshot"
+	if info.Delta {
+		kind = fmt.Sprintf("delta on top of snapshot %016x", info.Parent)
+	}
+	fmt.Fprintf(out, "format:     version %d, %s\n", info.Version, kind)
+	if info.ID != 0 {
+		fmt.Fprintf(out, "snapshot:   %016x\n", info.ID)
+	}
+	var maxGen uint32
+	for _, g := range info.Generations[:min(int(info.NextIndex), len(info.Generations))] {
+		maxGen = max(maxGen, g)
+	}
+	fmt.Fprintf(out, "ID pool:    next index %d, %d allocated, %d free, highest generation %d\n",
+		info.NextIndex, int(info.NextIndex)-len(info.Free), len(info.Free), maxGen)
+	if info.Delta {
+		fmt.Fprintf(out, "removed:    %d entities\n", len(info.Removed))
+	}
+
+	fmt.Fprintf(out, "\ncomponents: %d\n", len(info.Components))
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for i, c := range info.Components {
+		schema := "-"
+		if c.Schema != nil {
+			schema = c.Schema.String()
+		}
+		fmt.Fprintf(tw, "  #%d\t%s\tsize %d\talign %d\t%s\n", i, c.Name, c.Size, c.Align, schema)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	total := 0
+	for _, a := range info.Archetypes {
+		total += a.EntityCount
+	}
+	fmt.Fprintf(out, "\narchetypes: %d (%d entities)\n", len(info.Archetypes), total)
+	tw = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for i := range info.Archetypes {
+		a := &info.Archetypes[i]
+		fmt.Fprintf(tw, "  #%d\t%s\t%d entities\n", i, composition(info, a), a.EntityCount)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(info.Sparse) == 0 {
+		return nil
+	}
+	fmt.Fprintf(out, "\nsparse:     %d\n", len(info.Sparse))
+	tw = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, sp := range info.Sparse {
+		fmt.Fprintf(tw, "  #%d\t%s\t%d entities\n", sp.Component, info.Components[sp.Component].Name, len(sp.IDs))
+	}
+	return tw.Flush()
+}
+
+func runDump(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	hex := fs.Bool("hex", false, "print component values as their encoded bytes")
+	ids := fs.String("id", "", "comma-separated entity IDs to print (default: all)")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w\n%s", err, usage)
+	}
+	if fs.NArg() != 1 {
+		return errors.New("dump takes exactly one FILE\n" + usage)
+	}
+	var want map[uid.UID64]bool
+	if *ids != "" {
+		want = make(map[uid.UID64]bool)
+		for _, s := range strings.Split(*ids, ",") {
+			n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return fmt.Errorf("bad entity ID %q", s)
+			}
+			want[uid.UID64(n)] = true
+		}
+	}
+
+	info, err := inspectFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	entities, err := entitiesOf(info, !*hex)
+	if err != nil {
+		return err
+	}
+	for _, id := range sortedIDs(entities) {
+		if want != nil && !want[id] {
+			continue
+		}
+		delete(want, id)
+		e := entities[id]
+		idx, gen := id.Unpack()
+		fmt.Fprintf(out, "entity %d (index %d, generation %d) %s\n", id, idx, gen, e.composition)
+		for _, c := range e.comps {
+			if *hex {
+				fmt.Fprintf(out, "  %s: % x\n", c.name, c.raw)
+			} else {
+				fmt.Fprintf(out, "  %s: %s\n", c.name, formatValue(c.value))
+			}
+		}
+	}
+	for _, id := range sortedIDs(want) {
+		fmt.Fprintf(out, "entity %d: not in the file\n", id)
+	}
+	return nil
+}
+
+func runDiff(args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return errors.New("diff takes exactly two files, OLD and NEW\n" + usage)
+	}
+	var sides [2]map[uid.UID64]*entity
+	for i, path := range args {
+		info, err := inspectFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if info.Delta {
+			// A delta lists only changed entities; the rest would read as removed.
+			return fmt.Errorf("%s: a delta save — diff compares full snapshots only", path)
+		}
+		if sides[i], err = entitiesOf(info, true); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	old, cur := sides[0], sides[1]
+
+	all := make(map[uid.UID64]bool, len(old)+len(cur))
+	for id := range old {
+		all[id] = true
+	}
+	for id := range cur {
+		all[id] = true
+	}
+	var removed, added, changed int
+	for _, id := range sortedIDs(all) {
+		a, b := old[id], cur[id]
+		switch {
+		case b == nil:
+			removed++
+			fmt.Fprintf(out, "- %d %s\n", id, a.composition)
+		case a == nil:
+			added++
+			fmt.Fprintf(out, "+ %d %s\n", id, b.composition)
+		default:
+			if lines := diffEntity(a, b); len(lines) > 0 {
+				changed++
+				for _, line := range lines {
+					fmt.Fprintf(out, "~ %d %s\n", id, line)
+				}
+			}
+		}
+	}
+	fmt.Fprintf(out, "%d removed, %d added, %d changed, %d unchanged\n",
+		removed, added, changed, len(all)-removed-added-changed)
+	if removed+added+changed > 0 {
+		return errDiffer
+	}
+	return nil
+}
+
+// diffEntity describes how b differs from a: its composition, then each
+// component both hold with different bytes.
+func diffEntity(a, b *entity) []string {
+	var lines []string
+	if a.composition != b.composition {
+		lines = append(lines, fmt.Sprintf("composition %s -> %s", a.composition, b.composition))
+	}
+	for _, cb := range b.comps {
+		for _, ca := range a.comps {
+			if ca.name == cb.name && !bytes.Equal(ca.raw, cb.raw) {
+				lines = append(lines, fmt.Sprintf("%s: %s -> %s", cb.name, formatValue(ca.value), formatValue(cb.value)))
+			}
+		}
+	}
+	return lines
+}
+
+// entity is one saved entity's composition and component values.
+type entity struct {
+	composition string
+	comps       []compValue
+}
+
+type compValue struct {
+	name  string
+	raw   []byte
+	value any // decoded by the component's schema
+}
+
+// entitiesOf collects info's entities, decoding each value by its
+// component's schema if decode is set; an archetype's shared values are
+// listed with each of its entities, and a saved chunk's per-chunk values
+// with each of the chunk's entities. Sparse components held are listed
+// after the archetype's composition, e.g. "{game.Pos} sparse {game.Stun}".
+func entitiesOf(info *persist.FileInfo, decode bool) (map[uid.UID64]*entity, error) {
+	if !info.RowsReadable {
+		return nil, fmt.Errorf("format version %d: entity rows can only be read from version 5 files on", info.Version)
+	}
+	entities := make(map[uid.UID64]*entity)
+	for i := range info.Archetypes {
+		a := &info.Archetypes[i]
+		name := composition(info, a)
+		for _, id := range a.IDs {
+			entities[id] = &entity{composition: name}
+		}
+		for _, sh := range a.Shared {
+			c := info.Components[sh.Component]
+			v := compValue{name: c.Name, raw: sh.Data}
+			if decode {
+				var err error
+				if v.value, err = c.Schema.Decode(bytes.NewReader(sh.Data)); err != nil {
+					return nil, err
+				}
+			}
+			for _, id := range a.IDs {
+				entities[id].comps = append(entities[id].comps, v)
+			}
+		}
+		for _, col := range a.ChunkValues {
+			rows, err := info.ChunkRows(a, col)
+			if err != nil {
+				return nil, fmt.Errorf("%s, per-chunk component %q: %w", name, info.Components[col.Component].Name, err)
+			}
+			c := info.Components[col.Component]
+			first := 0
+			for j, n := range a.Chunks {
+				v := compValue{name: c.Name, raw: rows[j]}
+				if decode {
+					if v.value, err = c.Schema.Decode(bytes.NewReader(rows[j])); err != nil {
+						return nil, err
+					}
+				}
+				for _, id := range a.IDs[first : first+n] {
+					entities[id].comps = append(entities[id].comps, v)
+				}
+				first += n
+			}
+		}
+		for _, col := range a.Columns {
+			rows, err := info.Rows(a, col)
+			if err != nil {
+				return nil, fmt.Errorf("%s, component %q: %w", name, info.Components[col.Component].Name, err)
+			}
+			c := info.Components[col.Component]
+			for j, raw := range rows {
+				v := compValue{name: c.Name, raw: raw}
+				if decode {
+					if v.value, err = c.Schema.Decode(bytes.NewReader(raw)); err != nil {
+						return nil, err
+					}
+				}
+				e := entities[a.IDs[j]]
+				e.comps = append(e.comps, v)
+			}
+		}
+	}
+
+	sparse := make(map[uid.UID64][]string)
+	for i := range info.Sparse {
+		sp := &info.Sparse[i]
+		c := info.Components[sp.Component]
+		var rows [][]byte // nil for a tag
+		if c.Size > 0 {
+			var err error
+			if rows, err = info.SparseRows(sp); err != nil {
+				return nil, fmt.Errorf("sparse component %q: %w", c.Name, err)
+			}
+		}
+		for j, id := range sp.IDs {
+			e := entities[id]
+			if e == nil {
+				// A delta holds sparse components whole, entities it
+				// does not list included.
+				continue
+			}
+			sparse[id] = append(sparse[id], c.Name)
+			if c.Size == 0 {
+				continue
+			}
+			v := compValue{name: c.Name, raw: rows[j]}
+			if decode {
+				var err error
+				if v.value, err = c.Schema.Decode(bytes.NewReader(rows[j])); err != nil {
+					return nil, err
+				}
+			}
+			e.comps = append(e.comps, v)
+		}
+	}
+	for id, names := range sparse {
+		entities[id].composition += " sparse {" + strings.Join(names, ", ") + "}"
+	}
+	return entities, nil
+}
+
+// composition renders a's components, tags included, e.g. "{game.Pos, game.Vel}".
+func composition(info *persist.FileInfo, a *persist.ArchetypeInfo) string {
+	names := make([]string, len(a.Components))
+	for i, c := range a.Components {
+		names[i] = info.Components[c].Name
+	}
+	return "{" + strings.Join(names, ", ") + "}"
+}
+
+func sortedIDs[V any](m map[uid.UID64]V) []uid.UID64 {
+	ids := make([]uid.UID64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
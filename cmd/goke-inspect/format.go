@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kjkrol/goke/v3/internal/persist"
+)
+
+// formatValue renders a value decoded by persist.Schema.Decode in Go-like
+// syntax: {X: 1, Y: 2} for a struct, [1 2] for an array, a quoted string,
+// and an opaque value as the hex of its bytes.
+func formatValue(v any) string {
+	var b strings.Builder
+	writeValue(&b, v)
+	return b.String()
+}
+
+func writeValue(b *strings.Builder, v any) {
+	switch v := v.(type) {
+	case []persist.Field:
+		b.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(f.Name)
+			b.WriteString(": ")
+			writeValue(b, f.Value)
+		}
+		b.WriteByte('}')
+	case []any:
+		b.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			writeValue(b, elem)
+		}
+		b.WriteByte(']')
+	case []byte:
+		fmt.Fprintf(b, "0x%x", v)
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case float32:
+		b.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	case float64:
+		b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	default:
+		fmt.Fprint(b, v)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kjkrol/goke/v3"
+	"github.com/kjkrol/uid"
+)
+
+type position struct{ X, Y float32 }
+
+type label struct{ Name string }
+
+type stun struct{ Ticks int32 }
+
+type team struct{ ID uint8 }
+
+type bounds struct{ MinX, MaxX float32 }
+
+// saveWorld saves n entities with a position and a label, the i-th at
+// (i, y), and returns the file's path and the entities' IDs.
+func saveWorld(t *testing.T, n int, y float32) (string, []uid.UID64) {
+	t.Helper()
+	ecs := goke.New()
+	var pos goke.Comp[position]
+	var lbl goke.Comp[label]
+	var ids []uid.UID64
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		f := si.NewFactory(&pos, &lbl)
+		f.Create(n)
+		for f.Next() {
+			positions, labels := pos.Slice(&f.Cursor), lbl.Slice(&f.Cursor)
+			for i := range positions {
+				positions[i] = position{X: float32(len(ids) + i), Y: y}
+				labels[i] = label{Name: fmt.Sprintf("e%d", len(ids)+i)}
+			}
+			ids = append(ids, f.IDs...)
+		}
+	}})
+	path := filepath.Join(t.TempDir(), "save.bin")
+	ecs.Pause()
+	if err := ecs.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return path, ids
+}
+
+func runOutput(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := run(args, &out)
+	return out.String(), err
+}
+
+func TestInfo(t *testing.T) {
+	path, _ := saveWorld(t, 3, 0)
+	out, err := runOutput(t, "info", path)
+	if err != nil {
+		t.Fatalf("info: %v", err)
+	}
+	for _, want := range []string{
+		"format:     version ",
+		"ID pool:    next index 3, 3 allocated, 0 free",
+		"goke-inspect.position  size 8",
+		"struct{X float32; Y float32}",
+		"archetypes: 1 (3 entities)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("info output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	path, ids := saveWorld(t, 3, 0.5)
+	out, err := runOutput(t, "dump", "-id", fmt.Sprintf("%d,12345", ids[1]), path)
+	if err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("entity %d (index 1, generation ", ids[1]),
+		"position: {X: 1, Y: 0.5}",
+		`label: {Name: "e1"}`,
+		"entity 12345: not in the file",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dump output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `"e0"`) || strings.Contains(out, `"e2"`) {
+		t.Errorf("dump -id printed unselected entities:\n%s", out)
+	}
+
+	out, err = runOutput(t, "dump", "-hex", "-id", fmt.Sprint(ids[0]), path)
+	if err != nil {
+		t.Fatalf("dump -hex: %v", err)
+	}
+	if !strings.Contains(out, "position: 00 00 00 00 3f 00 00 00") {
+		t.Errorf("dump -hex output lacks the encoded position:\n%s", out)
+	}
+}
+
+// Sparse components show in info, and with each entity holding one in dump.
+func TestDump_SparseComponents(t *testing.T) {
+	ecs := goke.New()
+	stunID := ecs.RegComp[stun](goke.WithSparse())
+	var pos goke.Comp[position]
+	var ids []uid.UID64
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for cur := range si.NewFactory(&pos).Batches(2) {
+			ids = append(ids, cur.IDs...)
+		}
+	}})
+	sys := ecs.RegSys(goke.SystemFn{OnUpdate: func(cb *goke.CmdBuf, d time.Duration) {
+		cb.AddOne(ids[1], stunID, stun{Ticks: 3})
+	}})
+	ecs.SetPlan(func(ctx goke.RunCtx, d time.Duration) {
+		ctx.Run(sys, d)
+		ctx.Sync()
+	})
+	ecs.Tick(time.Millisecond)
+	path := filepath.Join(t.TempDir(), "save.bin")
+	ecs.Pause()
+	if err := ecs.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	out, err := runOutput(t, "info", path)
+	if err != nil {
+		t.Fatalf("info: %v", err)
+	}
+	if !strings.Contains(out, "sparse:     1") || !strings.Contains(out, "goke-inspect.stun  1 entities") {
+		t.Errorf("info output lacks the sparse component:\n%s", out)
+	}
+
+	out, err = runOutput(t, "dump", path)
+	if err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	for _, want := range []string{
+		"sparse {github.com/kjkrol/goke/v3/cmd/goke-inspect.stun}",
+		"stun: {Ticks: 3}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dump output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "stun:") != 1 {
+		t.Errorf("expected one stunned entity:\n%s", out)
+	}
+}
+
+// A shared value shows in dump with each entity of its archetype.
+func TestDump_SharedComponents(t *testing.T) {
+	ecs := goke.New()
+	ecs.RegComp[team](goke.WithShared())
+	var pos goke.Comp[position]
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for range si.NewFactory(&pos, goke.Share(team{ID: 7})).Batches(2) {
+		}
+	}})
+	path := filepath.Join(t.TempDir(), "save.bin")
+	ecs.Pause()
+	if err := ecs.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	out, err := runOutput(t, "dump", path)
+	if err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	if n := strings.Count(out, "team: {ID: 7}"); n != 2 {
+		t.Errorf("expected the shared value with both entities, got %d:\n%s", n, out)
+	}
+	if n := strings.Count(out, "position: "); n != 2 {
+		t.Errorf("expected both positions, got %d:\n%s", n, out)
+	}
+}
+
+// A per-chunk value shows in dump with each entity of its chunk.
+func TestDump_PerChunkComponents(t *testing.T) {
+	ecs := goke.New()
+	ecs.RegComp[bounds](goke.WithPerChunk())
+	var pos goke.Comp[position]
+	var b goke.ChunkComp[bounds]
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for cur := range si.NewFactory(&pos, &b).Batches(2) {
+			*b.Get(cur) = bounds{MinX: -1, MaxX: 1}
+		}
+	}})
+	path := filepath.Join(t.TempDir(), "save.bin")
+	ecs.Pause()
+	if err := ecs.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	out, err := runOutput(t, "dump", path)
+	if err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	if n := strings.Count(out, "bounds: {MinX: -1, MaxX: 1}"); n != 2 {
+		t.Errorf("expected the chunk's value with both entities, got %d:\n%s", n, out)
+	}
+	if n := strings.Count(out, "position: "); n != 2 {
+		t.Errorf("expected both positions, got %d:\n%s", n, out)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	oldPath, _ := saveWorld(t, 3, 0)
+	if _, err := runOutput(t, "diff", oldPath, oldPath); err != nil {
+		t.Errorf("diff of a file against itself: %v", err)
+	}
+
+	newPath, ids := saveWorld(t, 4, 2)
+	out, err := runOutput(t, "diff", oldPath, newPath)
+	if !errors.Is(err, errDiffer) {
+		t.Fatalf("diff error = %v, want errDiffer", err)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("~ %d github.com/kjkrol/goke/v3/cmd/goke-inspect.position: {X: 0, Y: 0} -> {X: 0, Y: 2}", ids[0]),
+		fmt.Sprintf("+ %d {", ids[3]),
+		"0 removed, 1 added, 3 changed, 0 unchanged",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("diff output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDiff_RejectsDelta(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[position]
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for range si.NewFactory(&pos).Batches(2) {
+		}
+	}})
+	dir := t.TempDir()
+	base, delta := filepath.Join(dir, "base.bin"), filepath.Join(dir, "delta.bin")
+	ecs.Pause()
+	if err := ecs.Save(base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := ecs.SaveDelta(delta); err != nil {
+		t.Fatalf("SaveDelta: %v", err)
+	}
+	if _, err := runOutput(t, "diff", base, delta); err == nil || errors.Is(err, errDiffer) || !strings.Contains(err.Error(), "delta") {
+		t.Errorf("diff against a delta = %v, want an error naming the delta", err)
+	}
+}
+
+func TestRun_RejectsBadUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"frobnicate"}, {"info"}, {"dump", "-id", "x", "f"}, {"diff", "a"}} {
+		if _, err := runOutput(t, args...); err == nil || errors.Is(err, errDiffer) {
+			t.Errorf("run(%q) = %v, want a usage error", args, err)
+		}
+	}
+}
@@ -17,7 +17,10 @@ type CmdBuf struct {
 // AddOne queues adding a component value to a single entity reached
 // outside a Query loop (an external event, a saved id) — not for entities
 // already being visited in a loop, where Query.BeginMigrate + Editor/
-// ValueEditor batches the change instead. Overwrites existing data.
+// ValueEditor batches the change instead. Overwrites existing data; for a
+// shared component (see [WithShared]), moves the entity to the archetype
+// holding value; for a per-chunk one (see [WithPerChunk]), overwrites the
+// value of the chunk the entity lands in.
 func (cb *CmdBuf) AddOne[T any](e uid.UID64, compID CompID, value T) {
 	orch.AddOne(cb.raw, e, compID, value)
 }
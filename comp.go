@@ -13,7 +13,9 @@ import (
 //
 // T must be encodable — recursively a bool, numeric kind, string, struct,
 // fixed-size array, or a type implementing encoding.BinaryMarshaler and
-// encoding.BinaryUnmarshaler — or RegComp panics.
+// encoding.BinaryUnmarshaler — or RegComp panics. A field tagged
+// `goke:"ref"` marks an entity reference, rewritten by [ECS.LoadMerge]; it
+// must be a uid.UID64.
 type Comp[T any] struct {
 	col iter.ArrayRef[T]
 }
@@ -26,19 +28,48 @@ func (c *Comp[T]) Slice(cur *Cursor) []T { return c.col.Slice(cur) }
 // At returns a pointer to the component for the current Pick/Seek-mode entity.
 func (c *Comp[T]) At(cur *Cursor) *T { return c.col.At(cur) }
 
-// Trackable is satisfied by *Comp[T] for any T — it lets NewQueryBuilder
-// accept components (&comp) directly as tracked data columns.
+// Maybe gives typed access to an optional component — one a Query tracks
+// via QueryBuilder.Maybe without requiring it. Its Slice and At return nil
+// for entities whose archetype lacks T; whether it is there is fixed per
+// chunk, so check Slice's result once per chunk, outside the inner loop.
+type Maybe[T any] struct {
+	col iter.ArrayRef[T]
+}
+
+// Slice returns the component slice for the current All-mode chunk, or nil
+// if the chunk's archetype lacks T.
+func (c *Maybe[T]) Slice(cur *Cursor) []T { return c.col.SliceOrNil(cur) }
+
+// At returns a pointer to the component for the current Pick/Seek-mode
+// entity, or nil if it lacks T.
+func (c *Maybe[T]) At(cur *Cursor) *T { return c.col.AtOrNil(cur) }
+
+// Trackable is satisfied by *Comp[T], *Sparse[T], *Shared[T] and
+// *ChunkComp[T] for any T — it lets NewQueryBuilder accept components
+// (&comp) directly as tracked data columns, or required sparse, shared or
+// per-chunk components.
 type Trackable interface {
-	// asTrack is unexported so *Comp[T] is the only implementer — this is a
-	// sealed interface, not an extension point.
+	// asTrack is unexported so *Comp[T], *Sparse[T], *Shared[T] and
+	// *ChunkComp[T] are the only implementers — this is a sealed interface,
+	// not an extension point.
 	asTrack() Opt
 }
 
-// Addable is satisfied by *Comp[T] for any T — it lets NewFactory and
-// NewEditorBuilder accept components (&comp) directly as added components.
+// Optional is satisfied by *Maybe[T] and *Sparse[T] for any T — it lets
+// QueryBuilder.Maybe accept optional components (&maybe) directly.
+type Optional interface {
+	// asOptional is unexported so *Maybe[T] and *Sparse[T] are the only
+	// implementers — this is a sealed interface, not an extension point.
+	asOptional() Opt
+}
+
+// Addable is satisfied by *Comp[T] and *ChunkComp[T] for any T, and by
+// Share's result — it lets NewFactory and NewEditorBuilder accept
+// components (&comp) directly as added components, and shared values to
+// set.
 type Addable interface {
-	// asAdd is unexported so *Comp[T] is the only implementer — this is a
-	// sealed interface, not an extension point.
+	// asAdd is unexported so *Comp[T], *ChunkComp[T] and Share are the only
+	// implementers — this is a sealed interface, not an extension point.
 	asAdd() EditOpt
 }
 
@@ -46,6 +77,8 @@ func (c *Comp[T]) asTrack() Opt      { return comp.Track[T](&c.col) }
 func (c *Comp[T]) asAdd() EditOpt    { return comp.Add[T](&c.col) }
 func (c *Comp[T]) asLoad() CompToken { return LoadComp[T]() }
 
+func (c *Maybe[T]) asOptional() Opt { return comp.Optional[T](&c.col) }
+
 // Loadable is satisfied by *Comp[T] for any T — it lets LoadComps accept
 // components (&comp) directly instead of naming their type again.
 type Loadable interface {
@@ -59,7 +92,47 @@ func Remove[T any]() EditOpt { return comp.Del[T]() }
 
 // LoadComp declares that a Load call may need to register component type
 // T — see [ECS.Load]. The order tokens are passed to Load does not matter.
-func LoadComp[T any]() CompToken { return reg.LoadComp[T]() }
+// Pass the same opts T is registered with (see [ECS.RegComp]); a token for
+// a named type also matches data saved under its Go type, before it was
+// named.
+func LoadComp[T any](opts ...CompOpt) CompToken { return reg.LoadComp[T](opts...) }
+
+// WithName registers a component under a stable, user-chosen name —
+// recorded in save files instead of its Go import path and type name, so
+// renaming or moving the type doesn't orphan saved data. Names must be
+// unique across components.
+func WithName(name string) CompOpt { return comp.WithName(name) }
+
+// WithAliases adds former names a component's saved data may be recorded
+// under — e.g. its WithName name, or its Go import path and type name,
+// before a rename.
+func WithAliases(aliases ...string) CompOpt { return comp.WithAliases(aliases...) }
+
+// WithSparse stores a component in a sparse set keyed by entity instead of
+// in archetype chunks — see [Sparse]. Adding or removing it is O(1) and
+// never migrates the entity; in exchange, queries test it per entity, and
+// it cannot be spawned by a Factory or changed by an Editor. Save writes
+// sparse values keyed by entity; pass WithSparse to LoadComp as well.
+func WithSparse() CompOpt { return comp.WithSparse() }
+
+// WithShared stores one value of a component per archetype instead of one
+// per entity — see [Shared]. Setting it moves the entity to the archetype
+// holding the value, which costs a migration but frees chunk space for the
+// per-entity columns. T must have data and be comparable by its bytes — no
+// string or BinaryMarshaler fields — and cannot also be sparse, nor hold
+// `goke:"ref"` fields, which LoadMerge could not remap. Save writes each
+// archetype's shared values; pass WithShared to LoadComp as well.
+func WithShared() CompOpt { return comp.WithShared() }
+
+// WithPerChunk stores one value of a component per chunk instead of one
+// per entity — see [ChunkComp]. Adding or removing it still moves the
+// entity between archetypes, but the value belongs to the chunk the entity
+// lands in. T must have data and no string, pointer or BinaryMarshaler
+// fields, and cannot also be sparse or shared. Save writes one value per
+// chunk; Load gives each chunk it fills the value of the last saved chunk
+// whose entities it received, so values come back whole only at the same
+// chunk size. Pass WithPerChunk to LoadComp as well.
+func WithPerChunk() CompOpt { return comp.WithPerChunk() }
 
 // ProvidedComps collects LoadComps from every value that implements
 // CompProvider, in order — values that don't implement it are skipped.
@@ -70,6 +143,8 @@ func ProvidedComps(values ...any) []CompToken { return reg.ProvidedComps(values.
 // LoadComps builds a CompToken per component, taking already-declared
 // Comp[T] fields directly (&comp) instead of naming each type again —
 // e.g. LoadComps(&m.pos, &m.vel) inside a CompProvider.LoadComps method.
+// A Comp[T] carries no registration options — use LoadComp[T](opts...)
+// for a component registered WithName.
 func LoadComps(comps ...Loadable) []CompToken {
 	out := make([]CompToken, len(comps))
 	for i, c := range comps {
@@ -1,5 +1,11 @@
 package goke
 
+import (
+	"fmt"
+
+	"github.com/kjkrol/goke/v3/internal/colstore"
+)
+
 // ECSOption defines a function signature for configuring the ECS.
 type ECSOption func(*Config)
 
@@ -16,3 +22,41 @@ func WithEntityFreeCap(cap int) ECSOption {
 		c.Entity.FreeCap = cap
 	}
 }
+
+// WithChunkBytes sets the target size of archetype chunks, in place of the
+// platform's L1 data cache size (32 KB, or 96 KB on arm64). Use
+// [ECS.SetChunkBytes] to size a single archetype differently. New panics
+// if bytes is not positive.
+func WithChunkBytes(bytes int) ECSOption {
+	return func(c *Config) {
+		if bytes <= 0 {
+			panic(fmt.Sprintf("goke: WithChunkBytes: chunk size must be positive, got %d", bytes))
+		}
+		c.Entity.Chunk.Bytes = uintptr(bytes)
+	}
+}
+
+// WithCacheSets sets the L1 data cache geometry chunk layouts avoid
+// set conflicts in: the number of cache sets and the line size in bytes.
+// New panics if either is not positive.
+func WithCacheSets(sets, lineBytes int) ECSOption {
+	return func(c *Config) {
+		if sets <= 0 || lineBytes <= 0 {
+			panic(fmt.Sprintf("goke: WithCacheSets: sets and line size must be positive, got %d and %d", sets, lineBytes))
+		}
+		c.Entity.Chunk.Sets = sets
+		c.Entity.Chunk.LineBytes = uintptr(lineBytes)
+	}
+}
+
+// WithDetectedCache sizes chunks for the host's L1 data cache, read from
+// /sys/devices/system/cpu/cpu0/cache. Where that is unavailable (outside
+// Linux, or in a restricted container) the configuration is left as is.
+// Options after it override what it detects.
+func WithDetectedCache() ECSOption {
+	return func(c *Config) {
+		if g, err := colstore.DetectGeometry(); err == nil {
+			c.Entity.Chunk = g
+		}
+	}
+}
@@ -45,3 +45,114 @@ func TestECSOptions_AppliedByNew(t *testing.T) {
 		t.Errorf("expected 10 entities created, got %d", total)
 	}
 }
+
+func TestWithChunkBytesAndCacheSets(t *testing.T) {
+	var c goke.Config
+	goke.WithChunkBytes(48 * 1024)(&c)
+	goke.WithCacheSets(64, 64)(&c)
+
+	if c.Entity.Chunk.Bytes != 48*1024 {
+		t.Errorf("expected Entity.Chunk.Bytes 48K, got %d", c.Entity.Chunk.Bytes)
+	}
+	if c.Entity.Chunk.Sets != 64 || c.Entity.Chunk.LineBytes != 64 {
+		t.Errorf("expected 64 sets of 64-byte lines, got %+v", c.Entity.Chunk)
+	}
+}
+
+// Detection may be unavailable on the host; when it succeeds, it must
+// produce a complete geometry.
+func TestWithDetectedCache(t *testing.T) {
+	var c goke.Config
+	goke.WithDetectedCache()(&c)
+
+	if g := c.Entity.Chunk; g.Bytes != 0 && (g.Sets <= 0 || g.LineBytes == 0) {
+		t.Errorf("expected a complete detected geometry, got %+v", g)
+	}
+}
+
+// largestChunk spawns n entities of each composition in groups into ecs
+// and returns, per group, the most rows any one of its chunks holds.
+func largestChunk(t *testing.T, ecs *goke.ECS, n int, groups ...[]goke.Addable) []int {
+	t.Helper()
+	var pos goke.Comp[Position]
+	queries := make([]*goke.Query, len(groups))
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for i, comps := range groups {
+			f := si.NewFactory(comps...)
+			f.Create(n)
+			for f.Next() {
+			}
+			qb := si.NewQueryBuilder(&pos)
+			if len(comps) == 1 {
+				qb = qb.Exclude(goke.Exclude[Velocity]())
+			}
+			queries[i] = qb.Build()
+		}
+	}})
+	most := make([]int, len(groups))
+	for i, q := range queries {
+		for cur := range q.Chunks() {
+			most[i] = max(most[i], len(cur.IDs))
+		}
+	}
+	return most
+}
+
+func TestWithChunkBytes_SizesChunks(t *testing.T) {
+	var pos goke.Comp[Position]
+	small := largestChunk(t, goke.New(goke.WithChunkBytes(1024)), 2000, []goke.Addable{&pos})
+	large := largestChunk(t, goke.New(goke.WithChunkBytes(8*1024)), 2000, []goke.Addable{&pos})
+
+	if small[0] > 1024/16 {
+		t.Errorf("expected at most %d rows per 1 KB chunk, got %d", 1024/16, small[0])
+	}
+	if large[0] <= small[0] {
+		t.Errorf("expected 8 KB chunks to hold more rows than 1 KB ones: %d <= %d", large[0], small[0])
+	}
+}
+
+func TestECS_SetChunkBytes(t *testing.T) {
+	var pos goke.Comp[Position]
+	var vel goke.Comp[Velocity]
+	ecs := goke.New(goke.WithChunkBytes(1024))
+	if err := ecs.SetChunkBytes(16*1024, &pos, &vel); err != nil {
+		t.Fatal(err)
+	}
+
+	most := largestChunk(t, ecs, 2000, []goke.Addable{&pos}, []goke.Addable{&pos, &vel})
+	if most[0] > 1024/16 {
+		t.Errorf("expected the default 1 KB chunks for Position alone, got %d rows", most[0])
+	}
+	if most[1] <= 1024/24 {
+		t.Errorf("expected the hot archetype's override to enlarge its chunks, got %d rows", most[1])
+	}
+
+	if err := ecs.SetChunkBytes(4096, &pos, &vel); err == nil {
+		t.Error("expected an error once the archetype exists")
+	}
+	if err := ecs.SetChunkBytes(0, &pos); err == nil {
+		t.Error("expected an error for a non-positive size")
+	}
+}
+
+func TestWithChunkBytes_PanicsOnNonPositive(t *testing.T) {
+	for _, bytes := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected New to panic for WithChunkBytes(%d)", bytes)
+				}
+			}()
+			goke.New(goke.WithChunkBytes(bytes))
+		}()
+	}
+}
+
+func TestWithCacheSets_PanicsOnNonPositive(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected New to panic for a negative line size")
+		}
+	}()
+	goke.New(goke.WithCacheSets(64, -64))
+}
@@ -32,7 +32,11 @@
 //     Query, Editor, ValueEditor, and Factory can only be constructed inside
 //     a System's Init (via [SysInit]) or a one-time [ECS.Setup] — never
 //     directly on ECS — so every read and structural change flows through a
-//     system. The order and concurrency of execution are defined via a Plan.
+//     system. The exception is [ECS.View]: a transient, read-only Query for
+//     inspecting a paused world from tools and tests. [SysInit.ParseQuery]
+//     and [ECS.ParseView] build queries from strings such as
+//     "Pos, Vel, !Frozen, Team|Npc", with reflective access via [DynQuery]. The order and
+//     concurrency of execution are defined via a Plan.
 //
 //  4. Thread Safety & Parallelism:
 //     The engine allows for synchronous or parallel system execution. While the engine
@@ -59,6 +63,18 @@
 //     subset queries via Query.Pick or single-entity access via Query.Seek
 //     yield per-entity component pointers resolved via the entity-to-storage
 //     index. All access is zero-allocation and reflection-free.
+//     [Query.PickSet] visits a large, unsorted [EntitySet] chunk by chunk,
+//     resolving offsets once per chunk instead of per archetype change.
+//     [QueryBuilder.Where] filters by component value; [WhereRange] keeps
+//     per-chunk min/max summaries so whole chunks are skipped untested.
+//     [ECS.NewHashIndex] and [ECS.NewOrderedIndex] index a component field,
+//     rebuilt at every Sync that changed them, and return IDs ready for
+//     Query.Pick.
+//     [SortBy] orders a Query's entities by a component key, re-sorting
+//     incrementally when few keys changed.
+//     [Query.Chunks], [Query.Entities], [Query.Picked] and Factory.Batches
+//     are range-over-func forms of the same loops; they inline to the
+//     manual All/Next code.
 //
 // # Hardware Constraints & Limits
 //
@@ -78,7 +94,10 @@
 //
 //   - Query Complexity: A single [Query] can track any number of component columns
 //     declared via [Comp][T]. Additional types can be used as filter-only
-//     constraints via Include/Exclude opts without occupying tracked columns.
+//     constraints via Include/Exclude opts without occupying tracked columns,
+//     and [QueryBuilder.Maybe] tracks columns an entity need not have.
+//     [AnyOf], [OneOf] and [AllOf] combine filter opts into boolean
+//     expressions, resolved once per archetype.
 //
 // # Internal Package Dependencies
 //
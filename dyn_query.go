@@ -0,0 +1,103 @@
+package goke
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// DynQuery is a Query built from a string expression at run time, for debug
+// consoles, scripting and test fixtures, with reflective access to its
+// tracked columns in place of Comp[T]. Build one with SysInit.ParseQuery,
+// or ECS.ParseView on a paused world. Expressions name components by their
+// registered name (WithName), alias, or Go type name:
+//
+//	"Pos, Vel, !Frozen, Team|Npc"
+//
+// A bare name tracks a data column (or requires a tag), "?Vel" tracks an
+// optional column, "!Frozen" excludes, and "A|B" matches either; inside
+// "|" and parentheses names are filters only, so "(A, !B)|C" reads as
+// AnyOf(AllOf(Include A, Exclude B), Include C).
+type DynQuery struct {
+	*Query
+	defs []comp.Def
+}
+
+// ParseQuery builds a DynQuery from the expression src — see DynQuery for
+// the syntax. It returns an error if src is malformed, names an unknown or
+// ambiguous component, or both requires and excludes one.
+func (s *SysInit) ParseQuery(src string) (*DynQuery, error) {
+	m, defs, err := s.ecs.registry.ParseMatcher(src)
+	if err != nil {
+		return nil, fmt.Errorf("goke: ParseQuery: %w", err)
+	}
+	return &DynQuery{Query: &Query{m: m, ecs: s.ecs}, defs: defs}, nil
+}
+
+// ParseView is ParseQuery for a read-only View — see ECS.View: it requires
+// a paused ECS, and the result must be released with Release.
+func (ecs *ECS) ParseView(src string) (*DynQuery, error) {
+	if !ecs.Paused() {
+		return nil, errors.New("goke: ParseView needs a paused ECS — call Pause() first")
+	}
+	q, err := ecs.sysInit.ParseQuery(src)
+	if err != nil {
+		return nil, err
+	}
+	q.view = true
+	ecs.views++
+	return q, nil
+}
+
+// Columns returns the keys of the tracked components, in the order Value
+// indexes them.
+func (q *DynQuery) Columns() []string {
+	keys := make([]string, len(q.defs))
+	for i, def := range q.defs {
+		keys[i] = def.Key
+	}
+	return keys
+}
+
+// Value returns the col'th tracked component of the entity at cur, as an
+// addressable reflect.Value — set its fields to write through. It is the
+// zero Value for an optional column the entity lacks.
+func (q *DynQuery) Value(cur *Cursor, col int) reflect.Value {
+	off := cur.Offsets[col]
+	if off == iter.Absent {
+		return reflect.Value{}
+	}
+	def := q.defs[col]
+	return reflect.NewAt(def.Type, unsafe.Add(cur.Base, off+cur.Slot*def.Size)).Elem()
+}
+
+// Format renders the tracked components of the entity at cur, e.g.
+// "Pos{X:1 Y:2} Health(7)"; an absent optional column shows as
+// "Vel(absent)".
+func (q *DynQuery) Format(cur *Cursor) string {
+	var b strings.Builder
+	for i, def := range q.defs {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		name := def.Type.Name()
+		if name == "" {
+			name = def.Key
+		}
+		b.WriteString(name)
+		switch v := q.Value(cur, i); {
+		case !v.IsValid():
+			b.WriteString("(absent)")
+		case v.Kind() == reflect.Struct:
+			fmt.Fprintf(&b, "%+v", v.Interface())
+		default:
+			fmt.Fprintf(&b, "(%v)", v.Interface())
+		}
+	}
+	return b.String()
+}
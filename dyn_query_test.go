@@ -0,0 +1,75 @@
+package goke_test
+
+import (
+	"testing"
+
+	"github.com/kjkrol/goke/v3"
+	"github.com/kjkrol/uid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	frozen   struct{ Ticks int }
+	vitality int32
+)
+
+func TestSysInit_ParseQuery(t *testing.T) {
+	ecs := goke.New()
+	ecs.RegComp[velocity](goke.WithName("game.Vel"))
+	var pos goke.Comp[position]
+	var hp goke.Comp[vitality]
+	var moving, still, icy uid.UID64
+	var q *goke.DynQuery
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		moving = si.NewFactory(&pos, &hp, new(goke.Comp[velocity])).SpawnAll(1)[0]
+		still = si.NewFactory(&pos, &hp).SpawnAll(1)[0]
+		icy = si.NewFactory(&pos, &hp, new(goke.Comp[frozen])).SpawnAll(1)[0]
+
+		var err error
+		q, err = si.ParseQuery("position, vitality, ?game.Vel, !frozen")
+		require.NoError(t, err)
+
+		_, err = si.ParseQuery("position, !position")
+		assert.ErrorContains(t, err, "both REQUIRED and EXCLUDED")
+		_, err = si.ParseQuery("position, Unknown")
+		assert.ErrorContains(t, err, `unknown component "Unknown"`)
+	}})
+	for i, id := range []uid.UID64{moving, still, icy} {
+		q.Seek(id)
+		*pos.At(q.Cursor()) = position{X: float64(i), Y: 1}
+		*hp.At(q.Cursor()) = vitality(10 * i)
+	}
+
+	assert.Equal(t, []string{"github.com/kjkrol/goke/v3_test.position", "github.com/kjkrol/goke/v3_test.vitality", "game.Vel"}, q.Columns())
+	got := map[uid.UID64]string{}
+	for id, cur := range q.Entities() {
+		got[id] = q.Format(cur)
+	}
+	assert.Equal(t, map[uid.UID64]string{
+		moving: "position{X:0 Y:1} vitality(0) velocity{VX:0 VY:0}",
+		still:  "position{X:1 Y:1} vitality(10) velocity(absent)",
+	}, got)
+
+	// Values are addressable: writes go to the world.
+	require.True(t, q.Seek(still))
+	q.Value(q.Cursor(), 1).SetInt(99)
+	assert.Equal(t, vitality(99), *hp.At(q.Cursor()))
+}
+
+func TestECS_ParseView(t *testing.T) {
+	ecs := goke.New()
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		si.NewFactory(new(goke.Comp[position])).SpawnAll(2)
+	}})
+
+	_, err := ecs.ParseView("position")
+	assert.ErrorContains(t, err, "paused")
+
+	ecs.Pause()
+	v, err := ecs.ParseView("position")
+	require.NoError(t, err)
+	assert.Equal(t, 2, v.Count())
+	v.Release()
+	ecs.Resume()
+}
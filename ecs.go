@@ -1,9 +1,13 @@
 package goke
 
 import (
+	"fmt"
+	"io"
 	"reflect"
 	"time"
 
+	"github.com/kjkrol/uid"
+
 	"github.com/kjkrol/goke/v3/internal/orch"
 	"github.com/kjkrol/goke/v3/internal/reg"
 )
@@ -17,6 +21,8 @@ type ECS struct {
 	scheduler orch.Scheduler
 	sysInit   SysInit
 	setupDone bool
+	ticking   bool
+	views     int // Views built and not yet released
 }
 
 // New creates a new ECS instance. Use ECSOption functions to tune memory
@@ -38,9 +44,13 @@ func New(opts ...ECSOption) *ECS {
 // RegComp registers the component type T with the ECS and returns its ID.
 // Call once at startup; subsequent calls for the same type return the
 // cached ID. Panics if T isn't encodable — see [Comp] for the exact rule.
-func (ecs *ECS) RegComp[T any]() CompID {
+//
+// opts give T a stable identity in save files ([WithName], [WithAliases]);
+// pass the same opts to [LoadComp]. A named type must be registered before
+// any query or factory first mentions it, since that registers it unnamed.
+func (ecs *ECS) RegComp[T any](opts ...CompOpt) CompID {
 	compType := reflect.TypeFor[T]()
-	return ecs.registry.RegComp(compType)
+	return ecs.registry.RegComp(compType, opts...)
 }
 
 // RegSys registers a system. The system's Init method is called
@@ -92,6 +102,19 @@ func (ecs *ECS) Setup(systems ...System) {
 	}
 }
 
+// SetChunkBytes sizes the chunks of the archetype made of exactly comps
+// for bytes instead of the WithChunkBytes target — e.g. larger chunks for a
+// hot archetype iterated every tick. Call before that archetype is first
+// created (by a factory, editor or entity); afterwards it returns an error.
+// The override survives Reset.
+func (ecs *ECS) SetChunkBytes(bytes int, comps ...Addable) error {
+	opts := make([]EditOpt, len(comps))
+	for i, c := range comps {
+		opts[i] = c.asAdd()
+	}
+	return ecs.registry.SetChunkBytes(bytes, opts...)
+}
+
 // SetPlan sets the execution plan that controls how systems run each tick.
 // Call before the first Tick; replaces any previously set plan.
 func (ecs *ECS) SetPlan(plan Plan) {
@@ -104,7 +127,10 @@ func (ecs *ECS) Tick(duration time.Duration) {
 	if ecs.registry.Paused() {
 		panic("goke: Tick called while the ECS is paused — call Resume() first")
 	}
+	ecs.ticking = true
+	defer func() { ecs.ticking = false }()
 	ecs.scheduler.Tick(duration)
+	ecs.registry.CaptureSaves()
 }
 
 // Pause stops Tick from running (panics until Resume) — also required
@@ -112,8 +138,14 @@ func (ecs *ECS) Tick(duration time.Duration) {
 func (ecs *ECS) Pause() { ecs.registry.Pause() }
 
 // Resume clears the paused state set by Pause, allowing Tick again.
-// Idempotent — calling it while not paused is a no-op.
-func (ecs *ECS) Resume() { ecs.registry.Resume() }
+// Idempotent — calling it while not paused is a no-op. Panics if a Query
+// built by View has not been released.
+func (ecs *ECS) Resume() {
+	if ecs.views > 0 {
+		panic(fmt.Sprintf("goke: Resume with %d unreleased View(s) — call Release on each first", ecs.views))
+	}
+	ecs.registry.Resume()
+}
 
 // Paused reports whether the ECS is currently paused.
 func (ecs *ECS) Paused() bool { return ecs.registry.Paused() }
@@ -125,6 +157,27 @@ func (ecs *ECS) Reset() {
 	ecs.scheduler.Reset()
 	ecs.registry.Reset()
 	ecs.setupDone = false
+	ecs.views = 0
+}
+
+// View starts a QueryBuilder for a transient, read-only Query, tracking the
+// given components — for debug tools, test assertions and editor panels
+// that inspect the world from outside any system:
+//
+//	ecs.Pause()
+//	v := ecs.View(&pos).Exclude(goke.Exclude[Dead]()).Build()
+//	for id, cur := range v.Entities() { fmt.Println(id, pos.At(cur)) }
+//	v.Release()
+//
+// Build panics unless the ECS is paused. Release the view (Query.Release)
+// when done, freeing its matcher slot for reuse — Resume panics while any
+// view is unreleased.
+// A view cannot start structural changes: BeginMigrate and the editor
+// builders panic on it.
+func (ecs *ECS) View(comps ...Trackable) *QueryBuilder {
+	b := ecs.sysInit.NewQueryBuilder(comps...)
+	b.view = true
+	return b
 }
 
 // Save writes a full snapshot of the world to path — every entity and
@@ -132,6 +185,39 @@ func (ecs *ECS) Reset() {
 // (panics otherwise).
 func (ecs *ECS) Save(path string) error { return ecs.registry.Save(path) }
 
+// SaveOnly is Save restricted to the entities whose archetype matches
+// filter — the Include/Exclude/AnyOf (or Track) opts a query takes, e.g.
+// SaveOnly(path, Include[Persistent]()) or SaveOnly(path,
+// Exclude[Particle]()) — so transient entities never reach disk. Load
+// releases the IDs of entities left out. Requires a prior [ECS.Pause].
+func (ecs *ECS) SaveOnly(path string, filter ...Opt) error {
+	return ecs.registry.SaveOnly(path, filter)
+}
+
+// SaveAsync starts a full save to w without stopping the simulation, and
+// returns a handle to wait on or cancel it. The world is copied at the
+// next Sync — cheap, no per-value encoding — after which ticking goes on
+// while the copy is encoded and compressed on a background goroutine.
+// Called between ticks, it copies the world right away; called from a
+// system, at its plan's next Sync, or at the end of the Tick if none
+// follows. Needs no [ECS.Pause], and does not start a [ECS.SaveDelta]
+// chain. w must not be touched until the save is done.
+func (ecs *ECS) SaveAsync(w io.Writer) *SaveJob {
+	job := ecs.registry.SaveAsync(w)
+	if !ecs.ticking {
+		ecs.registry.CaptureSaves()
+	}
+	return job
+}
+
+// SaveDelta writes to path only what changed since the last Save,
+// SaveDelta, Load, or LoadDeltas — entities removed since, plus every
+// entity of each chunk whose content changed — far smaller than a full
+// Save for a mostly static world. Apply with [ECS.LoadDeltas] on top of the
+// snapshot it was taken against. Requires a prior [ECS.Pause] (panics
+// otherwise); returns an error if nothing has been saved or loaded yet.
+func (ecs *ECS) SaveDelta(path string) error { return ecs.registry.SaveDelta(path) }
+
 // Load reads a snapshot written by Save into ecs — components, archetypes,
 // and entities, with original IDs. Must run before Setup or any other
 // registration (panics otherwise); matches comps by name, any order — see
@@ -139,3 +225,62 @@ func (ecs *ECS) Save(path string) error { return ecs.registry.Save(path) }
 func (ecs *ECS) Load(path string, comps ...CompToken) error {
 	return ecs.registry.Load(path, comps)
 }
+
+// LoadOnly is Load restricted to the saved entities whose archetype
+// matches filter (see [ECS.SaveOnly]); the rest are skipped, their IDs
+// released. Every component in the file is still registered. Same
+// preconditions as Load.
+func (ecs *ECS) LoadOnly(path string, filter []Opt, comps ...CompToken) error {
+	return ecs.registry.LoadOnly(path, filter, comps)
+}
+
+// LoadMerge adds the entities saved at path to ecs as it stands — for
+// importing a level chunk or blueprint into a live world. Unlike Load, it
+// may run once the world is set up, with components registered and
+// entities alive, but like Save it requires a prior [ECS.Pause] (panics
+// otherwise). Every loaded entity gets a fresh ID, and the returned map
+// takes each saved ID to its new one. Components are matched as by
+// Load (see [LoadComp]), registering any not yet known.
+//
+// A uid.UID64 component field tagged `goke:"ref"` is an entity reference:
+// one holding a saved ID is rewritten to the new ID, so links between the
+// merged entities survive; one holding an ID from outside the file is
+// left as is.
+func (ecs *ECS) LoadMerge(path string, comps ...CompToken) (map[uid.UID64]uid.UID64, error) {
+	return ecs.registry.LoadMerge(path, comps)
+}
+
+// LoadDeltas applies, in order, deltas written by [ECS.SaveDelta] on top of
+// the snapshot last loaded — e.g. Load(base, comps...) then
+// LoadDeltas(d1, d2, d3). Each delta records the snapshot it was taken
+// against; a delta applied out of order returns an error. A damaged delta
+// is rejected whole, before it changes anything, leaving the ones before
+// it applied. Every component a delta needs must already be registered
+// (pass its token to Load). Requires a prior [ECS.Pause] (panics
+// otherwise).
+func (ecs *ECS) LoadDeltas(paths ...string) error {
+	return ecs.registry.LoadDeltas(paths...)
+}
+
+// ExportJSON writes the world to w as indented JSON — component
+// definitions, archetypes, and every entity with its component values as
+// objects keyed by field name — for debugging, diffing, and hand-editing.
+// Requires a prior [ECS.Pause] (panics otherwise), like Save.
+func (ecs *ECS) ExportJSON(w io.Writer) error { return ecs.registry.ExportJSON(w) }
+
+// ImportJSON rebuilds the world from a document written by ExportJSON, or
+// a hand-authored fixture in the same shape: the "pool" object may be left
+// out, as may any component of an entity or field of a component (each is
+// zeroed). Components are matched as by Load (see [LoadComp]); same
+// preconditions as Load.
+func (ecs *ECS) ImportJSON(r io.Reader, comps ...CompToken) error {
+	return ecs.registry.ImportJSON(r, comps)
+}
+
+// Verify checks the save file at path — a snapshot or a delta — without
+// loading it or registering anything: every section's length and CRC32,
+// plus the gzip stream's own checksum. A damaged section is reported as a
+// [SectionError] naming it and, within entity data, the archetype's
+// components and the column. Files written before format version 4 carry
+// no section checksums; for those only the gzip checksum is checked.
+func Verify(path string) error { return reg.Verify(path) }
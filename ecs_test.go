@@ -273,3 +273,90 @@ func TestECS_Setup_AllowedAgainAfterReset(t *testing.T) {
 	ecs.Reset()
 	ecs.Setup(goke.SystemFn{})
 }
+
+func TestECS_View(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[Position]
+	var ids []uid.UID64
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		ids = si.NewFactory(&pos).SpawnAll(3)
+		si.NewFactory(&pos, new(goke.Comp[Velocity])).SpawnAll(2)
+	}})
+
+	ecs.Pause()
+	for range 100 { // more than the catalog holds, unless slots are reused
+		var p goke.Comp[Position]
+		v := ecs.View(&p).Exclude(goke.Exclude[Velocity]()).Build()
+		var seen []uid.UID64
+		for id := range v.Entities() {
+			seen = append(seen, id)
+		}
+		if len(seen) != len(ids) {
+			t.Fatalf("expected %d entities, got %d", len(ids), len(seen))
+		}
+		v.Release()
+	}
+
+	v := ecs.View(&pos).Build()
+	mustPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: expected a panic", name)
+			}
+		}()
+		fn()
+	}
+	mustPanic("NewEditorBuilder", func() { v.NewEditorBuilder() })
+	mustPanic("Resume with an unreleased View", ecs.Resume)
+	v.Release()
+	ecs.Resume()
+
+	mustPanic("View while running", func() { ecs.View(&pos).Build() })
+}
+
+func TestQuery_Release(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[Position]
+	var queries []*goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for range 200 { // well past the catalog's first block
+			queries = append(queries, si.NewQueryBuilder(&pos).Build())
+		}
+	}})
+	for _, q := range queries[:100] {
+		q.Release()
+	}
+
+	// Spawning creates the archetype after the releases: the live queries
+	// must see it, and queries built into released slots too.
+	var factory *goke.Factory
+	var rebuilt *goke.Query
+	sys := ecs.RegSys(goke.SystemFn{
+		OnInit: func(si *goke.SysInit) {
+			factory = si.NewFactory(&pos)
+			rebuilt = si.NewQueryBuilder(&pos).Build()
+		},
+		OnUpdate: func(cb *goke.CmdBuf, d time.Duration) { factory.SpawnAll(3) },
+	})
+	ecs.SetPlan(func(s goke.RunCtx, d time.Duration) {
+		s.Run(sys, d)
+		s.Sync()
+	})
+	ecs.Tick(time.Millisecond)
+
+	for i, q := range append(queries[100:], rebuilt) {
+		if n := q.Count(); n != 3 {
+			t.Fatalf("query %d: expected 3 entities, got %d", i, n)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic on a second Release")
+		}
+	}()
+	q := queries[150]
+	q.Release()
+	q.Release()
+}
@@ -0,0 +1,54 @@
+package goke
+
+import (
+	"cmp"
+
+	"github.com/kjkrol/goke/v3/internal/query"
+)
+
+type (
+	// HashIndex maps a component field to the entities holding each value,
+	// for exact-match lookups. Create via ECS.NewHashIndex; look up with
+	// Get, which returns IDs ready for Query.Pick.
+	HashIndex[K comparable] = query.HashIndex[K]
+
+	// OrderedIndex keeps entities sorted by a component field, for range
+	// scans. Create via ECS.NewOrderedIndex; scan with Range or Get, which
+	// return IDs ready for Query.Pick.
+	OrderedIndex[K cmp.Ordered] = query.OrderedIndex[K]
+)
+
+// NewHashIndex registers an index of every entity with a T by key(T) — e.g.
+// "the entity whose PlayerID is 42":
+//
+//	byPlayer := ecs.NewHashIndex(func(p *Player) int { return p.ID })
+//	for q.Pick(byPlayer.Get(42)); q.Next(); { ... }
+//
+// The index is built at once and rebuilt at the end of every Sync, after
+// the queued adds, removes and value writes are applied, so lookups between
+// two Syncs see the world as of the first; each rebuild is one pass over
+// the entities with a T, skipped when none was added, removed or written
+// since the last. Call Refresh to pick up changes sooner, e.g. after a
+// LoadDeltas. Returned slices are owned by the index and valid until the
+// next rebuild. ECS.Reset drops the index: using it afterwards panics.
+func (ecs *ECS) NewHashIndex[T any, K comparable](key func(*T) K) *HashIndex[K] {
+	c := new(Comp[T])
+	ix := query.NewHashIndex(ecs.registry.AddMatcher(c.asTrack()), func(cur *Cursor) K {
+		return key(c.At(cur))
+	})
+	ecs.registry.MatcherCatalog.AddIndex(ix)
+	return ix
+}
+
+// NewOrderedIndex registers an index of every entity with a T sorted by
+// key(T), for range scans such as "every entity with X in [a, b]" via
+// Range(a, b). It is maintained like [ECS.NewHashIndex]; each rebuild
+// re-sorts incrementally, so it is cheap when few keys changed.
+func (ecs *ECS) NewOrderedIndex[T any, K cmp.Ordered](key func(*T) K) *OrderedIndex[K] {
+	c := new(Comp[T])
+	ix := query.NewOrderedIndex(ecs.registry.AddMatcher(c.asTrack()), func(cur *Cursor) K {
+		return key(c.At(cur))
+	})
+	ecs.registry.MatcherCatalog.AddIndex(ix)
+	return ix
+}
@@ -0,0 +1,64 @@
+package goke_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kjkrol/goke/v3"
+	"github.com/kjkrol/uid"
+	"github.com/stretchr/testify/assert"
+)
+
+type team struct{ ID int }
+
+func TestECS_Indexes(t *testing.T) {
+	ecs := goke.New()
+	var tm goke.Comp[team]
+	var pos goke.Comp[position]
+	var ids []uid.UID64
+	var query *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		ids = si.NewFactory(&tm, &pos).SpawnAll(4)
+		query = si.NewQueryBuilder(&tm, &pos).Build()
+	}})
+	for i, id := range ids {
+		query.Seek(id)
+		tm.At(query.Cursor()).ID = i % 2
+		pos.At(query.Cursor()).X = float64(i)
+	}
+
+	byTeam := ecs.NewHashIndex(func(t *team) int { return t.ID })
+	byX := ecs.NewOrderedIndex(func(p *position) float64 { return p.X })
+	assert.Equal(t, []uid.UID64{ids[1], ids[3]}, byTeam.Get(1))
+	assert.Equal(t, []uid.UID64{ids[1], ids[2]}, byX.Range(0.5, 2))
+
+	var picked []uid.UID64
+	for q := query.Pick(byTeam.Get(0)); q.Next(); {
+		picked = append(picked, q.Entity())
+	}
+	assert.Equal(t, []uid.UID64{ids[0], ids[2]}, picked)
+
+	// A write and a removal show up after the next Sync.
+	sys := ecs.RegSys(goke.SystemFn{OnUpdate: func(cb *goke.CmdBuf, d time.Duration) {
+		query.Seek(ids[0])
+		tm.At(query.Cursor()).ID = 1
+		pos.At(query.Cursor()).X = 10
+		cb.RemoveOne(ids[3])
+	}})
+	ecs.SetPlan(func(s goke.RunCtx, d time.Duration) {
+		s.Run(sys, d)
+		s.Sync()
+	})
+	ecs.Tick(time.Millisecond)
+
+	assert.ElementsMatch(t, []uid.UID64{ids[0], ids[1]}, byTeam.Get(1))
+	assert.Equal(t, []uid.UID64{ids[1], ids[2], ids[0]}, byX.Range(1, 100))
+}
+
+func TestECS_Index_UseAfterReset_Panics(t *testing.T) {
+	ecs := goke.New()
+	byTeam := ecs.NewHashIndex(func(t *team) int { return t.ID })
+	ecs.Reset()
+
+	assert.Panics(t, func() { byTeam.Get(0) })
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/kjkrol/goke/v3/internal/colstore"
 	"github.com/kjkrol/goke/v3/internal/comp"
 )
 
@@ -16,7 +17,7 @@ func TestInitArchetype(t *testing.T) {
 	set := comp.Composition{}.With(compDef)
 	a := Archetype{}
 
-	a.Init(archId, set)
+	a.Init(archId, set, colstore.Geometry{})
 
 	if a.Id != archId {
 		t.Error("archetype Id is not set correctly")
@@ -40,7 +41,7 @@ func TestArchetype_Reset(t *testing.T) {
 	compDef := mi.Intern(reflect.TypeFor[testStruct1]())
 	set := comp.Composition{}.With(compDef)
 	a := Archetype{}
-	a.Init(ID(3), set)
+	a.Init(ID(3), set, colstore.Geometry{})
 
 	a.Reset()
 
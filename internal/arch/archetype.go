@@ -1,6 +1,8 @@
 package arch
 
 import (
+	"unsafe"
+
 	"github.com/kjkrol/goke/v3/internal/colstore"
 	"github.com/kjkrol/goke/v3/internal/comp"
 )
@@ -18,12 +20,25 @@ type Archetype struct {
 	Table colstore.Table
 	set   comp.Composition
 	graph *Graph
+	// shared holds the values of set.Shared, in the same order.
+	shared []unsafe.Pointer
 }
 
 func (a *Archetype) Mask() comp.Mask { return a.set.Mask }
 
 func (a *Archetype) Composition() comp.Composition { return a.set }
 
+// SharedValue returns the archetype's value of shared component id, or nil
+// if it has none.
+func (a *Archetype) SharedValue(id comp.ID) unsafe.Pointer {
+	for i, v := range a.set.Shared {
+		if v.ID == id {
+			return a.shared[i]
+		}
+	}
+	return nil
+}
+
 func (a *Archetype) Reset() {
 	a.Table.Clear()
 	if a.graph != nil {
@@ -31,13 +46,14 @@ func (a *Archetype) Reset() {
 	}
 	a.Id = NullID
 	a.set = comp.Composition{}
+	a.shared = nil
 }
 
-func (a *Archetype) Init(archId ID, set comp.Composition) {
+func (a *Archetype) Init(archId ID, set comp.Composition, g colstore.Geometry) {
 	a.Id = archId
 	a.set = set
 	a.graph = &Graph{}
-	a.Table.Init(set.Defs)
+	a.Table.InitWithChunk(set.Defs, set.Chunk, g)
 }
 
 func (a *Archetype) Len() int {
@@ -1,6 +1,7 @@
 package arch
 
 import (
+	"errors"
 	"fmt"
 	"unsafe"
 
@@ -15,6 +16,19 @@ type Catalog struct {
 	Archetypes         [MaxID]Archetype
 	lastArchetypeId    ID
 	onArchetypeCreated func(*Archetype)
+	// Geometry sizes the chunks of every new archetype not listed in
+	// overrides; zero fields take the platform defaults.
+	Geometry  colstore.Geometry
+	overrides map[comp.Mask]colstore.Geometry
+	// sparse holds the storage of sparse components by ID, created on
+	// first use; sparseSets lists the same sets for removal sweeps.
+	sparse     [comp.MaxComponents]*colstore.SparseSet
+	sparseSets []*colstore.SparseSet
+	// sharedPools interns shared component values by ID; sharedIndex
+	// finds the archetypes whose composition has shared values, which
+	// maskIndex cannot tell apart.
+	sharedPools [comp.MaxComponents]sharedPool
+	sharedIndex map[string]ID
 }
 
 func (r *Catalog) Init(onArchetypeCreated func(*Archetype)) {
@@ -27,8 +41,14 @@ func (r *Catalog) Len() ID {
 	return r.lastArchetypeId
 }
 
+// Upsert returns the archetype of set, creating it if needed. A set with
+// shared values is found by its mask and values together.
 func (r *Catalog) Upsert(set comp.Composition) ID {
-	if found, ok := r.maskIndex.Get(set.Mask); ok {
+	if len(set.Shared) > 0 {
+		if found, ok := r.sharedIndex[sharedKey(set)]; ok {
+			return found
+		}
+	} else if found, ok := r.maskIndex.Get(set.Mask); ok {
 		return found
 	}
 	archID := r.addArchetype(set)
@@ -82,12 +102,71 @@ func (r *Catalog) EnsureEdgePrev(compDef comp.Def, archID ID) (targetID ID, shou
 	return prevArchID, false
 }
 
+// SetGeometryFor sizes the chunks of the archetypes with the given mask for
+// g instead of Geometry. The override outlives Reset; it fails once such an
+// archetype exists — with or without shared values — since its chunks are
+// already laid out.
+func (r *Catalog) SetGeometryFor(mask comp.Mask, g colstore.Geometry) error {
+	if r.hasMask(mask) {
+		return errors.New("arch: archetype already exists — set its chunk geometry before its first entity")
+	}
+	if r.overrides == nil {
+		r.overrides = make(map[comp.Mask]colstore.Geometry)
+	}
+	r.overrides[mask] = g
+	return nil
+}
+
+// hasMask reports whether an archetype with the given mask exists, found
+// through sharedIndex too for those with shared values.
+func (r *Catalog) hasMask(mask comp.Mask) bool {
+	if _, ok := r.maskIndex.Get(mask); ok {
+		return true
+	}
+	for _, id := range r.sharedIndex {
+		if r.Archetypes[id].Mask() == mask {
+			return true
+		}
+	}
+	return false
+}
+
+// SparseSet returns the storage of sparse component def, creating it on
+// first use. The set outlives Reset, which only empties it.
+func (r *Catalog) SparseSet(def comp.Def) *colstore.SparseSet {
+	if s := r.sparse[def.ID]; s != nil {
+		return s
+	}
+	s := colstore.NewSparseSet(def.Type)
+	r.sparse[def.ID] = s
+	r.sparseSets = append(r.sparseSets, s)
+	return s
+}
+
+// LookupSparse returns the storage of sparse component id, or nil if it
+// was never used.
+func (r *Catalog) LookupSparse(id comp.ID) *colstore.SparseSet {
+	return r.sparse[id]
+}
+
+// RemoveSparse drops id from every sparse set — part of removing an entity.
+func (r *Catalog) RemoveSparse(id uid.UID64) {
+	for _, s := range r.sparseSets {
+		s.Remove(id)
+	}
+}
+
 func (r *Catalog) Reset() {
+	for _, s := range r.sparseSets {
+		s.Clear()
+	}
 	for i := int(RootID); i < int(r.lastArchetypeId); i++ {
 		r.Archetypes[i].Reset()
 	}
 	clear(r.Archetypes[:])
 	r.maskIndex.Reset()
+	clear(r.sharedIndex)
+	r.sharedPools = [comp.MaxComponents]sharedPool{}
 	r.lastArchetypeId = RootID
 	r.addArchetype(comp.Composition{})
 }
@@ -97,8 +176,20 @@ func (r *Catalog) addArchetype(set comp.Composition) ID {
 		panic(fmt.Sprintf("Max archetype number exceeded: %d", MaxID))
 	}
 	archID := r.lastArchetypeId
-	r.Archetypes[archID].Init(archID, set)
-	r.maskIndex.Upsert(set.Mask, archID)
+	g, ok := r.overrides[set.Mask]
+	if !ok {
+		g = r.Geometry
+	}
+	r.Archetypes[archID].Init(archID, set, g)
+	if len(set.Shared) > 0 {
+		if r.sharedIndex == nil {
+			r.sharedIndex = make(map[string]ID)
+		}
+		r.sharedIndex[sharedKey(set)] = archID
+		r.resolveShared(&r.Archetypes[archID])
+	} else {
+		r.maskIndex.Upsert(set.Mask, archID)
+	}
 	r.lastArchetypeId++
 	return archID
 }
@@ -265,6 +265,103 @@ func TestCatalog_MigrateEntity_MovesDataAndSwapsSource(t *testing.T) {
 	}
 }
 
+func TestCatalog_SetGeometryFor(t *testing.T) {
+	cat := newTestCatalog()
+	cat.Geometry = colstore.Geometry{Bytes: 4 * 1024}
+	posDef, velDef := testMetas()
+	small := comp.Composition{}.With(posDef)
+	large := comp.Composition{}.With(posDef).With(velDef)
+
+	if err := cat.SetGeometryFor(large.Mask, colstore.Geometry{Bytes: 64 * 1024}); err != nil {
+		t.Fatal(err)
+	}
+	smallID := cat.Upsert(small)
+	largeID := cat.Upsert(large)
+
+	if got := cat.Archetypes[smallID].Table.ChunkCap(); got > 4*1024/24 {
+		t.Errorf("expected the catalog geometry to bound ChunkCap, got %d", got)
+	}
+	if got := cat.Archetypes[largeID].Table.ChunkCap(); got <= 4*1024/40 {
+		t.Errorf("expected the override to enlarge ChunkCap, got %d", got)
+	}
+	if err := cat.SetGeometryFor(small.Mask, colstore.Geometry{}); err == nil {
+		t.Error("expected an error overriding an existing archetype")
+	}
+}
+
+func TestCatalog_Upsert_SharedValues(t *testing.T) {
+	cat := newTestCatalog()
+	mi := newDefIndex()
+	posDef := mi.Intern(reflect.TypeFor[position]())
+	velDef := mi.InternWith(reflect.TypeFor[velocity](), comp.RegSpec{Shared: true})
+	base := comp.Composition{}.With(posDef)
+
+	v1, v1Again, v2 := velocity{VX: 1}, velocity{VX: 1}, velocity{VX: 2}
+	h1 := cat.InternShared(velDef, unsafe.Pointer(&v1))
+	if cat.InternShared(velDef, unsafe.Pointer(&v1Again)) != h1 {
+		t.Error("expected equal values to share a handle")
+	}
+	h2 := cat.InternShared(velDef, unsafe.Pointer(&v2))
+	if h2 == h1 {
+		t.Error("expected distinct values to get distinct handles")
+	}
+	v1.VX = 5
+	if got := (*velocity)(cat.SharedValue(velDef.ID, h1)); got.VX != 1 {
+		t.Errorf("expected the catalog to keep its own copy, got %v", got)
+	}
+
+	plain := cat.Upsert(base)
+	id1 := cat.Upsert(base.WithShared(velDef.ID, h1))
+	id2 := cat.Upsert(base.WithShared(velDef.ID, h2))
+	if id1 == plain || id2 == plain || id1 == id2 {
+		t.Fatalf("expected one archetype per value, got %d, %d, %d", plain, id1, id2)
+	}
+	if cat.Upsert(base.WithShared(velDef.ID, h1)) != id1 || cat.Upsert(base) != plain {
+		t.Error("expected Upsert to find the existing archetypes")
+	}
+	if got := (*velocity)(cat.Archetypes[id2].SharedValue(velDef.ID)); got == nil || got.VX != 2 {
+		t.Errorf("expected the archetype to expose its value, got %v", got)
+	}
+	if cat.Archetypes[plain].SharedValue(velDef.ID) != nil {
+		t.Error("expected no value for an archetype without the component")
+	}
+
+	prev, unlink := cat.EnsureEdgePrev(velDef, id2)
+	if unlink || prev != plain {
+		t.Errorf("expected removing the shared component to lead back to %d, got %d", plain, prev)
+	}
+}
+
+func TestCatalog_SetGeometryFor_SharedValueArchetype(t *testing.T) {
+	cat := newTestCatalog()
+	mi := newDefIndex()
+	posDef := mi.Intern(reflect.TypeFor[position]())
+	velDef := mi.InternWith(reflect.TypeFor[velocity](), comp.RegSpec{Shared: true})
+	v := velocity{VX: 1}
+	id := cat.Upsert(comp.Composition{}.With(posDef).WithShared(velDef.ID, cat.InternShared(velDef, unsafe.Pointer(&v))))
+
+	if err := cat.SetGeometryFor(cat.Archetypes[id].Mask(), colstore.Geometry{Bytes: 64 * 1024}); err == nil {
+		t.Error("expected an error overriding an existing archetype with shared values")
+	}
+}
+
+func TestCatalog_EnsureEdgeNext_PerChunkComponent(t *testing.T) {
+	cat := newTestCatalog()
+	mi := newDefIndex()
+	posDef := mi.Intern(reflect.TypeFor[position]())
+	velDef := mi.InternWith(reflect.TypeFor[velocity](), comp.RegSpec{PerChunk: true})
+	base := cat.Upsert(comp.Composition{}.With(posDef))
+
+	nextID := cat.EnsureEdgeNext(velDef, base)
+	next := &cat.Archetypes[nextID]
+	if len(next.Composition().Defs) != 1 || len(next.Composition().Chunk) != 1 {
+		t.Fatalf("expected one column and one per-chunk value, got %+v", next.Composition())
+	}
+	if next.Table.ChunkValueAt(next.Table.ChunkPtrAt(0), velDef.ID) == nil {
+		t.Error("expected the archetype's chunks to hold a velocity")
+	}
+}
+
 func TestCatalog_Reset(t *testing.T) {
 	cat := newTestCatalog()
 	posDef, _ := testMetas()
@@ -38,4 +38,25 @@
 // archetype is registered. The caller wires in whatever notification logic it
 // needs without creating a circular import. This call is on the cold path and
 // carries no performance cost during normal iteration.
+//
+// # Sparse Sets
+//
+// Sparse components live beside the archetypes, one colstore.SparseSet per
+// component, created on first use by [Catalog.SparseSet]. Removing an
+// entity must also call [Catalog.RemoveSparse].
+//
+// # Shared Values
+//
+// A shared component's value belongs to the archetype: [Catalog.InternShared]
+// keeps one copy of each distinct value, byte for byte, and a composition
+// names it by that handle. Compositions with shared values are indexed by
+// mask and handles together, so equal masks with different values are
+// different archetypes.
+//
+// # Per-chunk Values
+//
+// A per-chunk component has no column: [Archetype.Init] passes the
+// composition's Chunk defs to its Table, which keeps one value of each in
+// every chunk. Its mask bit still decides the archetype, like any other
+// component's.
 package arch
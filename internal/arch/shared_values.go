@@ -0,0 +1,63 @@
+package arch
+
+import (
+	"encoding/binary"
+	"reflect"
+	"unsafe"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+// sharedPool interns the distinct values of one shared component: each
+// gets a handle, its index in vals, and a copy that never moves.
+type sharedPool struct {
+	byBytes map[string]uint32
+	vals    []unsafe.Pointer
+}
+
+// InternShared returns the handle of the shared component value at ptr,
+// copying the value into the catalog the first time it is seen. Equal
+// values — byte for byte — share one handle.
+func (r *Catalog) InternShared(def comp.Def, ptr unsafe.Pointer) uint32 {
+	p := &r.sharedPools[def.ID]
+	key := string(unsafe.Slice((*byte)(ptr), def.Size))
+	if val, ok := p.byBytes[key]; ok {
+		return val
+	}
+	if p.byBytes == nil {
+		p.byBytes = make(map[string]uint32)
+	}
+	v := reflect.New(def.Type).UnsafePointer()
+	copy(unsafe.Slice((*byte)(v), def.Size), key)
+	val := uint32(len(p.vals))
+	p.vals = append(p.vals, v)
+	p.byBytes[key] = val
+	return val
+}
+
+// SharedValue returns the value of shared component id interned as val.
+func (r *Catalog) SharedValue(id comp.ID, val uint32) unsafe.Pointer {
+	return r.sharedPools[id].vals[val]
+}
+
+// sharedKey identifies a composition with shared values: its mask, then
+// the value handles in ID order.
+func sharedKey(set comp.Composition) string {
+	b := make([]byte, 0, comp.MaskSize*8+len(set.Shared)*4)
+	for _, w := range set.Mask {
+		b = binary.LittleEndian.AppendUint64(b, w)
+	}
+	for _, v := range set.Shared {
+		b = binary.LittleEndian.AppendUint32(b, v.Val)
+	}
+	return string(b)
+}
+
+// resolveShared points archetype at the interned values of its shared
+// components.
+func (r *Catalog) resolveShared(archetype *Archetype) {
+	archetype.shared = make([]unsafe.Pointer, len(archetype.set.Shared))
+	for i, v := range archetype.set.Shared {
+		archetype.shared[i] = r.SharedValue(v.ID, v.Val)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build linux
+
+package chunk
+
+// DetectGeometry reads the L1 data cache of cpu0 from sysfs.
+func DetectGeometry() (Geometry, error) {
+	return detectFrom("/sys/devices/system/cpu/cpu0/cache")
+}
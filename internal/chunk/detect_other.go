@@ -0,0 +1,10 @@
+//go:build !linux
+
+package chunk
+
+import "errors"
+
+// DetectGeometry is only implemented on Linux; elsewhere it always fails.
+func DetectGeometry() (Geometry, error) {
+	return Geometry{}, errors.New("chunk: cache detection is only supported on linux")
+}
@@ -7,8 +7,8 @@
 //
 // # Layout
 //
-// [Layout.Init] computes how many slots of each field type fit within
-// [L1DataCacheSize]. Fields are arranged as parallel arrays at precomputed
+// [Layout.InitWith] computes how many slots of each field type fit within
+// a [Geometry]'s byte target ([Layout.Init] uses [L1DataCacheSize]). Fields are arranged as parallel arrays at precomputed
 // byte offsets:
 //
 //	┌───────────────────────────────────────┐
@@ -24,6 +24,12 @@
 //   - ChunkBytes — total byte size of one chunk
 //   - Offsets    — byte offset of each field array within the chunk
 //
+// [Layout.InitWithChunk] also reserves a header after the arrays holding
+// one value per chunk of each per-chunk component; ChunkOffsets locates
+// them. The header lives in the chunk's own memory, so it travels with the
+// chunk when chunks are swapped or a trimmed chunk is reused, and [Pack]
+// zeroes it whenever a chunk's last slot is freed.
+//
 // # Pack
 //
 // [Pack] is a densely packed, dynamically growing sequence of fixed-size chunks
@@ -43,6 +49,8 @@
 //
 // # L1DataCacheSize
 //
-// Defined per platform via build tags. [Layout.Init] uses it to size chunks
-// so that a full chunk fits within the L1 data cache.
+// Defined per platform via build tags, with [L1DataCacheSets]; together they
+// form [DefaultGeometry], which zero [Geometry] fields fall back to, so that
+// by default a full chunk fits within the L1 data cache. [DetectGeometry]
+// reads the host's actual L1 data cache from sysfs on Linux.
 package chunk
@@ -0,0 +1,97 @@
+package chunk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Geometry is the cache target [Layout.InitWith] sizes chunks for: a chunk
+// holds at most Bytes, and no two of its columns start in the same of Sets
+// cache sets of LineBytes-byte lines. Zero fields fall back to the platform
+// defaults, [L1DataCacheSize] and [L1DataCacheSets] with 64-byte lines.
+type Geometry struct {
+	Bytes     uintptr
+	Sets      int
+	LineBytes uintptr
+}
+
+// DefaultGeometry returns the build-time platform defaults.
+func DefaultGeometry() Geometry {
+	return Geometry{Bytes: L1DataCacheSize, Sets: L1DataCacheSets, LineBytes: 64}
+}
+
+// orDefault fills g's zero fields from DefaultGeometry.
+func (g Geometry) orDefault() Geometry {
+	d := DefaultGeometry()
+	if g.Bytes == 0 {
+		g.Bytes = d.Bytes
+	}
+	if g.Sets <= 0 {
+		g.Sets = d.Sets
+	}
+	if g.LineBytes == 0 {
+		g.LineBytes = d.LineBytes
+	}
+	return g
+}
+
+// detectFrom reads the level-1 data cache from a sysfs cache directory
+// laid out as /sys/devices/system/cpu/cpuN/cache: one indexK subdirectory
+// per cache, each with level, type, size, number_of_sets and
+// coherency_line_size files.
+func detectFrom(dir string) (Geometry, error) {
+	entries, err := filepath.Glob(filepath.Join(dir, "index*"))
+	if err != nil {
+		return Geometry{}, err
+	}
+	for _, idx := range entries {
+		if read(idx, "level") != "1" {
+			continue
+		}
+		if t := read(idx, "type"); t != "Data" && t != "Unified" {
+			continue
+		}
+		size, err := parseSize(read(idx, "size"))
+		if err != nil {
+			return Geometry{}, fmt.Errorf("chunk: %s: %w", idx, err)
+		}
+		sets, err := strconv.Atoi(read(idx, "number_of_sets"))
+		if err != nil || sets <= 0 {
+			return Geometry{}, fmt.Errorf("chunk: %s: bad number_of_sets", idx)
+		}
+		line, err := strconv.Atoi(read(idx, "coherency_line_size"))
+		if err != nil || line <= 0 {
+			return Geometry{}, fmt.Errorf("chunk: %s: bad coherency_line_size", idx)
+		}
+		return Geometry{Bytes: size, Sets: sets, LineBytes: uintptr(line)}, nil
+	}
+	return Geometry{}, fmt.Errorf("chunk: no level-1 data cache under %s", dir)
+}
+
+// read returns the trimmed contents of dir/name, or "" if unreadable.
+func read(dir, name string) string {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// parseSize parses a sysfs cache size such as "48K" or "2M".
+func parseSize(s string) (uintptr, error) {
+	mult := uintptr(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult, s = 1<<10, strings.TrimSuffix(s, "K")
+	case strings.HasSuffix(s, "M"):
+		mult, s = 1<<20, strings.TrimSuffix(s, "M")
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("bad size %q", s)
+	}
+	return uintptr(n) * mult, nil
+}
@@ -0,0 +1,56 @@
+package chunk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCacheIndex(t *testing.T, dir, name string, files map[string]string) {
+	t.Helper()
+	idx := filepath.Join(dir, name)
+	if err := os.MkdirAll(idx, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for f, v := range files {
+		if err := os.WriteFile(filepath.Join(idx, f), []byte(v+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectFrom(t *testing.T) {
+	dir := t.TempDir()
+	writeCacheIndex(t, dir, "index0", map[string]string{
+		"level": "1", "type": "Instruction", "size": "32K", "number_of_sets": "64", "coherency_line_size": "64",
+	})
+	writeCacheIndex(t, dir, "index1", map[string]string{
+		"level": "1", "type": "Data", "size": "48K", "number_of_sets": "64", "coherency_line_size": "64",
+	})
+	writeCacheIndex(t, dir, "index2", map[string]string{
+		"level": "2", "type": "Unified", "size": "2M", "number_of_sets": "2048", "coherency_line_size": "64",
+	})
+
+	g, err := detectFrom(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Geometry{Bytes: 48 * 1024, Sets: 64, LineBytes: 64}
+	if g != want {
+		t.Errorf("expected %+v, got %+v", want, g)
+	}
+}
+
+func TestDetectFrom_Errors(t *testing.T) {
+	if _, err := detectFrom(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without caches")
+	}
+
+	dir := t.TempDir()
+	writeCacheIndex(t, dir, "index0", map[string]string{
+		"level": "1", "type": "Data", "size": "lots", "number_of_sets": "64", "coherency_line_size": "64",
+	})
+	if _, err := detectFrom(dir); err == nil {
+		t.Error("expected an error for a malformed size")
+	}
+}
@@ -13,9 +13,26 @@ type Layout struct {
 	ChunkBytes uintptr
 	Offsets    []uintptr
 	NeedsScan  bool
+	// ChunkOffsets locates each per-chunk value in the header that follows
+	// the columns; HeaderStart is where that header begins.
+	ChunkOffsets []uintptr
+	HeaderStart  uintptr
 }
 
-func (l *Layout) Init(compDefs []comp.Def) {
+// Init sizes the layout for [DefaultGeometry].
+func (l *Layout) Init(compDefs []comp.Def) { l.InitWith(compDefs, Geometry{}) }
+
+// InitWith sizes the layout so a full chunk fits in g.Bytes with no two
+// column starts sharing a cache set; zero fields of g take the defaults.
+func (l *Layout) InitWith(compDefs []comp.Def, g Geometry) {
+	l.InitWithChunk(compDefs, nil, g)
+}
+
+// InitWithChunk is [Layout.InitWith] with a header after the columns that
+// holds one value of each of chunkDefs per chunk. The header counts
+// against g.Bytes like the columns do.
+func (l *Layout) InitWithChunk(compDefs, chunkDefs []comp.Def, g Geometry) {
+	g = g.orDefault()
 	entityStride := unsafe.Sizeof(uid.UID64(0))
 	totalStride := entityStride
 	needsScan := false
@@ -27,7 +44,15 @@ func (l *Layout) Init(compDefs []comp.Def) {
 	}
 	l.NeedsScan = needsScan
 
-	capacity := uintptr(L1DataCacheSize) / totalStride
+	var headerBytes uintptr
+	for _, chunkDef := range chunkDefs {
+		headerBytes = alignUp(headerBytes, chunkDef.Align) + chunkDef.Size
+	}
+
+	capacity := uintptr(0)
+	if g.Bytes > headerBytes {
+		capacity = (g.Bytes - headerBytes) / totalStride
+	}
 	if capacity == 0 {
 		capacity = 1
 	}
@@ -50,8 +75,18 @@ func (l *Layout) Init(compDefs []comp.Def) {
 			currentOffset += compDef.Size * capacity
 		}
 
-		if capacity == 1 || (currentOffset <= L1DataCacheSize && !hasCacheSetConflict(offsets)) {
+		headerStart := currentOffset
+		chunkOffsets := make([]uintptr, len(chunkDefs))
+		for i, chunkDef := range chunkDefs {
+			currentOffset = alignUp(currentOffset, chunkDef.Align)
+			chunkOffsets[i] = currentOffset
+			currentOffset += chunkDef.Size
+		}
+
+		if capacity == 1 || (currentOffset <= g.Bytes && !hasCacheSetConflict(offsets, g)) {
 			l.ChunkCap = uint32(capacity)
+			l.HeaderStart = headerStart
+			l.ChunkOffsets = chunkOffsets
 			if needsScan {
 				currentOffset = alignUp(currentOffset, unsafe.Sizeof(unsafe.Pointer(nil)))
 			}
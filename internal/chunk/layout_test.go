@@ -65,3 +65,58 @@ func TestLayout_Init_NeedsScan(t *testing.T) {
 		t.Errorf("expected ChunkBytes (%d) rounded up to a multiple of %d", scanLayout.ChunkBytes, wordSize)
 	}
 }
+
+func TestLayout_InitWith_Geometry(t *testing.T) {
+	defs := []comp.Def{
+		{ID: 1, Size: 8, Align: 8},
+		{ID: 2, Size: 4, Align: 4},
+	}
+	var small, large Layout
+	small.InitWith(defs, Geometry{Bytes: 4 * 1024, Sets: 16, LineBytes: 64})
+	large.InitWith(defs, Geometry{Bytes: 48 * 1024, Sets: 64, LineBytes: 64})
+
+	if small.ChunkBytes > 4*1024 {
+		t.Errorf("expected ChunkBytes (%d) to fit within 4 KB", small.ChunkBytes)
+	}
+	if large.ChunkBytes > 48*1024 {
+		t.Errorf("expected ChunkBytes (%d) to fit within 48 KB", large.ChunkBytes)
+	}
+	if large.ChunkCap <= small.ChunkCap {
+		t.Errorf("expected a larger target to hold more rows: %d <= %d", large.ChunkCap, small.ChunkCap)
+	}
+	if hasCacheSetConflict(large.Offsets, Geometry{Sets: 64, LineBytes: 64}) {
+		t.Errorf("expected no start-offset cache set conflict, offsets %v", large.Offsets)
+	}
+}
+
+// A zero Geometry is the platform default, so Init and InitWith agree.
+func TestLayout_InitWith_ZeroGeometryIsDefault(t *testing.T) {
+	defs := []comp.Def{{ID: 1, Size: 12, Align: 4}}
+	var a, b Layout
+	a.Init(defs)
+	b.InitWith(defs, Geometry{})
+	if a.ChunkCap != b.ChunkCap || a.ChunkBytes != b.ChunkBytes {
+		t.Errorf("expected identical layouts, got %d/%d and %d/%d", a.ChunkCap, a.ChunkBytes, b.ChunkCap, b.ChunkBytes)
+	}
+}
+
+func TestLayout_InitWithChunk_HeaderAfterColumns(t *testing.T) {
+	defs := []comp.Def{{ID: 1, Size: 8, Align: 8}}
+	chunkDefs := []comp.Def{{ID: 2, Size: 4, Align: 4}, {ID: 3, Size: 16, Align: 8}}
+	var l Layout
+	l.InitWithChunk(defs, chunkDefs, Geometry{})
+
+	if l.Offsets[0] != 0 {
+		t.Errorf("expected the entity column at offset 0, got %d", l.Offsets[0])
+	}
+	colsEnd := l.Offsets[1] + 8*uintptr(l.ChunkCap)
+	if l.HeaderStart != colsEnd || l.ChunkOffsets[0] != colsEnd {
+		t.Errorf("expected the header to start at %d, got HeaderStart %d ChunkOffsets %v", colsEnd, l.HeaderStart, l.ChunkOffsets)
+	}
+	if l.ChunkOffsets[1]%8 != 0 || l.ChunkOffsets[1] < l.ChunkOffsets[0]+4 {
+		t.Errorf("expected the second value aligned after the first, got %v", l.ChunkOffsets)
+	}
+	if l.ChunkBytes < l.ChunkOffsets[1]+16 || l.ChunkBytes > L1DataCacheSize {
+		t.Errorf("expected ChunkBytes (%d) to cover the header and fit the cache", l.ChunkBytes)
+	}
+}
@@ -67,12 +67,24 @@ func (g *Pack) Extend(idx Idx, n int) (base unsafe.Pointer, startSlot Slot) {
 func (g *Pack) FreeSlot(idx Idx) {
 	g.chunks[idx].Len--
 	g.len--
+	g.clearHeaderIfEmpty(idx)
 }
 
 // FreeSlots releases n slots from the tail of chunk idx.
 func (g *Pack) FreeSlots(idx Idx, n int) {
 	g.chunks[idx].Len -= Slot(n)
 	g.len -= uint32(n)
+	g.clearHeaderIfEmpty(idx)
+}
+
+// clearHeaderIfEmpty zeroes chunk idx's per-chunk values once its last
+// slot is freed, so whichever entities fill it next start from zero values
+// — however the chunk is later swapped, trimmed or reused.
+func (g *Pack) clearHeaderIfEmpty(idx Idx) {
+	c := &g.chunks[idx]
+	if c.Len == 0 && len(g.Layout.ChunkOffsets) > 0 {
+		clear(c.data[g.Layout.HeaderStart:])
+	}
 }
 
 // NextNonEmptyChunk returns the first chunk at or after from with Len > 0.
@@ -171,6 +183,7 @@ func (g *Pack) SwapChunks(a, b Idx) {
 func (g *Pack) BulkFreeChunk(idx Idx) {
 	g.len -= uint32(g.chunks[idx].Len)
 	g.chunks[idx].Len = 0
+	g.clearHeaderIfEmpty(idx)
 }
 
 // ChunkIdxByPtr finds the chunk whose Ptr equals ptr — linear scan, rare paths only.
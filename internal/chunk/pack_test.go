@@ -2,6 +2,7 @@ package chunk
 
 import (
 	"testing"
+	"unsafe"
 
 	"github.com/kjkrol/goke/v3/internal/comp"
 )
@@ -203,3 +204,29 @@ func TestAddChunks_ReusesSpareAfterTrim(t *testing.T) {
 		t.Error("expected AddChunks to reuse the spare chunk's backing array, got a freshly allocated one")
 	}
 }
+
+func TestPack_ClearsChunkHeaderWhenEmptied(t *testing.T) {
+	var g Pack
+	var layout Layout
+	layout.InitWithChunk([]comp.Def{{ID: 1, Size: 8, Align: 8}}, []comp.Def{{ID: 2, Size: 8, Align: 8}}, Geometry{})
+	g.Init(layout)
+	header := func() *uint64 { return (*uint64)(unsafe.Add(g.ChunkPtr(0), layout.ChunkOffsets[0])) }
+
+	g.Extend(0, 2)
+	*header() = 42
+	g.FreeSlot(0)
+	if *header() != 42 {
+		t.Error("expected the header to survive while the chunk holds entities")
+	}
+	g.FreeSlot(0)
+	if *header() != 0 {
+		t.Errorf("expected FreeSlot to zero the header of an emptied chunk, got %d", *header())
+	}
+
+	g.Extend(0, 3)
+	*header() = 7
+	g.BulkFreeChunk(0)
+	if *header() != 0 {
+		t.Errorf("expected BulkFreeChunk to zero the header, got %d", *header())
+	}
+}
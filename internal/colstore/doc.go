@@ -23,6 +23,12 @@
 // [Table.RemoveAt] removes the slot at a given [Pos] using swap-and-pop,
 // keeping all Chunks dense.
 //
+// A Table set up with [Table.InitWithChunk] also keeps one value per Chunk
+// of each per-chunk component, after the columns. [Table.BakeChunkOffsets]
+// locates them for a Cursor and [Table.ChunkValueAt] for a single Chunk.
+// Entities don't carry these values when they move; a Chunk's values are
+// zeroed once it empties.
+//
 // # IDSeeder
 //
 // [IDSeeder] is a function type injected into each [Table] via [Table.SetIDSeeder].
@@ -34,4 +40,11 @@
 // CompSize, and byte Offset within the Chunk. It exposes two accessors:
 //   - At(chunk, slot) — pointer to a specific slot
 //   - Base(chunk)     — pointer to the column's start within the Chunk
+//
+// # SparseSet
+//
+// A [SparseSet] stores one sparse component outside any Table: values in a
+// dense array, found through a sparse array indexed by entity index. Adding
+// or removing a value is O(1) and touches no Table, so sparse components
+// cost no migration.
 package colstore
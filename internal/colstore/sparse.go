@@ -0,0 +1,147 @@
+package colstore
+
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/kjkrol/uid"
+)
+
+// SparseSet stores one component outside archetype chunks: a dense array
+// of values with a parallel array of their entities' IDs, and a sparse
+// array mapping an entity's index to its dense position. Membership tests,
+// inserts and swap-removes are O(1) and never touch archetype tables.
+//
+// Values live in a []T allocated through reflect, so the GC scans them
+// whatever T holds. Pointers returned by Get and Upsert stay valid until
+// the next Upsert or Remove.
+type SparseSet struct {
+	typ   reflect.Type
+	size  uintptr
+	index []int32 // entity index → dense position + 1; 0 means absent
+	ids   []uid.UID64
+	data  reflect.Value // []T, len == cap; only the first len(ids) are live
+	base  unsafe.Pointer
+}
+
+// zeroSized is what Get and Upsert return for zero-size components:
+// a non-nil pointer that must not be written through.
+var zeroSized byte
+
+// NewSparseSet returns an empty SparseSet for values of type t.
+func NewSparseSet(t reflect.Type) *SparseSet {
+	return &SparseSet{typ: t, size: t.Size(), data: reflect.MakeSlice(reflect.SliceOf(t), 0, 0)}
+}
+
+// Len returns the number of entities in the set.
+func (s *SparseSet) Len() int { return len(s.ids) }
+
+// IDs returns the set's entities in dense order. Valid until the next
+// Upsert or Remove.
+func (s *SparseSet) IDs() []uid.UID64 { return s.ids }
+
+// At returns a pointer to the value at dense position i.
+func (s *SparseSet) At(i int) unsafe.Pointer {
+	if s.size == 0 {
+		return unsafe.Pointer(&zeroSized)
+	}
+	return unsafe.Add(s.base, uintptr(i)*s.size)
+}
+
+// pos returns id's dense position, or -1 if id is not in the set. A stale
+// entry left by an earlier generation of the same index does not match.
+func (s *SparseSet) pos(id uid.UID64) int {
+	idx := id.Index()
+	if int(idx) >= len(s.index) {
+		return -1
+	}
+	p := int(s.index[idx]) - 1
+	if p < 0 || s.ids[p] != id {
+		return -1
+	}
+	return p
+}
+
+// Has reports whether id has a value in the set.
+func (s *SparseSet) Has(id uid.UID64) bool { return s.pos(id) >= 0 }
+
+// Get returns a pointer to id's value, or nil if id is not in the set.
+func (s *SparseSet) Get(id uid.UID64) unsafe.Pointer {
+	p := s.pos(id)
+	if p < 0 {
+		return nil
+	}
+	return s.At(p)
+}
+
+// Upsert returns a pointer to id's value, appending a zero value first if
+// id is not in the set. An entry left by an earlier generation of id's
+// index is taken over and zeroed.
+func (s *SparseSet) Upsert(id uid.UID64) unsafe.Pointer {
+	idx := int(id.Index())
+	if idx < len(s.index) {
+		if p := int(s.index[idx]) - 1; p >= 0 {
+			if s.ids[p] != id {
+				s.ids[p] = id
+				s.zero(p)
+			}
+			return s.At(p)
+		}
+	} else {
+		s.index = append(s.index, make([]int32, idx+1-len(s.index))...)
+	}
+	p := len(s.ids)
+	s.ids = append(s.ids, id)
+	s.grow(p + 1)
+	s.index[idx] = int32(p + 1)
+	return s.At(p)
+}
+
+// Remove deletes id's value, moving the last value into its place.
+// Reports whether id was in the set.
+func (s *SparseSet) Remove(id uid.UID64) bool {
+	p := s.pos(id)
+	if p < 0 {
+		return false
+	}
+	last := len(s.ids) - 1
+	if p != last {
+		moved := s.ids[last]
+		s.ids[p] = moved
+		s.index[moved.Index()] = int32(p + 1)
+		if s.size > 0 {
+			s.data.Index(p).Set(s.data.Index(last))
+		}
+	}
+	s.zero(last)
+	s.ids = s.ids[:last]
+	s.index[id.Index()] = 0
+	return true
+}
+
+// Clear empties the set, keeping its storage.
+func (s *SparseSet) Clear() {
+	for p := range s.ids {
+		s.zero(p)
+	}
+	clear(s.index)
+	s.ids = s.ids[:0]
+}
+
+func (s *SparseSet) zero(p int) {
+	if s.size > 0 {
+		s.data.Index(p).SetZero()
+	}
+}
+
+// grow makes room for n values, doubling the backing array when full.
+func (s *SparseSet) grow(n int) {
+	if s.size == 0 || n <= s.data.Len() {
+		return
+	}
+	size := max(n, 8, 2*s.data.Len())
+	data := reflect.MakeSlice(reflect.SliceOf(s.typ), size, size)
+	reflect.Copy(data, s.data)
+	s.data = data
+	s.base = data.UnsafePointer()
+}
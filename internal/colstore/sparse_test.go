@@ -0,0 +1,94 @@
+package colstore
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kjkrol/uid"
+)
+
+type sparseVal struct {
+	A int
+	S string
+}
+
+func newSparseIDs(t *testing.T, n int) []uid.UID64 {
+	t.Helper()
+	var pool uid.UID64Pool
+	pool.Init(n, n)
+	ids := make([]uid.UID64, n)
+	pool.NextN(ids)
+	return ids
+}
+
+func TestSparseSet_UpsertGetRemove(t *testing.T) {
+	s := NewSparseSet(reflect.TypeFor[sparseVal]())
+	ids := newSparseIDs(t, 20)
+	for i, id := range ids {
+		(*sparseVal)(s.Upsert(id)).A = i
+	}
+	if s.Len() != 20 {
+		t.Fatalf("expected 20 entries, got %d", s.Len())
+	}
+	if !s.Remove(ids[3]) || s.Remove(ids[3]) {
+		t.Fatal("expected the first Remove to succeed and the second to fail")
+	}
+	if s.Has(ids[3]) || s.Get(ids[3]) != nil {
+		t.Error("expected the removed entity to be absent")
+	}
+	for i, id := range ids {
+		if i == 3 {
+			continue
+		}
+		if v := (*sparseVal)(s.Get(id)); v == nil || v.A != i {
+			t.Errorf("expected value %d for entity %d, got %v", i, i, v)
+		}
+	}
+	// The vacated tail slot is zeroed, so a re-added entity starts empty.
+	if v := (*sparseVal)(s.Upsert(ids[3])); v.A != 0 || v.S != "" {
+		t.Errorf("expected a zero value on re-add, got %+v", *v)
+	}
+}
+
+// An entry left behind by an earlier generation of an index is invisible
+// to the new generation and is taken over, zeroed, by its first Upsert.
+func TestSparseSet_StaleGeneration(t *testing.T) {
+	var pool uid.UID64Pool
+	pool.Init(4, 4)
+	old := pool.Next()
+	pool.Release(old)
+	reused := pool.Next()
+	if reused.Index() != old.Index() {
+		t.Fatal("expected the pool to reuse the released index")
+	}
+
+	s := NewSparseSet(reflect.TypeFor[sparseVal]())
+	(*sparseVal)(s.Upsert(old)).A = 7
+	if s.Has(reused) {
+		t.Error("expected a stale generation not to match")
+	}
+	if v := (*sparseVal)(s.Upsert(reused)); v.A != 0 {
+		t.Errorf("expected the stale value to be zeroed, got %d", v.A)
+	}
+	if s.Len() != 1 || s.Has(old) {
+		t.Errorf("expected the new generation to replace the old one, Len=%d", s.Len())
+	}
+}
+
+func TestSparseSet_ZeroSize(t *testing.T) {
+	s := NewSparseSet(reflect.TypeFor[struct{}]())
+	ids := newSparseIDs(t, 3)
+	for _, id := range ids {
+		if s.Upsert(id) == nil {
+			t.Fatal("expected a non-nil pointer for a zero-size value")
+		}
+	}
+	s.Remove(ids[0])
+	if s.Has(ids[0]) || !s.Has(ids[1]) || !s.Has(ids[2]) {
+		t.Error("expected only the removed entity to be absent")
+	}
+	s.Clear()
+	if s.Len() != 0 || s.Has(ids[1]) {
+		t.Error("expected Clear to empty the set")
+	}
+}
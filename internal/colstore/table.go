@@ -1,6 +1,8 @@
 package colstore
 
 import (
+	"slices"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/kjkrol/uid"
@@ -19,6 +21,13 @@ type Idx = chunk.Idx
 // Slot is the index of an entity slot within a chunk.
 type Slot = chunk.Slot
 
+// Geometry is the cache target a Table sizes its chunks for; see
+// chunk.Geometry.
+type Geometry = chunk.Geometry
+
+// DetectGeometry reads the host's L1 data cache geometry (Linux only).
+func DetectGeometry() (Geometry, error) { return chunk.DetectGeometry() }
+
 // IDSeeder fills dst with valid entity IDs starting at (ptr, slot), including
 // any bookkeeping such as index registration.
 type IDSeeder func(dst []uid.UID64, ptr unsafe.Pointer, slot Slot)
@@ -34,11 +43,21 @@ type Table struct {
 	columns    []ColDef
 	compColIdx columnIndex
 	seedIDs    IDSeeder
+	chunkIDs   []comp.ID // per-chunk components, in Layout.ChunkOffsets order
 
 	// version counts structural changes that remove or relocate existing
 	// entities (RemoveAt, compaction); appends never invalidate stored
 	// entities, so they don't bump it. Captured in bulk.ChunkSnapshot.
 	version uint32
+
+	// writes counts, per watched column, the writes marked to it: element
+	// 0 those for the whole table, element 1+i those for chunk i. Unwatched
+	// columns are nil, and a table watching none skips marking altogether.
+	// The counters are atomic — systems run in parallel mark the same
+	// table — and grow with the chunks only on structural paths; they stay
+	// put across SwapChunks, which bumps version instead.
+	writes   [][]atomic.Uint32
+	watching bool
 }
 
 func (t *Table) Version() uint32 { return t.version }
@@ -47,14 +66,30 @@ func (t *Table) Version() uint32 { return t.version }
 
 func (t *Table) SetIDSeeder(s IDSeeder) { t.seedIDs = s }
 
-func (t *Table) Init(compDefs []comp.Def) {
+func (t *Table) Init(compDefs []comp.Def) { t.InitWith(compDefs, Geometry{}) }
+
+// InitWith is Init with chunks sized for g instead of the platform default.
+func (t *Table) InitWith(compDefs []comp.Def, g Geometry) {
+	t.InitWithChunk(compDefs, nil, g)
+}
+
+// InitWithChunk is InitWith with one value of each of chunkDefs kept per
+// chunk, in a header after the columns.
+func (t *Table) InitWithChunk(compDefs, chunkDefs []comp.Def, g Geometry) {
 	var layout chunk.Layout
-	layout.Init(compDefs)
+	layout.InitWithChunk(compDefs, chunkDefs, g)
 	t.chunkPack.Init(layout)
 
+	t.chunkIDs = make([]comp.ID, len(chunkDefs))
+	for i, chunkDef := range chunkDefs {
+		t.chunkIDs[i] = chunkDef.ID
+	}
+
 	count := len(compDefs) + 1
 	t.columns = make([]ColDef, count)
 	t.compColIdx.Reset()
+	t.writes = make([][]atomic.Uint32, count)
+	t.watching = false
 
 	t.columns[entityColumnPos] = ColDef{
 		CompID:   comp.EntityID,
@@ -95,10 +130,26 @@ func (t *Table) BakeOffsets(ids []comp.ID) []uintptr {
 	return offsets
 }
 
+// BakeChunkOffsets returns the offset from a chunk's base of each per-chunk
+// component in ids, or iter.Absent for one the table doesn't keep.
+func (t *Table) BakeChunkOffsets(ids []comp.ID) []uintptr {
+	offsets := make([]uintptr, len(ids))
+	for i, id := range ids {
+		offsets[i] = iter.Absent
+		if j := slices.Index(t.chunkIDs, id); j >= 0 {
+			offsets[i] = t.chunkPack.Layout.ChunkOffsets[j]
+		}
+	}
+	return offsets
+}
+
 // --- Read ---
 
 func (t *Table) Len() uint32 { return t.chunkPack.Len() }
 
+// ChunkCap returns the number of entity slots per chunk.
+func (t *Table) ChunkCap() int { return int(t.chunkPack.Layout.ChunkCap) }
+
 // ComponentAt returns a pointer to component id at (ptr, slot); nil if untracked.
 func (t *Table) ComponentAt(ptr unsafe.Pointer, slot Slot, id comp.ID) unsafe.Pointer {
 	col := t.getColumn(id)
@@ -108,6 +159,16 @@ func (t *Table) ComponentAt(ptr unsafe.Pointer, slot Slot, id comp.ID) unsafe.Po
 	return col.At(ptr, slot)
 }
 
+// ChunkValueAt returns a pointer to per-chunk component id in the chunk at
+// ptr; nil if the table doesn't keep it.
+func (t *Table) ChunkValueAt(ptr unsafe.Pointer, id comp.ID) unsafe.Pointer {
+	j := slices.Index(t.chunkIDs, id)
+	if j < 0 {
+		return nil
+	}
+	return unsafe.Add(ptr, t.chunkPack.Layout.ChunkOffsets[j])
+}
+
 func (t *Table) ChunkPtrAt(idx Idx) unsafe.Pointer {
 	return t.chunkPack.ChunkPtr(idx)
 }
@@ -166,6 +227,7 @@ func (t *Table) CopyRangeFrom(src *Table, srcPtr unsafe.Pointer, srcSlot Slot, d
 // position plus the entity displaced by the swap, if any.
 func (t *Table) MoveEntityFrom(src *Table, entityID uid.UID64, srcPtr unsafe.Pointer, srcSlot Slot) (newPtr unsafe.Pointer, newSlot Slot, swappedEntity uid.UID64, swapped bool) {
 	newPos := t.chunkPack.AllocSlot()
+	t.growWrites()
 	newPtr = t.chunkPack.ChunkPtr(newPos.Idx)
 	newSlot = newPos.Slot
 	*(*uid.UID64)(t.columns[entityColumnPos].At(newPtr, newSlot)) = entityID
@@ -203,6 +265,7 @@ func (t *Table) SpawnCursor(cur *iter.Cursor, idx Idx, n int, colBakes []ColBake
 // index, slots available there, and the capacity of each subsequent chunk.
 func (t *Table) ReserveSlots(count int) (firstIdx Idx, firstAvailable int, chunkCap int) {
 	idx, avail := t.chunkPack.ReserveSlots(count)
+	t.growWrites()
 	return idx, avail, int(t.chunkPack.Layout.ChunkCap)
 }
 
@@ -243,6 +306,99 @@ func (t *Table) Clear() {
 	t.chunkPack.Clear()
 	t.columns = nil
 	t.compColIdx.Reset()
+	t.writes, t.watching = nil, false
+}
+
+// --- Writes ---
+
+// Watch starts counting the writes marked to id's column, for readers
+// caching a summary of its values; writes to unwatched columns go
+// unrecorded. Components the table lacks are skipped.
+func (t *Table) Watch(id comp.ID) {
+	pos := t.compColIdx.Get(id)
+	if pos == invalidColumnPos || t.writes[pos] != nil {
+		return
+	}
+	t.writes[pos] = make([]atomic.Uint32, int(t.chunkPack.NumChunks())+1)
+	t.watching = true
+}
+
+// Watching reports whether any column is watched, so writers can skip
+// marking a table nobody summarizes.
+func (t *Table) Watching() bool { return t.watching }
+
+// MarkWritten records that the values of ids' columns may have changed
+// anywhere in the table. Unwatched columns and components the table lacks
+// are skipped.
+func (t *Table) MarkWritten(ids ...comp.ID) {
+	if !t.watching {
+		return
+	}
+	for _, id := range ids {
+		if counts := t.writeCounts(id); counts != nil {
+			counts[0].Add(1)
+		}
+	}
+}
+
+// MarkChunkWritten is MarkWritten confined to chunk idx.
+func (t *Table) MarkChunkWritten(idx Idx, ids ...comp.ID) {
+	if !t.watching {
+		return
+	}
+	for _, id := range ids {
+		if counts := t.writeCounts(id); counts != nil {
+			counts[1+int(idx)].Add(1)
+		}
+	}
+}
+
+// WriteStamp returns a value that changes whenever a write to id's column
+// is marked for chunk idx or for the whole table; 0 if the column is not
+// watched.
+func (t *Table) WriteStamp(idx Idx, id comp.ID) uint32 {
+	counts := t.writeCounts(id)
+	if counts == nil {
+		return 0
+	}
+	return counts[0].Load() + counts[1+int(idx)].Load()
+}
+
+// Writes returns the number of writes marked to id's column, for any chunk
+// or the whole table; 0 if the column is not watched.
+func (t *Table) Writes(id comp.ID) uint32 {
+	counts := t.writeCounts(id)
+	var n uint32
+	for i := range counts {
+		n += counts[i].Load()
+	}
+	return n
+}
+
+func (t *Table) writeCounts(id comp.ID) []atomic.Uint32 {
+	if pos := t.compColIdx.Get(id); pos != invalidColumnPos {
+		return t.writes[pos]
+	}
+	return nil
+}
+
+// growWrites gives every watched column a counter for each chunk added
+// since it was sized.
+func (t *Table) growWrites() {
+	if !t.watching {
+		return
+	}
+	n := int(t.chunkPack.NumChunks()) + 1
+	for pos, counts := range t.writes {
+		if counts == nil || len(counts) >= n {
+			continue
+		}
+		grown := make([]atomic.Uint32, n)
+		for i := range counts {
+			grown[i].Store(counts[i].Load())
+		}
+		t.writes[pos] = grown
+	}
 }
 
 // --- Internal ---
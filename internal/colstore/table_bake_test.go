@@ -2,8 +2,10 @@ package colstore
 
 import (
 	"testing"
+	"unsafe"
 
 	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
 )
 
 func TestTable_BakeColumnsAndOffsets(t *testing.T) {
@@ -66,3 +68,20 @@ func TestTable_ComponentAt(t *testing.T) {
 		t.Error("expected nil pointer for an untracked component ID")
 	}
 }
+
+func TestTable_BakeChunkOffsetsAndChunkValueAt(t *testing.T) {
+	var tbl Table
+	tbl.InitWithChunk([]comp.Def{{ID: 1, Size: 8, Align: 8}}, []comp.Def{{ID: 5, Size: 4, Align: 4}}, Geometry{})
+
+	offsets := tbl.BakeChunkOffsets([]comp.ID{5, 1})
+	if offsets[0] == iter.Absent || offsets[1] != iter.Absent {
+		t.Fatalf("expected an offset for the per-chunk component only, got %v", offsets)
+	}
+	ptr := tbl.ChunkPtrAt(0)
+	if got, want := tbl.ChunkValueAt(ptr, 5), unsafe.Add(ptr, offsets[0]); got != want {
+		t.Errorf("ChunkValueAt: got %v, want %v", got, want)
+	}
+	if tbl.ChunkValueAt(ptr, 1) != nil {
+		t.Error("expected nil ChunkValueAt for a column")
+	}
+}
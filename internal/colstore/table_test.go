@@ -192,3 +192,51 @@ func TestTable_CopyRangeFrom_CopiesSharedColumns(t *testing.T) {
 		t.Errorf("expected zero accData in dst (not in src), got %+v", gotAcc)
 	}
 }
+
+func TestTable_WriteStamp_TracksMarks(t *testing.T) {
+	tbl := newTestTable(t, []comp.Def{{ID: 1, Size: 8, Align: 8}, {ID: 2, Size: 8, Align: 8}, {ID: 3, Size: 8, Align: 8}})
+	tbl.Watch(1)
+	tbl.Watch(2)
+	tbl.ReserveSlots(3 * tbl.ChunkCap()) // chunks added after Watch get counters too
+
+	if got := tbl.WriteStamp(0, 1); got != 0 {
+		t.Fatalf("fresh stamp = %d, want 0", got)
+	}
+	tbl.MarkChunkWritten(1, 1, 3)
+	if tbl.WriteStamp(0, 1) != 0 {
+		t.Error("a write marked for chunk 1 changed chunk 0's stamp")
+	}
+	if tbl.WriteStamp(1, 1) == 0 {
+		t.Error("a write marked for chunk 1 left its stamp unchanged")
+	}
+	if tbl.WriteStamp(1, 2) != 0 {
+		t.Error("a write to column 1 changed column 2's stamp")
+	}
+
+	before := tbl.WriteStamp(0, 2)
+	tbl.MarkWritten(2, 9) // 9 is not in the table
+	if tbl.WriteStamp(0, 2) == before || tbl.WriteStamp(2, 2) == 0 {
+		t.Error("a table-wide write left a chunk's stamp unchanged")
+	}
+	if got := tbl.Writes(1); got != 1 {
+		t.Errorf("Writes(1) = %d, want 1", got)
+	}
+	if got := tbl.Writes(2); got != 1 {
+		t.Errorf("Writes(2) = %d, want 1", got)
+	}
+	if got := tbl.Writes(3); got != 0 {
+		t.Errorf("Writes(3) = %d, want 0: column 3 is not watched", got)
+	}
+	if got := tbl.Writes(9); got != 0 {
+		t.Errorf("Writes(9) = %d, want 0", got)
+	}
+}
+
+func TestTable_MarkWritten_SkipsUnwatchedTable(t *testing.T) {
+	tbl := newTestTable(t, []comp.Def{{ID: 1, Size: 8, Align: 8}})
+	tbl.MarkChunkWritten(0, 1)
+	tbl.MarkWritten(1)
+	if tbl.Watching() || tbl.Writes(1) != 0 {
+		t.Error("an unwatched table recorded writes")
+	}
+}
@@ -12,7 +12,7 @@ type AccessOpt func(*AccessSpec, *DefIndex) error
 func Include[T any]() AccessOpt {
 	return func(s *AccessSpec, mi *DefIndex) error {
 		compDef := mi.Intern(reflect.TypeFor[T]())
-		return s.Tag(compDef.ID)
+		return s.Require(compDef)
 	}
 }
 
@@ -26,9 +26,57 @@ func Track[T any](col *iter.ArrayRef[T]) AccessOpt {
 	}
 }
 
+// Optional registers T as a tracked data column like Track, without
+// requiring it: archetypes lacking T still match, and col reads nil there.
+func Optional[T any](col *iter.ArrayRef[T]) AccessOpt {
+	return func(s *AccessSpec, mi *DefIndex) error {
+		col.Idx = len(s.CompInfos)
+		compDef := mi.Intern(reflect.TypeFor[T]())
+		return s.Optional(compDef)
+	}
+}
+
+// TrackShared requires shared component T and sets ref.Idx to its position
+// among the spec's shared components, for reading its per-archetype value.
+func TrackShared[T any](ref *iter.SharedRef[T]) AccessOpt {
+	return func(s *AccessSpec, mi *DefIndex) error {
+		ref.Idx = len(s.SharedIDs)
+		compDef := mi.Intern(reflect.TypeFor[T]())
+		return s.Shared(compDef)
+	}
+}
+
+// TrackChunk requires per-chunk component T and sets ref.Idx to its
+// position among the spec's per-chunk components, for reading the current
+// chunk's value.
+func TrackChunk[T any](ref *iter.ChunkRef[T]) AccessOpt {
+	return func(s *AccessSpec, mi *DefIndex) error {
+		ref.Idx = len(s.ChunkDefs)
+		compDef := mi.Intern(reflect.TypeFor[T]())
+		return s.Chunk(compDef)
+	}
+}
+
 func Exclude[T any]() AccessOpt {
 	return func(s *AccessSpec, mi *DefIndex) error {
 		compDef := mi.Intern(reflect.TypeFor[T]())
-		return s.Exclude(compDef.ID)
+		return s.Forbid(compDef)
+	}
+}
+
+// TrackSparse requires sparse component T and hands its storage to bind.
+func TrackSparse[T any](bind func(store any)) AccessOpt {
+	return func(s *AccessSpec, mi *DefIndex) error {
+		compDef := mi.Intern(reflect.TypeFor[T]())
+		return s.AddSparse(SparseUse{Def: compDef, Bind: bind})
+	}
+}
+
+// OptionalSparse is TrackSparse without the requirement: entities lacking
+// T still match.
+func OptionalSparse[T any](bind func(store any)) AccessOpt {
+	return func(s *AccessSpec, mi *DefIndex) error {
+		compDef := mi.Intern(reflect.TypeFor[T]())
+		return s.AddSparse(SparseUse{Def: compDef, Optional: true, Bind: bind})
 	}
 }
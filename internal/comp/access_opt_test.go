@@ -1,6 +1,7 @@
 package comp_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/kjkrol/goke/v3/internal/comp"
@@ -62,3 +63,160 @@ func TestAccessOpt_Exclude(t *testing.T) {
 		t.Errorf("expected Exclude to add an ExCompID, got %v", s.ExCompIDs)
 	}
 }
+
+func TestAccessOpt_Optional(t *testing.T) {
+	var mi comp.DefIndex
+	mi.Init()
+	var s comp.AccessSpec
+	var pos iter.ArrayRef[position]
+	var vel iter.ArrayRef[velocity]
+
+	s.Init(&mi, comp.Track(&pos), comp.Optional(&vel))
+
+	if vel.Idx != 1 || len(s.CompInfos) != 2 {
+		t.Fatalf("expected Optional to track a second data column, got Idx %d and %v", vel.Idx, s.CompInfos)
+	}
+	velDef := s.CompInfos[1]
+	if len(s.OptIDs) != 1 || s.OptIDs[0] != velDef.ID {
+		t.Errorf("expected OptIDs [%d], got %v", velDef.ID, s.OptIDs)
+	}
+	if mask := comp.NewMask(&s); mask.IsSet(velDef.ID) || !mask.IsSet(s.CompInfos[0].ID) {
+		t.Errorf("expected NewMask to require the tracked column only, got %v", mask)
+	}
+	if c := s.Compose(); !c.Mask.IsSet(velDef.ID) {
+		t.Errorf("expected Compose to hold the optional column too, got %v", c.Mask)
+	}
+	if err := comp.Optional(&vel)(&s, &mi); err == nil {
+		t.Error("expected an error tracking the same component twice")
+	}
+}
+
+// Include and Exclude route a sparse component to the spec's sparse uses;
+// it can be neither a data column, a group term, nor part of an edit.
+func TestAccessOpt_Sparse(t *testing.T) {
+	var mi comp.DefIndex
+	mi.Init()
+	mi.InternWith(reflect.TypeFor[rotation](), comp.RegSpec{Sparse: true})
+	var s comp.AccessSpec
+
+	if err := comp.Include[rotation]()(&s, &mi); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.TagIDs) != 0 || len(s.Sparse) != 1 || s.Sparse[0].Exclude {
+		t.Errorf("expected one required sparse use, got tags %v, sparse %v", s.TagIDs, s.Sparse)
+	}
+	if err := comp.Exclude[rotation]()(&s, &mi); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Conflicts(); err == nil {
+		t.Error("expected a conflict for a sparse component both required and excluded")
+	}
+
+	var bound any
+	var tracked comp.AccessSpec
+	if err := comp.OptionalSparse[rotation](func(store any) { bound = store })(&tracked, &mi); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u := tracked.Sparse[0]; !u.Optional || u.Bind == nil || bound != nil {
+		t.Errorf("expected an unbound optional use, got %+v", u)
+	}
+
+	if err := comp.Track(new(iter.ArrayRef[rotation]))(&comp.AccessSpec{}, &mi); err == nil {
+		t.Error("expected Track to reject a sparse component")
+	}
+	if err := comp.AnyOf(comp.Include[rotation]())(&comp.AccessSpec{}, &mi); err == nil {
+		t.Error("expected AnyOf to reject a sparse component")
+	}
+	if err := comp.Add(new(iter.ArrayRef[rotation]))(&comp.EditSpec{}, &mi); err == nil {
+		t.Error("expected Add to reject a sparse component")
+	}
+	if err := comp.TrackSparse[position](nil)(&comp.AccessSpec{}, &mi); err == nil {
+		t.Error("expected TrackSparse to reject a chunk-stored component")
+	}
+}
+
+func TestAccessOpt_Shared(t *testing.T) {
+	var mi comp.DefIndex
+	mi.Init()
+	mi.Intern(reflect.TypeFor[position]())
+	rotDef := mi.InternWith(reflect.TypeFor[rotation](), comp.RegSpec{Shared: true})
+	var s comp.AccessSpec
+
+	var ref iter.SharedRef[rotation]
+	ref.Idx = -1
+	if err := comp.TrackShared(&ref)(&s, &mi); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ref.Idx != 0 || len(s.SharedIDs) != 1 || !comp.NewMask(&s).IsSet(rotDef.ID) {
+		t.Errorf("expected a required shared component at index 0, got idx %d, spec %+v", ref.Idx, s)
+	}
+	if err := comp.TrackShared(&ref)(&s, &mi); err == nil {
+		t.Error("expected a duplicate TrackShared to fail")
+	}
+
+	if err := comp.Track(new(iter.ArrayRef[rotation]))(&comp.AccessSpec{}, &mi); err == nil {
+		t.Error("expected Track to reject a shared component")
+	}
+	if err := comp.Add(new(iter.ArrayRef[rotation]))(&comp.EditSpec{}, &mi); err == nil {
+		t.Error("expected Add to reject a shared component")
+	}
+	if err := comp.TrackShared(new(iter.SharedRef[position]))(&comp.AccessSpec{}, &mi); err == nil {
+		t.Error("expected TrackShared to reject a per-entity component")
+	}
+
+	var es comp.EditSpec
+	if err := comp.Share(rotation{Angle: 1})(&es, &mi); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(es.Shared) != 1 || es.Shared[0].Def.ID != rotDef.ID || (*rotation)(es.Shared[0].Value).Angle != 1 {
+		t.Errorf("expected Share to record the value, got %+v", es.Shared)
+	}
+	if err := comp.Share(position{})(&comp.EditSpec{}, &mi); err == nil {
+		t.Error("expected Share to reject a per-entity component")
+	}
+}
+
+func TestAccessOpt_PerChunk(t *testing.T) {
+	var mi comp.DefIndex
+	mi.Init()
+	mi.Intern(reflect.TypeFor[position]())
+	rotDef := mi.InternWith(reflect.TypeFor[rotation](), comp.RegSpec{PerChunk: true})
+	var s comp.AccessSpec
+
+	var ref iter.ChunkRef[rotation]
+	ref.Idx = -1
+	if err := comp.TrackChunk(&ref)(&s, &mi); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ref.Idx != 0 || len(s.ChunkDefs) != 1 || !comp.NewMask(&s).IsSet(rotDef.ID) {
+		t.Errorf("expected a required per-chunk component at index 0, got idx %d, spec %+v", ref.Idx, s)
+	}
+	if err := comp.TrackChunk(&ref)(&s, &mi); err == nil {
+		t.Error("expected a duplicate TrackChunk to fail")
+	}
+	if len(s.Compose().Chunk) != 1 {
+		t.Error("expected Compose to carry the per-chunk component")
+	}
+
+	if err := comp.Track(new(iter.ArrayRef[rotation]))(&comp.AccessSpec{}, &mi); err == nil {
+		t.Error("expected Track to reject a per-chunk component")
+	}
+	if err := comp.Add(new(iter.ArrayRef[rotation]))(&comp.EditSpec{}, &mi); err == nil {
+		t.Error("expected Add to reject a per-chunk component")
+	}
+	if err := comp.TrackChunk(new(iter.ChunkRef[position]))(&comp.AccessSpec{}, &mi); err == nil {
+		t.Error("expected TrackChunk to reject a per-entity component")
+	}
+
+	var es comp.EditSpec
+	var addRef iter.ChunkRef[rotation]
+	if err := comp.AddChunk(&addRef)(&es, &mi); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(es.ChunkDefs) != 1 || es.ChunkDefs[0].ID != rotDef.ID || addRef.Idx != 0 {
+		t.Errorf("expected AddChunk to record the component, got %+v", es.ChunkDefs)
+	}
+	if err := comp.AddChunk(new(iter.ChunkRef[position]))(&comp.EditSpec{}, &mi); err == nil {
+		t.Error("expected AddChunk to reject a per-entity component")
+	}
+}
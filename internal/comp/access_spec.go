@@ -9,6 +9,22 @@ type AccessSpec struct {
 	CompInfos []Def
 	TagIDs    []ID
 	ExCompIDs []ID
+	OptIDs    []ID    // tracked columns in CompInfos that are not required
+	Groups    []Group // AnyOf/OneOf/AllOf constraints, all of which must hold
+	Sparse    []SparseUse
+	SharedIDs []ID  // required shared components read per block, in track order
+	ChunkDefs []Def // required per-chunk components, in track order
+}
+
+// SparseUse is a sparse component in an AccessSpec: required unless
+// Exclude or Optional is set, and bound to its storage through Bind, if
+// given. Sparse components are tested per entity, not per archetype.
+type SparseUse struct {
+	Def      Def
+	Exclude  bool
+	Optional bool
+	// Bind receives the component's storage once the spec is resolved.
+	Bind func(store any)
 }
 
 // Init applies opts against mi, populating s in place.
@@ -22,8 +38,13 @@ func (s *AccessSpec) Init(mi *DefIndex, opts ...AccessOpt) {
 }
 
 // Compose derives a Composition from the AccessSpec without requiring a DefIndex.
+// Optional columns are part of it: a composition holds every column.
 func (s *AccessSpec) Compose() Composition {
-	return Composition{Mask: NewMask(s), Defs: s.CompInfos}
+	mask := NewMask(s)
+	for _, id := range s.OptIDs {
+		mask = mask.Set(id)
+	}
+	return Composition{Mask: mask, Defs: s.CompInfos, Chunk: s.ChunkDefs}
 }
 
 // CompIDs returns the IDs of the tracked data columns in track order.
@@ -36,6 +57,28 @@ func (s *AccessSpec) CompIDs() []ID {
 	return ids
 }
 
+// Conflicts reports a component that s both requires (or tracks as
+// optional) and excludes — a filter no archetype can satisfy.
+func (s *AccessSpec) Conflicts() error {
+	for i, u := range s.Sparse {
+		for _, v := range s.Sparse[i+1:] {
+			if u.Def.ID == v.Def.ID && u.Exclude != v.Exclude {
+				return fmt.Errorf("component %d cannot be both REQUIRED and EXCLUDED", u.Def.ID)
+			}
+		}
+	}
+	required := NewMask(s)
+	for _, id := range s.ExCompIDs {
+		if required.IsSet(id) {
+			return fmt.Errorf("component %d cannot be both REQUIRED and EXCLUDED", id)
+		}
+		if slices.Contains(s.OptIDs, id) {
+			return fmt.Errorf("component %d cannot be both OPTIONAL and EXCLUDED", id)
+		}
+	}
+	return nil
+}
+
 func (s *AccessSpec) Comp(def Def) error {
 	for _, existing := range s.CompInfos {
 		if existing.ID == def.ID {
@@ -45,10 +88,28 @@ func (s *AccessSpec) Comp(def Def) error {
 	if def.Size == 0 {
 		return fmt.Errorf("cannot add %s: tags are not allowed as data columns", def.Type.String())
 	}
+	if def.Sparse {
+		return fmt.Errorf("cannot add %s: sparse components are not chunk columns", def.Type.String())
+	}
+	if def.Shared {
+		return fmt.Errorf("cannot add %s: shared components are not chunk columns", def.Type.String())
+	}
+	if def.PerChunk {
+		return fmt.Errorf("cannot add %s: per-chunk components are not chunk columns", def.Type.String())
+	}
 	s.CompInfos = append(s.CompInfos, def)
 	return nil
 }
 
+// Optional adds def as a tracked data column that archetypes need not have.
+func (s *AccessSpec) Optional(def Def) error {
+	if err := s.Comp(def); err != nil {
+		return err
+	}
+	s.OptIDs = append(s.OptIDs, def.ID)
+	return nil
+}
+
 func (s *AccessSpec) Tag(tagId ID) error {
 	if slices.Contains(s.TagIDs, tagId) {
 		return fmt.Errorf("tag with ID %d is already in this access spec", tagId)
@@ -64,3 +125,77 @@ func (s *AccessSpec) Exclude(id ID) error {
 	s.ExCompIDs = append(s.ExCompIDs, id)
 	return nil
 }
+
+// Shared adds def, which must be shared, as a required component whose
+// per-archetype value is read through the Cursor.
+func (s *AccessSpec) Shared(def Def) error {
+	if !def.Shared {
+		return fmt.Errorf("component %s is not shared", def.Type)
+	}
+	if slices.Contains(s.SharedIDs, def.ID) {
+		return fmt.Errorf("shared component %s is already in this access spec", def.Type)
+	}
+	s.SharedIDs = append(s.SharedIDs, def.ID)
+	return nil
+}
+
+// Chunk adds def, which must be per-chunk, as a required component whose
+// value for the current chunk is read through the Cursor.
+func (s *AccessSpec) Chunk(def Def) error {
+	if !def.PerChunk {
+		return fmt.Errorf("component %s is not per-chunk", def.Type)
+	}
+	for _, existing := range s.ChunkDefs {
+		if existing.ID == def.ID {
+			return fmt.Errorf("per-chunk component %s is already in this access spec", def.Type)
+		}
+	}
+	s.ChunkDefs = append(s.ChunkDefs, def)
+	return nil
+}
+
+// ChunkIDs returns the IDs of the tracked per-chunk components in track
+// order.
+func (s *AccessSpec) ChunkIDs() []ID {
+	ids := make([]ID, len(s.ChunkDefs))
+	for i, def := range s.ChunkDefs {
+		ids[i] = def.ID
+	}
+	return ids
+}
+
+// Require adds def as a filter-only requirement: a Tag, or a required
+// SparseUse if def is sparse.
+func (s *AccessSpec) Require(def Def) error {
+	if def.Sparse {
+		return s.addSparse(SparseUse{Def: def})
+	}
+	return s.Tag(def.ID)
+}
+
+// Forbid adds def as an exclusion: an Exclude, or an excluded SparseUse if
+// def is sparse.
+func (s *AccessSpec) Forbid(def Def) error {
+	if def.Sparse {
+		return s.addSparse(SparseUse{Def: def, Exclude: true})
+	}
+	return s.Exclude(def.ID)
+}
+
+// AddSparse adds u, which must name a sparse component.
+func (s *AccessSpec) AddSparse(u SparseUse) error {
+	if !u.Def.Sparse {
+		return fmt.Errorf("component %s is not sparse", u.Def.Type)
+	}
+	return s.addSparse(u)
+}
+
+func (s *AccessSpec) addSparse(u SparseUse) error {
+	for _, v := range s.Sparse {
+		if v.Def.ID == u.Def.ID && v.Exclude == u.Exclude {
+			return fmt.Errorf("sparse component %s is already in this access spec", u.Def.Type)
+		}
+	}
+	s.Sparse = append(s.Sparse, u)
+	return nil
+}
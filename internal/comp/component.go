@@ -17,4 +17,16 @@ type Def struct {
 	Size  uintptr
 	Align uintptr
 	Type  reflect.Type
+	// Key is the type's stable identity outside the process: its RegSpec
+	// name if one was given, otherwise its [TypeKey].
+	Key string
+	// Sparse marks a type kept in a per-component sparse set keyed by
+	// entity, outside archetype masks and chunks — see RegSpec.Sparse.
+	Sparse bool
+	// Shared marks a type stored once per archetype instead of per entity:
+	// its value is part of the archetype's identity — see RegSpec.Shared.
+	Shared bool
+	// PerChunk marks a type stored once per chunk of the archetypes that
+	// have it, as chunk metadata — see RegSpec.PerChunk.
+	PerChunk bool
 }
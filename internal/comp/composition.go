@@ -1,24 +1,73 @@
 package comp
 
+import "slices"
+
 // Composition describes the component composition of an archetype:
-// a Mask (bitset of IDs) and the metadata for each non-tag component.
-// Immutable after construction — use With and Without to derive new instances.
+// a Mask (bitset of IDs), the metadata for each non-tag component and each
+// per-chunk component, and the value of each shared component.
+// Immutable after construction — use With, WithShared and Without to derive
+// new instances.
 type Composition struct {
-	Mask Mask
-	Defs []Def
+	Mask   Mask
+	Defs   []Def
+	Chunk  []Def       // per-chunk components, kept once per chunk
+	Shared []SharedVal // sorted by ID
+}
+
+// SharedVal is a shared component's value in a Composition, by the handle
+// the archetype catalog interned it under. Two compositions with the same
+// mask but different SharedVals are different archetypes.
+type SharedVal struct {
+	ID  ID
+	Val uint32
 }
 
 // With returns a new Composition with compDef added.
-// Tags (Size == 0) update only the mask, not Defs.
+// Tags (Size == 0) update only the mask, not Defs; a per-chunk compDef goes
+// to Chunk. Panics for a shared compDef, which needs a value — use
+// WithShared.
 func (s Composition) With(compDef Def) Composition {
+	if compDef.Shared {
+		panic("comp: With of shared component " + compDef.Type.String() + " — use WithShared")
+	}
 	newMask := s.Mask.Set(compDef.ID)
 	if compDef.Size == 0 {
-		return Composition{Mask: newMask, Defs: s.Defs}
+		return Composition{Mask: newMask, Defs: s.Defs, Chunk: s.Chunk, Shared: s.Shared}
+	}
+	if compDef.PerChunk {
+		return Composition{Mask: newMask, Defs: s.Defs, Chunk: append(slices.Clip(s.Chunk), compDef), Shared: s.Shared}
 	}
 	newDefs := make([]Def, len(s.Defs)+1)
 	copy(newDefs, s.Defs)
 	newDefs[len(s.Defs)] = compDef
-	return Composition{Mask: newMask, Defs: newDefs}
+	return Composition{Mask: newMask, Defs: newDefs, Chunk: s.Chunk, Shared: s.Shared}
+}
+
+// WithShared returns a new Composition with shared component compID set to
+// the value interned as val, replacing any value it had.
+func (s Composition) WithShared(compID ID, val uint32) Composition {
+	i, found := slices.BinarySearchFunc(s.Shared, compID, func(v SharedVal, id ID) int { return int(v.ID) - int(id) })
+	if found && s.Shared[i].Val == val {
+		return s
+	}
+	newShared := slices.Clone(s.Shared)
+	if found {
+		newShared[i].Val = val
+	} else {
+		newShared = slices.Insert(newShared, i, SharedVal{ID: compID, Val: val})
+	}
+	return Composition{Mask: s.Mask.Set(compID), Defs: s.Defs, Chunk: s.Chunk, Shared: newShared}
+}
+
+// SharedVal returns the value handle of shared component compID, and
+// whether s has it.
+func (s Composition) SharedVal(compID ID) (uint32, bool) {
+	for _, v := range s.Shared {
+		if v.ID == compID {
+			return v.Val, true
+		}
+	}
+	return 0, false
 }
 
 // Without returns a new Composition with compID removed.
@@ -34,5 +83,17 @@ func (s Composition) Without(compID ID) Composition {
 			newMetas = append(newMetas, m)
 		}
 	}
-	return Composition{Mask: newMask, Defs: newMetas}
+	var newChunk []Def
+	for _, m := range s.Chunk {
+		if m.ID != compID {
+			newChunk = append(newChunk, m)
+		}
+	}
+	var newShared []SharedVal
+	for _, v := range s.Shared {
+		if v.ID != compID {
+			newShared = append(newShared, v)
+		}
+	}
+	return Composition{Mask: newMask, Defs: newMetas, Chunk: newChunk, Shared: newShared}
 }
@@ -1,6 +1,7 @@
 package comp_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/kjkrol/goke/v3/internal/comp"
@@ -68,3 +69,61 @@ func TestComposition_Without(t *testing.T) {
 		}
 	})
 }
+
+func TestComposition_WithShared(t *testing.T) {
+	var c comp.Composition
+	c = c.With(comp.Def{ID: 1, Size: 8})
+
+	a := c.WithShared(5, 0).WithShared(3, 7)
+	if !a.Mask.IsSet(3) || !a.Mask.IsSet(5) {
+		t.Error("expected mask bits 3 and 5 to be set")
+	}
+	if want := []comp.SharedVal{{ID: 3, Val: 7}, {ID: 5, Val: 0}}; !slices.Equal(a.Shared, want) {
+		t.Errorf("expected Shared sorted by ID %v, got %v", want, a.Shared)
+	}
+	if len(a.Defs) != 1 {
+		t.Errorf("expected shared components to add no column, got %v", a.Defs)
+	}
+
+	b := a.WithShared(3, 9)
+	if val, _ := b.SharedVal(3); val != 9 {
+		t.Errorf("expected WithShared to replace the value, got %d", val)
+	}
+	if val, _ := a.SharedVal(3); val != 7 {
+		t.Error("expected WithShared to leave the receiver unchanged")
+	}
+
+	d := b.Without(3)
+	if _, ok := d.SharedVal(3); ok || d.Mask.IsSet(3) {
+		t.Error("expected Without to drop the shared value and its bit")
+	}
+	if _, ok := d.SharedVal(5); !ok {
+		t.Error("expected Without to keep the other shared values")
+	}
+}
+
+func TestComposition_WithPerChunk(t *testing.T) {
+	var c comp.Composition
+	c = c.With(comp.Def{ID: 1, Size: 8})
+
+	a := c.With(comp.Def{ID: 4, Size: 16, PerChunk: true})
+	if !a.Mask.IsSet(4) {
+		t.Error("expected mask bit 4 to be set")
+	}
+	if len(a.Defs) != 1 || len(a.Chunk) != 1 || a.Chunk[0].ID != 4 {
+		t.Errorf("expected a per-chunk def and no new column, got Defs %v Chunk %v", a.Defs, a.Chunk)
+	}
+
+	b := a.With(comp.Def{ID: 2, Size: 8})
+	if len(b.Chunk) != 1 {
+		t.Errorf("expected With to keep the per-chunk defs, got %v", b.Chunk)
+	}
+
+	d := b.Without(4)
+	if len(d.Chunk) != 0 || d.Mask.IsSet(4) {
+		t.Error("expected Without to drop the per-chunk def and its bit")
+	}
+	if len(a.Chunk) != 1 {
+		t.Error("expected Without to leave the receiver unchanged")
+	}
+}
@@ -1,9 +1,11 @@
 package comp
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"reflect"
+	"strings"
 )
 
 // DefIndex maps Go types to stable [Def] descriptors.
@@ -12,17 +14,20 @@ import (
 type DefIndex struct {
 	typeIndex map[reflect.Type]Def
 	idIndex   [MaxComponents]Def
+	keyIndex  map[string]ID // every Key and alias claimed so far
 }
 
 func (r *DefIndex) Init() {
 	r.typeIndex = make(map[reflect.Type]Def)
+	r.keyIndex = make(map[string]ID)
 }
 
 func (r *DefIndex) Reset() {
 	if r.typeIndex == nil {
-		r.typeIndex = make(map[reflect.Type]Def)
+		r.Init()
 	} else {
 		clear(r.typeIndex)
+		clear(r.keyIndex)
 	}
 	r.idIndex = [MaxComponents]Def{}
 }
@@ -41,6 +46,35 @@ func (r *DefIndex) Intern(t reflect.Type) Def {
 	if info, ok := r.typeIndex[t]; ok {
 		return info
 	}
+	return r.InternWith(t, RegSpec{})
+}
+
+// InternWith is [DefIndex.Intern] with registration options: spec.Name
+// replaces the type's TypeKey as its Def.Key, and spec.Aliases are claimed
+// alongside it. Every key and alias must be unique across registered types,
+// and a type already registered under a different name cannot be renamed —
+// both panic, as does asking for sparse, shared or per-chunk storage once a
+// type is registered without it. A shared type must have data and no
+// off-chunk or reference fields, a per-chunk type data and no off-chunk
+// fields, and a type takes at most one of the three. Re-registering with
+// an empty spec, or the same name, returns the existing Def.
+func (r *DefIndex) InternWith(t reflect.Type, spec RegSpec) Def {
+	if info, ok := r.typeIndex[t]; ok {
+		if spec.Name != "" && spec.Name != info.Key {
+			panic(fmt.Sprintf("comp: component %s is already registered as %q, cannot rename it to %q", t, info.Key, spec.Name))
+		}
+		if spec.Sparse && !info.Sparse {
+			panic(fmt.Sprintf("comp: component %s is already registered with chunk storage, cannot make it sparse", t))
+		}
+		if spec.Shared && !info.Shared {
+			panic(fmt.Sprintf("comp: component %s is already registered with per-entity storage, cannot make it shared", t))
+		}
+		if spec.PerChunk && !info.PerChunk {
+			panic(fmt.Sprintf("comp: component %s is already registered with per-entity storage, cannot make it per-chunk", t))
+		}
+		r.claimKeys(t, info.ID, spec.Aliases)
+		return info
+	}
 
 	if err := ValidateEncodable(t); err != nil {
 		panic(fmt.Sprintf("comp: cannot register component %s: %v", t, err))
@@ -50,16 +84,36 @@ func (r *DefIndex) Intern(t reflect.Type) Def {
 		panic(fmt.Sprintf("too many components registered: MaxComponents (%d) limit reached", MaxComponents))
 	}
 
+	if spec.Shared {
+		if err := validateShared(t, spec); err != nil {
+			panic(fmt.Sprintf("comp: cannot register component %s as shared: %v", t, err))
+		}
+	}
+	if spec.PerChunk {
+		if err := validatePerChunk(t, spec); err != nil {
+			panic(fmt.Sprintf("comp: cannot register component %s as per-chunk: %v", t, err))
+		}
+	}
+
 	for _, path := range OffChunkFields(t) {
 		log.Printf("comp: component %s: %s requires a dereference outside the archetype's contiguous chunk memory during iteration, degrading cache locality — consider a fixed-size alternative if this component is iterated in a hot loop", t, path)
 	}
 
+	key := spec.Name
+	if key == "" {
+		key = TypeKey(t)
+	}
 	id := ID(len(r.typeIndex))
+	r.claimKeys(t, id, append([]string{key}, spec.Aliases...))
 	info := Def{
-		ID:    id,
-		Size:  t.Size(),
-		Align: uintptr(t.Align()),
-		Type:  t,
+		ID:       id,
+		Size:     t.Size(),
+		Align:    uintptr(t.Align()),
+		Type:     t,
+		Key:      key,
+		Sparse:   spec.Sparse,
+		Shared:   spec.Shared,
+		PerChunk: spec.PerChunk,
 	}
 
 	r.typeIndex[t] = info
@@ -67,6 +121,70 @@ func (r *DefIndex) Intern(t reflect.Type) Def {
 	return info
 }
 
+// validateShared checks t can be a shared component: archetypes are told
+// apart by its value's bytes, so it needs some, and none may point
+// elsewhere.
+func validateShared(t reflect.Type, spec RegSpec) error {
+	if spec.Sparse {
+		return errors.New("a component cannot be both sparse and shared")
+	}
+	if t.Size() == 0 {
+		return errors.New("a tag has no value to share")
+	}
+	if paths := OffChunkFields(t); len(paths) > 0 {
+		path := paths[0]
+		if path == "" {
+			path = t.String()
+		}
+		return fmt.Errorf("values are compared by their bytes, and %s points outside them", path)
+	}
+	if refs := RefFields(t); len(refs) > 0 {
+		return fmt.Errorf("values are interned by their bytes, so a merge could not remap the entity ID in %s", refs[0].Path)
+	}
+	return nil
+}
+
+// validatePerChunk checks t can be a per-chunk component: it needs data,
+// and none of it may point elsewhere.
+func validatePerChunk(t reflect.Type, spec RegSpec) error {
+	if spec.Sparse || spec.Shared {
+		return errors.New("a per-chunk component cannot also be sparse or shared")
+	}
+	if t.Size() == 0 {
+		return errors.New("a tag has no value to keep per chunk")
+	}
+	if paths := OffChunkFields(t); len(paths) > 0 {
+		path := paths[0]
+		if path == "" {
+			path = t.String()
+		}
+		return fmt.Errorf("values are cleared as raw bytes when their chunk empties, and %s points outside them", path)
+	}
+	return nil
+}
+
+// claimKeys records keys as belonging to id, panicking if another type
+// already claimed one of them. Validated up front, so a panic leaves the
+// index unchanged.
+func (r *DefIndex) claimKeys(t reflect.Type, id ID, keys []string) {
+	for _, k := range keys {
+		if owner, taken := r.keyIndex[k]; taken && owner != id {
+			panic(fmt.Sprintf("comp: cannot register component %s under key %q: already used by %s", t, k, r.idIndex[owner].Type))
+		}
+	}
+	for _, k := range keys {
+		r.keyIndex[k] = id
+	}
+}
+
+// ByKey looks up a registered component by its Key or one of its aliases.
+func (r *DefIndex) ByKey(key string) (Def, bool) {
+	if id, ok := r.keyIndex[key]; ok {
+		return r.idIndex[id], true
+	}
+	return Def{}, false
+}
+
 // ByType looks up a registered component by its Go type.
 func (r *DefIndex) ByType(t reflect.Type) (Def, bool) {
 	if info, ok := r.typeIndex[t]; ok {
@@ -84,3 +202,29 @@ func (r *DefIndex) ByID(id ID) Def {
 func (r *DefIndex) Count() int {
 	return len(r.typeIndex)
 }
+
+// Lookup resolves a component by name for tools and query expressions: its
+// Key or an alias first, then its bare type name ("Pos") or package-qualified
+// one ("physics.Pos"), if exactly one registered type has it.
+func (r *DefIndex) Lookup(name string) (Def, error) {
+	if def, ok := r.ByKey(name); ok {
+		return def, nil
+	}
+	var found []Def
+	for _, def := range r.idIndex[:r.Count()] {
+		if def.Type.Name() == name || def.Type.String() == name {
+			found = append(found, def)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Def{}, fmt.Errorf("unknown component %q", name)
+	case 1:
+		return found[0], nil
+	}
+	keys := make([]string, len(found))
+	for i, def := range found {
+		keys[i] = def.Key
+	}
+	return Def{}, fmt.Errorf("component name %q is ambiguous: %s", name, strings.Join(keys, ", "))
+}
@@ -239,3 +239,144 @@ func TestDefIndex_InternPanicsWhenFull(t *testing.T) {
 	}()
 	c.Intern(reflect.ArrayOf(comp.MaxComponents+1, reflect.TypeFor[byte]()))
 }
+
+func TestDefIndex_InternWith_Name(t *testing.T) {
+	c := newDefIndex()
+	def := c.InternWith(reflect.TypeFor[position](), comp.NewRegSpec(comp.WithName("game.Position"), comp.WithAliases("old.Pos")))
+
+	if def.Key != "game.Position" {
+		t.Errorf("expected Key %q, got %q", "game.Position", def.Key)
+	}
+	for _, key := range []string{"game.Position", "old.Pos"} {
+		if got, ok := c.ByKey(key); !ok || got.ID != def.ID {
+			t.Errorf("ByKey(%q): expected ID %d, got %v, %v", key, def.ID, got.ID, ok)
+		}
+	}
+	if again := c.Intern(reflect.TypeFor[position]()); again.Key != def.Key {
+		t.Errorf("expected plain Intern to return the named Def, got Key %q", again.Key)
+	}
+}
+
+func TestDefIndex_Intern_DefaultKeyIsTypeKey(t *testing.T) {
+	c := newDefIndex()
+	rt := reflect.TypeFor[velocity]()
+	if def := c.Intern(rt); def.Key != comp.TypeKey(rt) {
+		t.Errorf("expected Key %q, got %q", comp.TypeKey(rt), def.Key)
+	}
+}
+
+func TestDefIndex_InternWith_Conflicts(t *testing.T) {
+	cases := map[string]func(c *comp.DefIndex){
+		"duplicate name": func(c *comp.DefIndex) {
+			c.InternWith(reflect.TypeFor[velocity](), comp.NewRegSpec(comp.WithName("game.Position")))
+		},
+		"alias taken by a name": func(c *comp.DefIndex) {
+			c.InternWith(reflect.TypeFor[velocity](), comp.NewRegSpec(comp.WithAliases("game.Position")))
+		},
+		"rename": func(c *comp.DefIndex) {
+			c.InternWith(reflect.TypeFor[position](), comp.NewRegSpec(comp.WithName("game.Pos2")))
+		},
+	}
+	for name, register := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := newDefIndex()
+			c.InternWith(reflect.TypeFor[position](), comp.NewRegSpec(comp.WithName("game.Position")))
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected a panic")
+				}
+			}()
+			register(&c)
+		})
+	}
+}
+
+func TestDefIndex_Reset_ReleasesKeys(t *testing.T) {
+	c := newDefIndex()
+	c.InternWith(reflect.TypeFor[position](), comp.NewRegSpec(comp.WithName("game.Position")))
+	c.Reset()
+	if _, ok := c.ByKey("game.Position"); ok {
+		t.Fatal("expected Reset to release claimed keys")
+	}
+	c.InternWith(reflect.TypeFor[velocity](), comp.NewRegSpec(comp.WithName("game.Position")))
+}
+
+func TestDefIndex_InternWithSparse(t *testing.T) {
+	c := newDefIndex()
+	def := c.InternWith(reflect.TypeFor[velocity](), comp.RegSpec{Sparse: true})
+	if !def.Sparse || !c.Intern(reflect.TypeFor[velocity]()).Sparse {
+		t.Error("expected the sparse flag to be recorded and kept")
+	}
+
+	c.Intern(reflect.TypeFor[position]())
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic making a registered chunk component sparse")
+		}
+	}()
+	c.InternWith(reflect.TypeFor[position](), comp.RegSpec{Sparse: true})
+}
+
+func TestDefIndex_InternWithShared(t *testing.T) {
+	c := newDefIndex()
+	def := c.InternWith(reflect.TypeFor[velocity](), comp.RegSpec{Shared: true})
+	if !def.Shared || !c.Intern(reflect.TypeFor[velocity]()).Shared {
+		t.Error("expected the shared flag to be recorded and kept")
+	}
+
+	type label struct{ Name string }
+	type marker struct{}
+	type owner struct {
+		ID uint64 `goke:"ref"`
+	}
+	cases := map[string]struct {
+		t    reflect.Type
+		spec comp.RegSpec
+	}{
+		"off-chunk field":    {reflect.TypeFor[label](), comp.RegSpec{Shared: true}},
+		"reference field":    {reflect.TypeFor[owner](), comp.RegSpec{Shared: true}},
+		"tag":                {reflect.TypeFor[marker](), comp.RegSpec{Shared: true}},
+		"sparse too":         {reflect.TypeFor[rotation](), comp.RegSpec{Shared: true, Sparse: true}},
+		"registered already": {c.Intern(reflect.TypeFor[position]()).Type, comp.RegSpec{Shared: true}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			c.InternWith(tc.t, tc.spec)
+		})
+	}
+}
+
+func TestDefIndex_InternWithPerChunk(t *testing.T) {
+	c := newDefIndex()
+	def := c.InternWith(reflect.TypeFor[velocity](), comp.RegSpec{PerChunk: true})
+	if !def.PerChunk || !c.Intern(reflect.TypeFor[velocity]()).PerChunk {
+		t.Error("expected the per-chunk flag to be recorded and kept")
+	}
+
+	type label struct{ Name string }
+	type marker struct{}
+	cases := map[string]struct {
+		t    reflect.Type
+		spec comp.RegSpec
+	}{
+		"off-chunk field":    {reflect.TypeFor[label](), comp.RegSpec{PerChunk: true}},
+		"tag":                {reflect.TypeFor[marker](), comp.RegSpec{PerChunk: true}},
+		"sparse too":         {reflect.TypeFor[rotation](), comp.RegSpec{PerChunk: true, Sparse: true}},
+		"registered already": {c.Intern(reflect.TypeFor[position]()).Type, comp.RegSpec{PerChunk: true}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			c.InternWith(tc.t, tc.spec)
+		})
+	}
+}
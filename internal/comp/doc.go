@@ -10,6 +10,15 @@
 // [DefIndex] maps Go types to [Def] in O(1) and resolves an [ID] back to its
 // [Def] via [DefIndex.ByID].
 //
+// # Keys
+//
+// Every [Def] carries a Key — its identity outside the process, e.g. in a
+// save file. By default that is its [TypeKey] (import path plus type name);
+// registering through [DefIndex.InternWith] with a [RegSpec] ([WithName],
+// [WithAliases]) replaces it with a stable, user-chosen name and claims
+// former keys as aliases. Keys and aliases are unique across types;
+// [DefIndex.ByKey] resolves either back to its [Def].
+//
 // # Mask
 //
 // [Mask] is a fixed-size bitset of component IDs.
@@ -18,8 +27,16 @@
 //
 // [AccessOpt] is a functional option that configures an [AccessSpec]:
 //   - [Track][T] — registers T as a data column; sets ArrayRef[T].Idx to its position
+//   - [Optional][T] — like Track, but archetypes lacking T still match
 //   - [Include][T] — adds T as a filter-only requirement (no data column)
 //   - [Exclude][T] — adds T as an exclusion constraint
+//   - [TrackShared][T] — requires shared T; sets SharedRef[T].Idx to its
+//     position in Cursor.Shared
+//   - [TrackChunk][T] — requires per-chunk T; sets ChunkRef[T].Idx to its
+//     position in Cursor.ChunkOffsets
+//   - [AnyOf], [OneOf], [AllOf] — combine the filter opts above (and each
+//     other) into a [Group], one [Term] per opt, for disjunctive and nested
+//     filters
 //
 // # Encoding constraints
 //
@@ -31,6 +48,28 @@
 // UnsafePointer, Slice, Map, Interface, Chan, and Func are rejected unless
 // covered by that escape hatch. See [ValidateEncodable] and
 // [OffChunkFields].
+//
+// # Shared components
+//
+// A type registered with [WithShared] has one value per archetype: a
+// [Composition] lists it in Shared, by the handle its value is interned
+// under, and not in Defs. [Share] sets one in an [EditSpec]. Values are
+// told apart by their bytes, so the type must have some and none may
+// point outside it.
+//
+// # Per-chunk components
+//
+// A type registered with [WithPerChunk] has one value per chunk: a
+// [Composition] lists it in Chunk rather than Defs, and the chunk layout
+// reserves room for it once. [AddChunk] adds one in an [EditSpec]; its
+// value starts zeroed in each new chunk.
+//
+// # References
+//
+// A struct field tagged `goke:"ref"` holds another entity's ID. [RefFields]
+// lists every such field's offset, through nested structs and arrays, so a
+// loader that assigns fresh IDs can rewrite them.
+//
 // # Constants
 //
 //	MaskSize      = 2    // number of uint64 words in Mask
@@ -3,15 +3,25 @@ package comp
 import (
 	"fmt"
 	"reflect"
+	"unsafe"
 
 	"github.com/kjkrol/goke/v3/iter"
 )
 
 // EditSpec is the set of structural changes an Editor applies to an entity:
-// components to add (with their column bound for value writes) and to remove.
+// components to add (with their column bound for value writes), per-chunk
+// components to add, shared components to set, and components to remove.
 type EditSpec struct {
-	AddDefs []Def
-	DelDefs []Def
+	AddDefs   []Def
+	DelDefs   []Def
+	ChunkDefs []Def
+	Shared    []SharedInit
+}
+
+// SharedInit is a shared component an EditSpec sets, with its value.
+type SharedInit struct {
+	Def   Def
+	Value unsafe.Pointer
 }
 
 // EditOpt configures an EditSpec — the structural counterpart of [AccessOpt]
@@ -31,15 +41,68 @@ func (s *EditSpec) Init(mi *DefIndex, opts ...EditOpt) {
 // after the edit. col.Idx is set to T's position among the added columns.
 func Add[T any](col *iter.ArrayRef[T]) EditOpt {
 	return func(s *EditSpec, mi *DefIndex) error {
+		def := mi.Intern(reflect.TypeFor[T]())
+		if def.Sparse {
+			return errSparseEdit(def)
+		}
+		if def.Shared {
+			return fmt.Errorf("%s is shared: set its value with Share, not as a column", def.Type)
+		}
+		if def.PerChunk {
+			return fmt.Errorf("%s is per-chunk: add it with AddChunk, not as a column", def.Type)
+		}
 		col.Idx = len(s.AddDefs)
-		s.AddDefs = append(s.AddDefs, mi.Intern(reflect.TypeFor[T]()))
+		s.AddDefs = append(s.AddDefs, def)
+		return nil
+	}
+}
+
+// AddChunk registers per-chunk component T to add, binding ref so a
+// Factory's cursor reads the value of each chunk it spawns into. ref.Idx is
+// set to T's position among the added per-chunk components.
+func AddChunk[T any](ref *iter.ChunkRef[T]) EditOpt {
+	return func(s *EditSpec, mi *DefIndex) error {
+		def := mi.Intern(reflect.TypeFor[T]())
+		if !def.PerChunk {
+			return fmt.Errorf("component %s is not per-chunk", def.Type)
+		}
+		ref.Idx = len(s.ChunkDefs)
+		s.ChunkDefs = append(s.ChunkDefs, def)
+		return nil
+	}
+}
+
+// Share registers shared component T to be set to value, moving the
+// entities to the archetype holding it.
+func Share[T any](value T) EditOpt {
+	return func(s *EditSpec, mi *DefIndex) error {
+		def := mi.Intern(reflect.TypeFor[T]())
+		if !def.Shared {
+			return fmt.Errorf("component %s is not shared", def.Type)
+		}
+		for _, v := range s.Shared {
+			if v.Def.ID == def.ID {
+				return fmt.Errorf("shared component %s is already set by this edit", def.Type)
+			}
+		}
+		s.Shared = append(s.Shared, SharedInit{Def: def, Value: unsafe.Pointer(&value)})
 		return nil
 	}
 }
 
 func Del[T any]() EditOpt {
 	return func(s *EditSpec, mi *DefIndex) error {
-		s.DelDefs = append(s.DelDefs, mi.Intern(reflect.TypeFor[T]()))
+		def := mi.Intern(reflect.TypeFor[T]())
+		if def.Sparse {
+			return errSparseEdit(def)
+		}
+		s.DelDefs = append(s.DelDefs, def)
 		return nil
 	}
 }
+
+// errSparseEdit rejects a sparse component in an EditSpec: edits move
+// entities between archetypes, and a sparse component belongs to none.
+func errSparseEdit(def Def) error {
+	return fmt.Errorf("%s is sparse: add or remove it per entity (CmdBuf.AddOne, RemoveCompOne), not by archetype edit", def.Type)
+}
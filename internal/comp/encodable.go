@@ -117,6 +117,46 @@ func OffChunkFields(t reflect.Type) []string {
 	return out
 }
 
+// HasOpaqueIndirection reports whether t (recursively) contains a type
+// resolved via encoding.BinaryMarshaler/BinaryUnmarshaler whose own layout
+// holds a pointer, slice, map, interface, channel, or func — state that can
+// change without a single byte of t's in-chunk representation changing.
+// Strings are immutable and do not count. Assumes t is already known to be
+// encodable.
+func HasOpaqueIndirection(t reflect.Type) bool {
+	if implementsBinaryCodec(t) {
+		return holdsIndirection(t)
+	}
+	switch t.Kind() {
+	case reflect.Array:
+		return HasOpaqueIndirection(t.Elem())
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if HasOpaqueIndirection(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func holdsIndirection(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.UnsafePointer, reflect.Slice, reflect.Map,
+		reflect.Interface, reflect.Chan, reflect.Func:
+		return true
+	case reflect.Array:
+		return holdsIndirection(t.Elem())
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if holdsIndirection(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func collectOffChunkFields(t reflect.Type, fieldPath string, out *[]string) {
 	if implementsBinaryCodec(t) {
 		*out = append(*out, displayPath(fieldPath))
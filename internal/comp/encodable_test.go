@@ -263,3 +263,27 @@ func TestDefIndex_Intern_NoLogForPurePOD(t *testing.T) {
 		t.Errorf("expected no warning for a pure POD type, got: %s", buf.String())
 	}
 }
+
+// packedStamp is an opaque codec type with a pointer-free layout — its
+// in-chunk bytes are its whole state.
+type packedStamp struct{ V int64 }
+
+func (s packedStamp) MarshalBinary() ([]byte, error)     { return nil, nil }
+func (s *packedStamp) UnmarshalBinary(data []byte) error { return nil }
+
+func TestHasOpaqueIndirection(t *testing.T) {
+	cases := map[reflect.Type]bool{
+		reflect.TypeFor[withString]():              false,
+		reflect.TypeFor[nestedPod]():               false,
+		reflect.TypeFor[packedStamp]():             false,
+		reflect.TypeFor[marshaledType]():           true,
+		reflect.TypeFor[withMarshaled]():           true,
+		reflect.TypeFor[[2]withMarshaled]():        true,
+		reflect.TypeFor[struct{ S packedStamp }](): false,
+	}
+	for rt, want := range cases {
+		if got := comp.HasOpaqueIndirection(rt); got != want {
+			t.Errorf("HasOpaqueIndirection(%v) = %v, want %v", rt, got, want)
+		}
+	}
+}
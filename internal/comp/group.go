@@ -0,0 +1,109 @@
+package comp
+
+import "fmt"
+
+// GroupKind is how a Group combines its terms.
+type GroupKind uint8
+
+const (
+	AnyOfGroup GroupKind = iota // at least one term holds
+	OneOfGroup                  // exactly one term holds
+	AllOfGroup                  // every term holds
+)
+
+func (k GroupKind) String() string {
+	switch k {
+	case AnyOfGroup:
+		return "AnyOf"
+	case OneOfGroup:
+		return "OneOf"
+	case AllOfGroup:
+		return "AllOf"
+	}
+	return fmt.Sprintf("GroupKind(%d)", k)
+}
+
+// Term is a conjunction over an archetype's mask: every component in
+// Include, none in Exclude, and every nested group satisfied.
+type Term struct {
+	Include Mask
+	Exclude Mask
+	Groups  []Group
+}
+
+// Matches reports whether mask satisfies t.
+func (t *Term) Matches(mask Mask) bool {
+	return mask.Matches(t.Include, t.Exclude) && mask.Satisfies(t.Groups)
+}
+
+// Group combines terms by Kind — one term per opt passed to AnyOf, OneOf
+// or AllOf.
+type Group struct {
+	Kind  GroupKind
+	Terms []Term
+}
+
+// Matches reports whether mask satisfies g.
+func (g *Group) Matches(mask Mask) bool {
+	n := 0
+	for i := range g.Terms {
+		if g.Terms[i].Matches(mask) {
+			n++
+		} else if g.Kind == AllOfGroup {
+			return false
+		}
+		if n > 0 && g.Kind == AnyOfGroup || n > 1 && g.Kind == OneOfGroup {
+			break
+		}
+	}
+	switch g.Kind {
+	case AnyOfGroup:
+		return n > 0
+	case OneOfGroup:
+		return n == 1
+	default:
+		return n == len(g.Terms)
+	}
+}
+
+// AnyOf matches archetypes satisfying at least one of opts — e.g.
+// AnyOf(Include[Circle](), Include[Rect]()).
+func AnyOf(opts ...AccessOpt) AccessOpt { return group(AnyOfGroup, opts) }
+
+// OneOf matches archetypes satisfying exactly one of opts.
+func OneOf(opts ...AccessOpt) AccessOpt { return group(OneOfGroup, opts) }
+
+// AllOf matches archetypes satisfying every one of opts — a conjunction to
+// nest inside AnyOf or OneOf, e.g. AnyOf(AllOf(Include[A](), Include[B]()), Include[C]()).
+func AllOf(opts ...AccessOpt) AccessOpt { return group(AllOfGroup, opts) }
+
+// group builds a Group of kind with one term per opt. Each opt may be an
+// Include, an Exclude, or another group; data columns (Track, Optional)
+// are rejected, since which archetypes hold them is left open.
+func group(kind GroupKind, opts []AccessOpt) AccessOpt {
+	return func(s *AccessSpec, mi *DefIndex) error {
+		if len(opts) == 0 {
+			return fmt.Errorf("%s needs at least one opt", kind)
+		}
+		g := Group{Kind: kind, Terms: make([]Term, len(opts))}
+		for i, opt := range opts {
+			var sub AccessSpec
+			if err := opt(&sub, mi); err != nil {
+				return fmt.Errorf("%s: %w", kind, err)
+			}
+			if len(sub.CompInfos) > 0 {
+				return fmt.Errorf("%s takes filter opts only: %s is a data column", kind, sub.CompInfos[0].Type)
+			}
+			if len(sub.Sparse) > 0 {
+				return fmt.Errorf("%s matches archetypes: sparse component %s belongs to none", kind, sub.Sparse[0].Def.Type)
+			}
+			t := Term{Include: NewMask(&sub), Groups: sub.Groups}
+			for _, id := range sub.ExCompIDs {
+				t.Exclude = t.Exclude.Set(id)
+			}
+			g.Terms[i] = t
+		}
+		s.Groups = append(s.Groups, g)
+		return nil
+	}
+}
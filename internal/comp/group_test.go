@@ -0,0 +1,92 @@
+package comp_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+type (
+	circle struct{ R float32 }
+	rect   struct{ W, H float32 }
+)
+
+func TestGroups_Match(t *testing.T) {
+	var mi comp.DefIndex
+	mi.Init()
+	id := func(t reflect.Type) comp.ID { return mi.Intern(t).ID }
+	posID, velID := id(reflect.TypeFor[position]()), id(reflect.TypeFor[velocity]())
+	circleID, rectID := id(reflect.TypeFor[circle]()), id(reflect.TypeFor[rect]())
+	maskOf := func(ids ...comp.ID) comp.Mask {
+		var m comp.Mask
+		for _, id := range ids {
+			m = m.Set(id)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name string
+		opt  comp.AccessOpt
+		want map[string]bool
+	}{
+		{
+			name: "AnyOf",
+			opt:  comp.AnyOf(comp.Include[circle](), comp.Include[rect]()),
+			want: map[string]bool{"pos": false, "pos+circle": true, "pos+rect": true, "circle+rect": true},
+		},
+		{
+			name: "OneOf",
+			opt:  comp.OneOf(comp.Include[circle](), comp.Include[rect]()),
+			want: map[string]bool{"pos": false, "pos+circle": true, "pos+rect": true, "circle+rect": false},
+		},
+		{
+			name: "AllOf",
+			opt:  comp.AllOf(comp.Include[circle](), comp.Exclude[rect]()),
+			want: map[string]bool{"pos": false, "pos+circle": true, "pos+rect": false, "circle+rect": false},
+		},
+		{
+			name: "nested",
+			opt:  comp.AnyOf(comp.AllOf(comp.Include[position](), comp.Exclude[circle]()), comp.Include[rect]()),
+			want: map[string]bool{"pos": true, "pos+circle": false, "pos+rect": true, "circle+rect": true},
+		},
+	}
+	masks := map[string]comp.Mask{
+		"pos":         maskOf(posID, velID),
+		"pos+circle":  maskOf(posID, circleID),
+		"pos+rect":    maskOf(posID, rectID),
+		"circle+rect": maskOf(circleID, rectID),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s comp.AccessSpec
+			s.Init(&mi, tt.opt)
+			if len(s.Groups) != 1 || len(s.CompInfos)+len(s.TagIDs)+len(s.ExCompIDs) != 0 {
+				t.Fatalf("expected a single group and nothing else, got %+v", s)
+			}
+			for name, want := range tt.want {
+				if got := masks[name].Satisfies(s.Groups); got != want {
+					t.Errorf("%s: Satisfies = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGroups_RejectBadOpts(t *testing.T) {
+	var mi comp.DefIndex
+	mi.Init()
+	for name, opt := range map[string]comp.AccessOpt{
+		"empty":      comp.AnyOf(),
+		"track":      comp.OneOf(comp.Track(new(iter.ArrayRef[position])), comp.Include[rect]()),
+		"optional":   comp.AnyOf(comp.Optional(new(iter.ArrayRef[position]))),
+		"nested bad": comp.AllOf(comp.AnyOf()),
+	} {
+		var s comp.AccessSpec
+		if err := opt(&s, &mi); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
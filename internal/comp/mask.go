@@ -3,20 +3,31 @@ package comp
 import (
 	"iter"
 	"math/bits"
+	"slices"
 )
 
 // Mask encodes a set of component IDs as an array of MaskSize uint64 words.
 // Supports up to MaxComponents component types.
 type Mask [MaskSize]uint64
 
+// NewMask returns the components s requires: its tracked columns, except
+// optional ones, its tags, and its tracked shared and per-chunk components.
 func NewMask(s *AccessSpec) Mask {
 	var mask Mask
 	for _, info := range s.CompInfos {
-		mask = mask.Set(info.ID)
+		if !slices.Contains(s.OptIDs, info.ID) {
+			mask = mask.Set(info.ID)
+		}
 	}
 	for _, id := range s.TagIDs {
 		mask = mask.Set(id)
 	}
+	for _, id := range s.SharedIDs {
+		mask = mask.Set(id)
+	}
+	for _, def := range s.ChunkDefs {
+		mask = mask.Set(def.ID)
+	}
 	return mask
 }
 
@@ -82,6 +93,16 @@ func (s Mask) Count() int {
 		bits.OnesCount64(s[1])
 }
 
+// Satisfies reports whether s satisfies every group.
+func (s Mask) Satisfies(groups []Group) bool {
+	for i := range groups {
+		if !groups[i].Matches(s) {
+			return false
+		}
+	}
+	return true
+}
+
 // Matches returns true if the mask contains all bits from include AND none from exclude.
 func (s Mask) Matches(include, exclude Mask) bool {
 	if (s[0]&include[0]) != include[0] ||
@@ -0,0 +1,168 @@
+package comp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseFilter parses a query expression such as "Pos, Vel, !Frozen, Team|Npc"
+// into access opts. The grammar is
+//
+//	list := item { "," item }
+//	item := alt { "|" alt }          — two or more alts form an AnyOf
+//	alt  := name | "!" name | "?" name | "(" list ")"
+//
+// At the top level a bare name tracks a data column (or includes a tag),
+// "?name" tracks an optional column, and "!name" excludes. Inside "|" and
+// parentheses — an AllOf — names are filters only, as with [AnyOf]. Names
+// are resolved when the opts are applied, through [DefIndex.Lookup].
+func ParseFilter(src string) ([]AccessOpt, error) {
+	p := parser{src: src}
+	opts, err := p.list(true)
+	if err == nil && p.peek() != 0 {
+		err = p.errorf("unexpected %q", p.peek())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("query %q, at %d: %s", p.src, p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek skips whitespace and returns the next byte, or 0 at the end.
+func (p *parser) peek() byte {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	if p.pos == len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) list(top bool) ([]AccessOpt, error) {
+	var opts []AccessOpt
+	for {
+		opt, err := p.item(top)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+		if p.peek() != ',' {
+			return opts, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) item(top bool) (AccessOpt, error) {
+	first, err := p.alt()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != '|' {
+		return first.opt(p, top)
+	}
+	alts := []alt{first}
+	for p.peek() == '|' {
+		p.pos++
+		a, err := p.alt()
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, a)
+	}
+	opts := make([]AccessOpt, len(alts))
+	for i, a := range alts {
+		if opts[i], err = a.opt(p, false); err != nil {
+			return nil, err
+		}
+	}
+	return AnyOf(opts...), nil
+}
+
+// alt is one parsed alternative: a prefixed name, or a parenthesized group.
+type alt struct {
+	prefix byte // 0, '!' or '?'
+	name   string
+	group  AccessOpt
+}
+
+func (p *parser) alt() (alt, error) {
+	switch c := p.peek(); c {
+	case '(':
+		p.pos++
+		opts, err := p.list(false)
+		if err != nil {
+			return alt{}, err
+		}
+		if p.peek() != ')' {
+			return alt{}, p.errorf("expected ')'")
+		}
+		p.pos++
+		return alt{group: AllOf(opts...)}, nil
+	case '!', '?':
+		p.pos++
+		name, err := p.name()
+		return alt{prefix: c, name: name}, err
+	}
+	name, err := p.name()
+	return alt{name: name}, err
+}
+
+// opt turns a into an AccessOpt, as a data column if top and as a filter
+// otherwise.
+func (a alt) opt(p *parser, top bool) (AccessOpt, error) {
+	switch {
+	case a.group != nil:
+		return a.group, nil
+	case a.prefix == '!':
+		return named(a.name, (*AccessSpec).Forbid), nil
+	case a.prefix == '?' && !top:
+		return nil, p.errorf("optional %q is only allowed at the top level", a.name)
+	case a.prefix == '?':
+		return named(a.name, (*AccessSpec).Optional), nil
+	case !top:
+		return named(a.name, (*AccessSpec).Require), nil
+	}
+	return named(a.name, func(s *AccessSpec, d Def) error {
+		if d.Size == 0 || d.Sparse || d.Shared || d.PerChunk {
+			return s.Require(d)
+		}
+		return s.Comp(d)
+	}), nil
+}
+
+func (p *parser) name() (string, error) {
+	p.peek()
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(",|!?() \t", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		if p.pos == len(p.src) {
+			return "", p.errorf("expected a component name, got end of query")
+		}
+		return "", p.errorf("expected a component name, got %q", p.src[p.pos])
+	}
+	return p.src[start:p.pos], nil
+}
+
+// named resolves name through the DefIndex and applies add with its Def.
+func named(name string, add func(*AccessSpec, Def) error) AccessOpt {
+	return func(s *AccessSpec, mi *DefIndex) error {
+		def, err := mi.Lookup(name)
+		if err != nil {
+			return err
+		}
+		return add(s, def)
+	}
+}
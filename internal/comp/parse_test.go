@@ -0,0 +1,101 @@
+package comp_test
+
+import (
+	"go/constant"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+type frozen struct{}
+
+func TestParseFilter(t *testing.T) {
+	mi := newDefIndex()
+	pos := mi.Intern(reflect.TypeFor[position]())
+	vel := mi.InternWith(reflect.TypeFor[velocity](), comp.RegSpec{Name: "game.Vel"})
+	frz := mi.Intern(reflect.TypeFor[frozen]())
+	circ := mi.Intern(reflect.TypeFor[circle]())
+	rct := mi.Intern(reflect.TypeFor[rect]())
+
+	opts, err := comp.ParseFilter(" position, ?game.Vel , !frozen, circle|(rect, !frozen)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s comp.AccessSpec
+	s.Init(&mi, opts...)
+
+	if len(s.CompInfos) != 2 || s.CompInfos[0].ID != pos.ID || s.CompInfos[1].ID != vel.ID {
+		t.Errorf("expected tracked [position game.Vel], got %+v", s.CompInfos)
+	}
+	if len(s.OptIDs) != 1 || s.OptIDs[0] != vel.ID {
+		t.Errorf("expected game.Vel optional, got %v", s.OptIDs)
+	}
+	if len(s.ExCompIDs) != 1 || s.ExCompIDs[0] != frz.ID {
+		t.Errorf("expected frozen excluded, got %v", s.ExCompIDs)
+	}
+	if len(s.Groups) != 1 || s.Groups[0].Kind != comp.AnyOfGroup || len(s.Groups[0].Terms) != 2 {
+		t.Fatalf("expected one AnyOf of two terms, got %+v", s.Groups)
+	}
+	var withCircle, withRect comp.Mask
+	withCircle = withCircle.Set(pos.ID).Set(circ.ID)
+	withRect = withRect.Set(pos.ID).Set(rct.ID)
+	if !withCircle.Satisfies(s.Groups) || !withRect.Satisfies(s.Groups) || withRect.Set(frz.ID).Satisfies(s.Groups) {
+		t.Error("AnyOf group evaluates wrongly")
+	}
+
+	// A bare tag at the top level is required, not tracked.
+	opts, _ = comp.ParseFilter("frozen")
+	var tag comp.AccessSpec
+	tag.Init(&mi, opts...)
+	if len(tag.CompInfos) != 0 || len(tag.TagIDs) != 1 {
+		t.Errorf("expected frozen as a tag, got %+v", tag)
+	}
+}
+
+func TestParseFilter_Errors(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{"", "expected a component name, got end of query"},
+		{"Pos,", "expected a component name, got end of query"},
+		{"Pos Vel", `unexpected 'V'`},
+		{"(Pos", "expected ')'"},
+		{"Pos|?Vel", `optional "Vel" is only allowed at the top level`},
+		{"!|Pos", `expected a component name, got '|'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := comp.ParseFilter(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseFilter(%q) error = %v, want %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefIndex_LookupByName(t *testing.T) {
+	mi := newDefIndex()
+	mi.InternWith(reflect.TypeFor[velocity](), comp.RegSpec{Name: "game.Vel", Aliases: []string{"old.Vel"}})
+	mi.Intern(reflect.TypeFor[circle]())
+	mi.Intern(reflect.TypeFor[rect]())
+	mi.Intern(reflect.TypeFor[reflect.Kind]())
+	outer := mi.Intern(reflect.TypeFor[constant.Kind]()) // same Name, another package
+
+	for _, name := range []string{"game.Vel", "old.Vel", "circle", "comp_test.circle"} {
+		if _, err := mi.Lookup(name); err != nil {
+			t.Errorf("Lookup(%q): %v", name, err)
+		}
+	}
+	if _, err := mi.Lookup("velocity"); err != nil {
+		t.Errorf("expected the type name to resolve alongside a registered name: %v", err)
+	}
+	if _, err := mi.Lookup("Kind"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected an ambiguity error, got %v", err)
+	}
+	if def, err := mi.Lookup(outer.Key); err != nil || def.ID != outer.ID {
+		t.Errorf("expected the full key to disambiguate, got %v, %v", def, err)
+	}
+	if _, err := mi.Lookup("nope"); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("expected an unknown-component error, got %v", err)
+	}
+}
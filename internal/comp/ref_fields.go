@@ -0,0 +1,57 @@
+package comp
+
+import "reflect"
+
+// RefTag is the struct tag marking a component field as a reference to
+// another entity — `goke:"ref"`. Entity IDs are rewritten in such fields
+// when a snapshot is merged into a world under fresh IDs.
+const (
+	RefTagKey   = "goke"
+	RefTagValue = "ref"
+)
+
+// RefField is one entity-reference slot of a component: a field tagged
+// [RefTagKey]:[RefTagValue], or one element of such a field of array type.
+type RefField struct {
+	Path   string       // field path within the component, as in OffChunkFields
+	Offset uintptr      // from the start of the component value
+	Type   reflect.Type // the slot's own type (the element type, for an array)
+}
+
+// RefFields walks t recursively, through structs and fixed-size arrays,
+// and returns every reference slot in it, in memory order. A type resolved
+// via encoding.BinaryMarshaler is opaque — tags inside it are not seen.
+func RefFields(t reflect.Type) []RefField {
+	var out []RefField
+	collectRefFields(t, "", 0, false, &out)
+	return out
+}
+
+func collectRefFields(t reflect.Type, fieldPath string, offset uintptr, tagged bool, out *[]RefField) {
+	if implementsBinaryCodec(t) {
+		if tagged {
+			*out = append(*out, RefField{Path: displayPath(fieldPath), Offset: offset, Type: t})
+		}
+		return
+	}
+	switch t.Kind() {
+	case reflect.Array:
+		elem := t.Elem()
+		for i := range t.Len() {
+			collectRefFields(elem, fieldPath+"[]", offset+uintptr(i)*elem.Size(), tagged, out)
+		}
+	case reflect.Struct:
+		if tagged {
+			*out = append(*out, RefField{Path: displayPath(fieldPath), Offset: offset, Type: t})
+			return
+		}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			collectRefFields(f.Type, subPath(fieldPath, f.Name), offset+f.Offset, f.Tag.Get(RefTagKey) == RefTagValue, out)
+		}
+	default:
+		if tagged {
+			*out = append(*out, RefField{Path: displayPath(fieldPath), Offset: offset, Type: t})
+		}
+	}
+}
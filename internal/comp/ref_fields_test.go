@@ -0,0 +1,49 @@
+package comp_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+type link struct {
+	Weight float32
+	Target uint64 `goke:"ref"`
+}
+
+type linkSet struct {
+	Owner uint64 `goke:"ref"`
+	Links [2]link
+	Slots [2]uint64 `goke:"ref"`
+	Other uint64
+	Tag   marshaledType `goke:"ref"`
+}
+
+func TestRefFields(t *testing.T) {
+	typ := reflect.TypeFor[linkSet]()
+	u64 := reflect.TypeFor[uint64]()
+	links, _ := typ.FieldByName("Links")
+	slots, _ := typ.FieldByName("Slots")
+	tag, _ := typ.FieldByName("Tag")
+	target, _ := reflect.TypeFor[link]().FieldByName("Target")
+	linkSize := reflect.TypeFor[link]().Size()
+
+	want := []comp.RefField{
+		{Path: "Owner", Offset: 0, Type: u64},
+		{Path: "Links[].Target", Offset: links.Offset + target.Offset, Type: u64},
+		{Path: "Links[].Target", Offset: links.Offset + linkSize + target.Offset, Type: u64},
+		{Path: "Slots[]", Offset: slots.Offset, Type: u64},
+		{Path: "Slots[]", Offset: slots.Offset + 8, Type: u64},
+		{Path: "Tag", Offset: tag.Offset, Type: reflect.TypeFor[marshaledType]()},
+	}
+	if got := comp.RefFields(typ); !reflect.DeepEqual(got, want) {
+		t.Errorf("RefFields:\n got  %+v\n want %+v", got, want)
+	}
+}
+
+func TestRefFields_NoneTagged(t *testing.T) {
+	if got := comp.RefFields(reflect.TypeFor[nestedPod]()); len(got) != 0 {
+		t.Errorf("expected no reference fields, got %+v", got)
+	}
+}
@@ -0,0 +1,73 @@
+package comp
+
+// RegSpec collects the options a component type is registered with — see
+// [DefIndex.InternWith]. The zero value registers a type under its
+// [TypeKey].
+type RegSpec struct {
+	// Name is the stable key the type is recorded under outside the
+	// process — e.g. in a save file — instead of its TypeKey, so renaming
+	// or moving the Go type does not orphan data recorded under it.
+	Name string
+
+	// Aliases are former keys still accepted for the type when matching
+	// recorded data, e.g. its key before a rename.
+	Aliases []string
+
+	// Sparse keeps the type in a sparse set keyed by entity instead of in
+	// archetype chunks, so adding or removing it never moves an entity
+	// between archetypes.
+	Sparse bool
+
+	// Shared stores one value of the type per archetype instead of one per
+	// entity: entities with different values live in different archetypes,
+	// so setting the value moves the entity. The type must be comparable
+	// bytewise — no strings, pointers or BinaryMarshaler fields.
+	Shared bool
+
+	// PerChunk stores one value of the type per chunk instead of one per
+	// entity — chunk metadata such as a bounding box. Entities still add
+	// and remove it, which moves them between archetypes; its value
+	// belongs to the chunk they land in, and is zeroed whenever that chunk
+	// empties. Like Shared, no strings, pointers or BinaryMarshaler fields.
+	PerChunk bool
+}
+
+// RegOpt configures a RegSpec.
+type RegOpt func(*RegSpec)
+
+// WithName records the component under name instead of its TypeKey.
+func WithName(name string) RegOpt {
+	return func(s *RegSpec) { s.Name = name }
+}
+
+// WithAliases adds former keys still accepted for the component.
+func WithAliases(aliases ...string) RegOpt {
+	return func(s *RegSpec) { s.Aliases = append(s.Aliases, aliases...) }
+}
+
+// WithSparse stores the component in a sparse set instead of archetype
+// chunks.
+func WithSparse() RegOpt {
+	return func(s *RegSpec) { s.Sparse = true }
+}
+
+// WithShared stores one value of the component per archetype instead of
+// one per entity.
+func WithShared() RegOpt {
+	return func(s *RegSpec) { s.Shared = true }
+}
+
+// WithPerChunk stores one value of the component per chunk instead of one
+// per entity.
+func WithPerChunk() RegOpt {
+	return func(s *RegSpec) { s.PerChunk = true }
+}
+
+// NewRegSpec applies opts to a zero RegSpec.
+func NewRegSpec(opts ...RegOpt) RegSpec {
+	var s RegSpec
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
@@ -0,0 +1,15 @@
+package comp
+
+import "reflect"
+
+// TypeKey returns the identity a component type is recorded under outside
+// the process — e.g. in a save file's component directory: the full import
+// path plus type name for a named type ("github.com/acme/physics.Pos"), so
+// two packages that share a package name and type name never collide, or
+// reflect.Type.String() for an unnamed one, which has no import path.
+func TypeKey(t reflect.Type) string {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
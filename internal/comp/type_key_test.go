@@ -0,0 +1,29 @@
+package comp_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+func TestTypeKey_NamedTypeIsPackageQualified(t *testing.T) {
+	got := comp.TypeKey(reflect.TypeFor[position]())
+	want := "github.com/kjkrol/goke/v3/internal/comp_test.position"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTypeKey_UnnamedAndPredeclaredTypes(t *testing.T) {
+	cases := map[reflect.Type]string{
+		reflect.TypeFor[[4]float32]():        "[4]float32",
+		reflect.TypeFor[struct{ X int32 }](): "struct { X int32 }",
+		reflect.TypeFor[uint16]():            "uint16",
+	}
+	for rt, want := range cases {
+		if got := comp.TypeKey(rt); got != want {
+			t.Errorf("TypeKey(%v): expected %q, got %q", rt, want, got)
+		}
+	}
+}
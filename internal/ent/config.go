@@ -1,8 +1,13 @@
 package ent
 
+import "github.com/kjkrol/goke/v3/internal/colstore"
+
 type Config struct {
 	Cap     int
 	FreeCap int
+	// Chunk is the cache geometry archetype chunks are sized for; zero
+	// fields take the platform defaults.
+	Chunk colstore.Geometry
 }
 
 func DefaultConfig() Config {
@@ -16,8 +16,10 @@
 // Adding or removing a component moves the entity to a different archetype.
 //
 // [Manager] delegates storage to [arch.Catalog] and identity management
-// to [addr.Book], exposing a unified API: Remove, UpsertComp, RemoveComp,
-// CreateFactory.
+// to [addr.Book], exposing a unified API: Remove, UpsertComp, SetShared,
+// RemoveComp, CreateFactory. SetShared moves the entity to the archetype
+// holding a shared component's new value. UpsertComp of a per-chunk
+// component returns its value in the entity's new chunk.
 //
 // [Factory] handles bulk entity creation using a chunk-based iterator.
 //
@@ -88,7 +88,7 @@ func (m *Editor) applyGroup(srcArchID arch.ID, ids []uid.UID64, slotRefs []colst
 		dstArchID = m.resolve(srcArchID)
 	}
 	if dstArchID == arch.NullID {
-		removeBatch(m.addrBook, &m.defrag, srcArchID, srcTable, ids, slotRefs)
+		removeBatch(m.addrBook, m.archCatalog, &m.defrag, srcArchID, srcTable, ids, slotRefs)
 		return
 	}
 	if dstArchID == srcArchID {
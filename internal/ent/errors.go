@@ -2,4 +2,7 @@ package ent
 
 import "errors"
 
-var errInvalidEntity = errors.New("invalid entity")
+var (
+	errInvalidEntity = errors.New("invalid entity")
+	errSharedValue   = errors.New("shared component needs a value — use SetShared")
+)
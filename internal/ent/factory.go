@@ -1,6 +1,8 @@
 package ent
 
 import (
+	goiter "iter"
+
 	"github.com/kjkrol/uid"
 
 	"github.com/kjkrol/goke/v3/internal/arch"
@@ -23,13 +25,20 @@ type Factory struct {
 	available int
 }
 
-// Init resolves or creates the archetype from accessSpec and prepares
-// the Factory for repeated Create/Next cycles.
-func (f *Factory) Init(em *Manager, accessSpec comp.AccessSpec) {
-	archID := em.ArchCatalog.Upsert(accessSpec.Compose())
+// Init resolves or creates the archetype from accessSpec, with the given
+// shared values, and prepares the Factory for repeated Create/Next cycles.
+func (f *Factory) Init(em *Manager, accessSpec comp.AccessSpec, shared ...comp.SharedInit) {
+	set := accessSpec.Compose()
+	for _, v := range shared {
+		set = set.WithShared(v.Def.ID, em.ArchCatalog.InternShared(v.Def, v.Value))
+	}
+	archID := em.ArchCatalog.Upsert(set)
 	f.arch = &em.ArchCatalog.Archetypes[archID]
 	f.colBakes = f.arch.Table.BakeColumns(accessSpec.CompInfos)
-	f.Cursor = iter.Cursor{Offsets: make([]uintptr, len(accessSpec.CompInfos))}
+	f.Cursor = iter.Cursor{
+		Offsets:      make([]uintptr, len(accessSpec.CompInfos)),
+		ChunkOffsets: f.arch.Table.BakeChunkOffsets(accessSpec.ChunkIDs()),
+	}
 }
 
 // Create pre-allocates chunks for count entities and resets the iterator.
@@ -60,6 +69,27 @@ func (f *Factory) Next() bool {
 	return true
 }
 
+// Batches creates count entities, yielding the Cursor once per batch with
+// its IDs set — the range-over-func form of Create/Next:
+//
+//	for cur := range f.Batches(n) {
+//		positions := pos.Slice(cur)
+//		for i := range cur.IDs { ... }
+//	}
+//
+// Breaking out early creates only the batches yielded so far.
+func (f *Factory) Batches(count int) goiter.Seq[*iter.Cursor] {
+	return func(yield func(*iter.Cursor) bool) {
+		for f.Create(count); f.Next(); {
+			if !yield(&f.Cursor) {
+				f.remaining = 0
+				f.Next()
+				return
+			}
+		}
+	}
+}
+
 // SpawnAll creates count entities in one call, driving Create/Next
 // internally, and returns every id created — for deferred callers (like
 // CmdBuf.Spawn) that don't need per-chunk Cursor access to write values
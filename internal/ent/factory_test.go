@@ -6,7 +6,9 @@ import (
 
 	"github.com/kjkrol/uid"
 
+	"github.com/kjkrol/goke/v3/internal/arch"
 	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/ent"
 	"github.com/kjkrol/goke/v3/iter"
 )
 
@@ -191,3 +193,48 @@ func TestFactory_TaggedOnlyArchetype(t *testing.T) {
 		t.Errorf("expected 3 entities, got %d", total)
 	}
 }
+
+func TestFactory_Batches(t *testing.T) {
+	m := newManager()
+	var mi comp.DefIndex
+	mi.Init()
+	var posCol iter.ArrayRef[Position]
+	var spec comp.AccessSpec
+	spec.Init(&mi, comp.Track(&posCol))
+	factory := m.CreateFactory(spec)
+
+	const count = 5000
+	total, batches := 0, 0
+	for cur := range factory.Batches(count) {
+		positions := posCol.Slice(cur)
+		for i := range cur.IDs {
+			positions[i] = Position{X: float64(total + i)}
+		}
+		total += len(cur.IDs)
+		batches++
+	}
+	if total != count || batches < 2 {
+		t.Fatalf("expected %d entities over several batches, got %d in %d", count, total, batches)
+	}
+
+	// Breaking out after the first batch creates that batch only.
+	var first int
+	for cur := range factory.Batches(count) {
+		first = len(cur.IDs)
+		break
+	}
+	if got := storedEntities(m); got != count+first {
+		t.Errorf("expected %d entities after an early break, got %d", count+first, got)
+	}
+	if ids := factory.SpawnAll(3); len(ids) != 3 {
+		t.Errorf("expected the factory reusable after an early break, got %d ids", len(ids))
+	}
+}
+
+func storedEntities(m *ent.Manager) int {
+	n := 0
+	for id := arch.RootID; id < m.ArchCatalog.Len(); id++ {
+		n += int(m.ArchCatalog.Archetypes[id].Table.Len())
+	}
+	return n
+}
@@ -21,6 +21,7 @@ type Manager struct {
 
 func (m *Manager) Init(cfg Config, onArchetypeCreated func(*arch.Archetype)) {
 	m.AddressBook.Init(cfg.Cap, cfg.FreeCap)
+	m.ArchCatalog.Geometry = cfg.Chunk
 	m.ArchCatalog.Init(func(a *arch.Archetype) {
 		archID := a.Id
 		a.Table.SetIDSeeder(func(dst []uid.UID64, ptr unsafe.Pointer, slot colstore.Slot) {
@@ -43,23 +44,40 @@ func (m *Manager) Remove(id uid.UID64) bool {
 	return true
 }
 
-// CreateFactory resolves or creates the archetype from accessSpec and returns
-// a reusable Factory ready for repeated Create/Next cycles.
-func (m *Manager) CreateFactory(accessSpec comp.AccessSpec) *Factory {
+// CreateFactory resolves or creates the archetype from accessSpec, with the
+// given shared values, and returns a reusable Factory ready for repeated
+// Create/Next cycles.
+func (m *Manager) CreateFactory(accessSpec comp.AccessSpec, shared ...comp.SharedInit) *Factory {
 	var f Factory
-	f.Init(m, accessSpec)
+	f.Init(m, accessSpec, shared...)
 	return &f
 }
 
 // UpsertComp ensures the entity has the given component, migrating to a new
 // archetype if necessary, and returns a pointer to the component's storage slot.
-// If the component is a zero-size tag, returns (nil, nil).
+// A sparse component goes to its sparse set instead, without a migration.
+// If the component is a zero-size tag, returns (nil, nil). A shared
+// component has no per-entity slot — set it with SetShared. For a per-chunk
+// component the pointer is to the value of the chunk the entity lands in,
+// shared with every other entity there.
 func (m *Manager) UpsertComp(entityID uid.UID64, compDef comp.Def) (unsafe.Pointer, error) {
 	entry, ok := m.AddressBook.Get(entityID)
 	if !ok {
 		return nil, errInvalidEntity
 	}
 
+	if compDef.Shared {
+		return nil, errSharedValue
+	}
+
+	if compDef.Sparse {
+		ptr := m.ArchCatalog.SparseSet(compDef).Upsert(entityID)
+		if compDef.Size == 0 {
+			return nil, nil
+		}
+		return ptr, nil
+	}
+
 	targetArchID := entry.ArchID
 	targetPtr := entry.ChunkPtr
 	targetSlot := entry.Slot
@@ -73,18 +91,45 @@ func (m *Manager) UpsertComp(entityID uid.UID64, compDef comp.Def) (unsafe.Point
 		return nil, nil
 	}
 
-	return m.ArchCatalog.Archetypes[targetArchID].Table.ComponentAt(targetPtr, targetSlot, compDef.ID), nil
+	table := &m.ArchCatalog.Archetypes[targetArchID].Table
+	if compDef.PerChunk {
+		return table.ChunkValueAt(targetPtr, compDef.ID), nil
+	}
+	table.MarkWritten(compDef.ID)
+	return table.ComponentAt(targetPtr, targetSlot, compDef.ID), nil
+}
+
+// SetShared sets the entity's shared component compDef to the value at
+// value, migrating it to the archetype holding that value. A no-op if the
+// entity already has it.
+func (m *Manager) SetShared(entityID uid.UID64, compDef comp.Def, value unsafe.Pointer) error {
+	entry, ok := m.AddressBook.Get(entityID)
+	if !ok {
+		return errInvalidEntity
+	}
+	set := m.ArchCatalog.Archetypes[entry.ArchID].Composition()
+	targetArchID := m.ArchCatalog.Upsert(set.WithShared(compDef.ID, m.ArchCatalog.InternShared(compDef, value)))
+	if targetArchID != entry.ArchID {
+		m.migrateEntity(entityID, entry.ArchID, entry.ChunkPtr, entry.Slot, targetArchID)
+	}
+	return nil
 }
 
 // RemoveComp removes the given component from the entity, migrating it to the
 // appropriate archetype. If the entity would have no components remaining,
-// it is unlinked from archetype storage entirely.
+// it is unlinked from archetype storage entirely. A sparse component is
+// dropped from its sparse set, leaving the archetype as it is.
 func (m *Manager) RemoveComp(entityID uid.UID64, compDef comp.Def) error {
 	entry, ok := m.AddressBook.Get(entityID)
 	if !ok {
 		return errInvalidEntity
 	}
 
+	if compDef.Sparse {
+		m.ArchCatalog.SparseSet(compDef).Remove(entityID)
+		return nil
+	}
+
 	if !m.ArchCatalog.Archetypes[entry.ArchID].Mask().IsSet(compDef.ID) {
 		return nil
 	}
@@ -111,6 +156,7 @@ func (m *Manager) removeFromArchetype(id uid.UID64, archID arch.ID, ptr unsafe.P
 		m.AddressBook.Move(swappedEntity, archID, ptr, slot)
 	}
 	m.AddressBook.Delete(id)
+	m.ArchCatalog.RemoveSparse(id)
 }
 
 func (m *Manager) migrateEntity(id uid.UID64, srcArchID arch.ID, srcPtr unsafe.Pointer, srcSlot colstore.Slot, dstArchID arch.ID) (unsafe.Pointer, colstore.Slot) {
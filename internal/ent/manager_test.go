@@ -3,12 +3,14 @@ package ent_test
 import (
 	"reflect"
 	"testing"
+	"unsafe"
 
 	"github.com/kjkrol/uid"
 
 	"github.com/kjkrol/goke/v3/internal/arch"
 	"github.com/kjkrol/goke/v3/internal/comp"
 	"github.com/kjkrol/goke/v3/internal/ent"
+	"github.com/kjkrol/goke/v3/iter"
 )
 
 // Init must invoke the caller's onArchetypeCreated callback for every new
@@ -353,3 +355,152 @@ func TestManager_Reset(t *testing.T) {
 		t.Error("expected Manager to be usable again after Reset")
 	}
 }
+
+// A sparse component goes to its sparse set: the entity keeps its archetype
+// and storage slot, and removing the entity drops its value.
+func TestManager_UpsertComp_SparseNeverMigrates(t *testing.T) {
+	m := newManager()
+	var mi comp.DefIndex
+	mi.Init()
+	tagDef := mi.Intern(reflect.TypeFor[Tag]())
+	velDef := mi.InternWith(reflect.TypeFor[Velocity](), comp.RegSpec{Sparse: true})
+
+	factory := m.CreateFactory(tagAccessSpec(tagDef.ID))
+	factory.Create(2)
+	factory.Next()
+	id, other := factory.IDs[0], factory.IDs[1]
+	before, _ := m.AddressBook.Get(id)
+	archLen := m.ArchCatalog.Len()
+
+	ptr, err := m.UpsertComp(id, velDef)
+	if err != nil || ptr == nil {
+		t.Fatalf("expected a value pointer, got %v, %v", ptr, err)
+	}
+	*(*Velocity)(ptr) = Velocity{VX: 3}
+
+	after, _ := m.AddressBook.Get(id)
+	if after != before || m.ArchCatalog.Len() != archLen {
+		t.Error("expected no migration and no new archetype")
+	}
+	set := m.ArchCatalog.SparseSet(velDef)
+	if v := (*Velocity)(set.Get(id)); v == nil || v.VX != 3 {
+		t.Errorf("expected the stored value, got %v", v)
+	}
+
+	if err := m.RemoveComp(id, velDef); err != nil || set.Has(id) {
+		t.Errorf("expected RemoveComp to drop the value, err=%v", err)
+	}
+	if after, _ := m.AddressBook.Get(id); after != before {
+		t.Error("expected RemoveComp not to migrate")
+	}
+
+	if _, err := m.UpsertComp(other, velDef); err != nil {
+		t.Fatal(err)
+	}
+	m.Remove(other)
+	if set.Len() != 0 {
+		t.Errorf("expected Remove to drop the entity's sparse values, %d left", set.Len())
+	}
+}
+
+func TestManager_SetShared_MovesToValueGroup(t *testing.T) {
+	m := newManager()
+	var mi comp.DefIndex
+	mi.Init()
+	posDef := mi.Intern(reflect.TypeFor[Position]())
+	velDef := mi.InternWith(reflect.TypeFor[Velocity](), comp.RegSpec{Shared: true})
+
+	var spec comp.AccessSpec
+	if err := spec.Comp(posDef); err != nil {
+		t.Fatal(err)
+	}
+	slow := Velocity{VX: 1}
+	factory := m.CreateFactory(spec, comp.SharedInit{Def: velDef, Value: unsafe.Pointer(&slow)})
+	factory.Create(2)
+	factory.Next()
+	a, b := factory.IDs[0], factory.IDs[1]
+	(&iter.ArrayRef[Position]{}).Slice(&factory.Cursor)[0] = Position{X: 7}
+	archOf := func(id uid.UID64) arch.ID {
+		entry, _ := m.AddressBook.Get(id)
+		return entry.ArchID
+	}
+	slowArch := archOf(a)
+
+	if _, err := m.UpsertComp(a, velDef); err == nil {
+		t.Error("expected UpsertComp to refuse a shared component")
+	}
+
+	same := slow
+	if err := m.SetShared(a, velDef, unsafe.Pointer(&same)); err != nil {
+		t.Fatal(err)
+	}
+	if got := archOf(a); got != slowArch {
+		t.Errorf("expected an equal value to keep the entity in place, got archetype %d", got)
+	}
+
+	fast := Velocity{VX: 9}
+	if err := m.SetShared(a, velDef, unsafe.Pointer(&fast)); err != nil {
+		t.Fatal(err)
+	}
+	entry, _ := m.AddressBook.Get(a)
+	if entry.ArchID == slowArch || archOf(b) != slowArch {
+		t.Fatal("expected only the updated entity to move to a new group")
+	}
+	fastArch := &m.ArchCatalog.Archetypes[entry.ArchID]
+	if v := (*Velocity)(fastArch.SharedValue(velDef.ID)); v == nil || v.VX != 9 {
+		t.Errorf("expected the group to hold the new value, got %v", v)
+	}
+	if p := (*Position)(fastArch.Table.ComponentAt(entry.ChunkPtr, entry.Slot, posDef.ID)); p.X != 7 {
+		t.Errorf("expected the migration to keep per-entity data, got %v", p)
+	}
+
+	if err := m.RemoveComp(a, velDef); err != nil {
+		t.Fatal(err)
+	}
+	if a := &m.ArchCatalog.Archetypes[archOf(a)]; a.Mask().IsSet(velDef.ID) || len(a.Composition().Shared) != 0 {
+		t.Error("expected RemoveComp to drop the shared value")
+	}
+	if err := m.SetShared(uid.UID64(999), velDef, unsafe.Pointer(&fast)); err == nil {
+		t.Error("expected an error for an unknown entity")
+	}
+}
+
+func TestManager_PerChunkComponent(t *testing.T) {
+	m := newManager()
+	var mi comp.DefIndex
+	mi.Init()
+	posDef := mi.Intern(reflect.TypeFor[Position]())
+	velDef := mi.InternWith(reflect.TypeFor[Velocity](), comp.RegSpec{PerChunk: true})
+
+	var spec comp.AccessSpec
+	if err := spec.Comp(posDef); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Chunk(velDef); err != nil {
+		t.Fatal(err)
+	}
+	factory := m.CreateFactory(spec)
+	factory.Create(2)
+	factory.Next()
+	a, b := factory.IDs[0], factory.IDs[1]
+	(&iter.ChunkRef[Velocity]{}).Get(&factory.Cursor).VX = 3
+
+	entry, _ := m.AddressBook.Get(b)
+	table := &m.ArchCatalog.Archetypes[entry.ArchID].Table
+	if v := (*Velocity)(table.ChunkValueAt(entry.ChunkPtr, velDef.ID)); v.VX != 3 {
+		t.Errorf("expected the factory to write the chunk's value, got %v", v)
+	}
+
+	for _, id := range []uid.UID64{a, b} {
+		if err := m.RemoveComp(id, velDef); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ptr, err := m.UpsertComp(a, velDef)
+	if err != nil || ptr == nil {
+		t.Fatalf("expected a pointer to the chunk's value, got %v, %v", ptr, err)
+	}
+	if v := (*Velocity)(ptr); v.VX != 0 {
+		t.Errorf("expected an emptied chunk to start from a zero value, got %v", v)
+	}
+}
@@ -41,5 +41,5 @@ func (r *Remover) Migrate(snap bulk.ChunkSnapshot, ids []uid.UID64) {
 	if len(validIDs) == 0 {
 		return
 	}
-	removeBatch(r.addrBook, &r.defrag, snap.ArchID, srcTable, validIDs, slotRefs)
+	removeBatch(r.addrBook, r.archCatalog, &r.defrag, snap.ArchID, srcTable, validIDs, slotRefs)
 }
@@ -78,8 +78,9 @@ func resolveSlotRefs(
 }
 
 // resolveDst computes the destination archetype for applying spec's adds
-// then dels to srcArchID's composition. Returns arch.NullID when nothing is
-// left (every component removed).
+// (columns and per-chunk components), then its shared values, then its dels
+// to srcArchID's composition. Returns arch.NullID when nothing is left
+// (every component removed).
 func resolveDst(archCatalog *arch.Catalog, spec comp.EditSpec, srcArchID arch.ID) arch.ID {
 	set := archCatalog.Archetypes[srcArchID].Composition()
 	for i := range spec.AddDefs {
@@ -88,6 +89,14 @@ func resolveDst(archCatalog *arch.Catalog, spec comp.EditSpec, srcArchID arch.ID
 			set = set.With(d)
 		}
 	}
+	for _, d := range spec.ChunkDefs {
+		if !set.Mask.IsSet(d.ID) {
+			set = set.With(d)
+		}
+	}
+	for _, v := range spec.Shared {
+		set = set.WithShared(v.Def.ID, archCatalog.InternShared(v.Def, v.Value))
+	}
 	for i := range spec.DelDefs {
 		set = set.Without(spec.DelDefs[i].ID)
 	}
@@ -97,11 +106,12 @@ func resolveDst(archCatalog *arch.Catalog, spec comp.EditSpec, srcArchID arch.ID
 	return archCatalog.Upsert(set)
 }
 
-// removeBatch removes ids outright from srcTable, compacting and updating
-// addrBook in one pass. Used by Remover.Migrate and by Editor.applyGroup
+// removeBatch removes ids outright from srcTable and archCatalog's sparse
+// sets, compacting and updating addrBook in one pass. Used by Remover.Migrate and by Editor.applyGroup
 // when a batch's destination composition resolves empty.
 func removeBatch(
 	addrBook *addr.Book,
+	archCatalog *arch.Catalog,
 	defrag *colstore.Defragmenter,
 	srcArchID arch.ID,
 	srcTable *colstore.Table,
@@ -111,6 +121,7 @@ func removeBatch(
 	moves := defrag.Compact(srcTable, slotRefs)
 	for _, id := range ids {
 		addrBook.Delete(id)
+		archCatalog.RemoveSparse(id)
 	}
 	for _, sm := range moves {
 		addrBook.MoveUnchecked(sm.ID, srcArchID, sm.NewPtr, sm.NewSlot)
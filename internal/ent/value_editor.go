@@ -99,7 +99,7 @@ func (vm *ValueEditor) applyGroup(srcArchID arch.ID, ids []uid.UID64, slotRefs [
 		dstArchID = vm.resolve(srcArchID)
 	}
 	if dstArchID == arch.NullID {
-		removeBatch(vm.addrBook, &vm.defrag, srcArchID, srcTable, ids, slotRefs)
+		removeBatch(vm.addrBook, vm.archCatalog, &vm.defrag, srcArchID, srcTable, ids, slotRefs)
 		return
 	}
 
@@ -114,6 +114,7 @@ func (vm *ValueEditor) applyGroup(srcArchID arch.ID, ids []uid.UID64, slotRefs [
 		if elemSize == 0 {
 			return
 		}
+		srcTable.MarkWritten(addDef.ID)
 		for i, ref := range slotRefs {
 			dst := srcTable.ComponentAt(ref.Ptr, ref.Slot, addDef.ID)
 			if dst == nil {
@@ -42,4 +42,6 @@
 //
 // [Mutator] is an interface defined in this package. Any external state that
 // implements it can be orchestrated — for example, an ECS component storage.
+// Its AfterSync hook runs at the end of every successful Sync, the one point
+// where the state is known consistent — e.g. to snapshot it.
 package orch
@@ -11,12 +11,18 @@ import (
 )
 
 type Mutator interface {
-	UpsertComp(uid.UID64, comp.ID) (unsafe.Pointer, error)
+	// UpsertComp ensures the entity has the component and returns its slot
+	// for the caller to copy value into — or nil if there is none to write,
+	// a tag's or a shared component's, which is set from value directly.
+	UpsertComp(id uid.UID64, compID comp.ID, value unsafe.Pointer) (unsafe.Pointer, error)
 	RemoveComp(uid.UID64, comp.ID) error
 	Remove(uid.UID64) bool
 	// Remover returns a shared bulk.Migrator that removes whole entities,
 	// for CmdBuf.Remove to queue against without the caller building one.
 	Remover() bulk.Migrator
+	// AfterSync is called at the end of every successful Scheduler.Sync,
+	// once all queued mutations have been applied.
+	AfterSync()
 }
 
 type Runnable interface {
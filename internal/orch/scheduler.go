@@ -90,6 +90,7 @@ func (s *Scheduler) Sync() error {
 			}
 		}
 	}
+	s.mutator.AfterSync()
 	return nil
 }
 
@@ -108,7 +109,7 @@ func (s *Scheduler) applyBufferCmds(cb *CmdBuf) error {
 
 		switch cmd.cType {
 		case cmdAssignComp:
-			ptr, err := s.mutator.UpsertComp(target, cmd.compID)
+			ptr, err := s.mutator.UpsertComp(target, cmd.compID, cmd.dataPtr)
 			if err != nil {
 				return fmt.Errorf("failed to allocate ID for target %d: %w", target, err)
 			}
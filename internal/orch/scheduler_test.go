@@ -27,10 +27,11 @@ type mockMutator struct {
 		called bool
 		id     uid.UID64
 	}
-	remover bulk.Migrator
+	remover    bulk.Migrator
+	afterSyncs int
 }
 
-func (m *mockMutator) UpsertComp(uid.UID64, comp.ID) (unsafe.Pointer, error) {
+func (m *mockMutator) UpsertComp(uid.UID64, comp.ID, unsafe.Pointer) (unsafe.Pointer, error) {
 	return nil, m.upsertErr
 }
 
@@ -54,6 +55,8 @@ func (m *mockMutator) Remover() bulk.Migrator {
 	return m.remover
 }
 
+func (m *mockMutator) AfterSync() { m.afterSyncs++ }
+
 // fnRunnable adapts a plain function to the Runnable interface.
 type fnRunnable struct {
 	fn func(cb *CmdBuf, d time.Duration)
@@ -161,6 +164,32 @@ func TestScheduler_Sync_PropagatesUpsertCompError(t *testing.T) {
 	}
 }
 
+func TestScheduler_Sync_CallsAfterSync(t *testing.T) {
+	wantErr := errors.New("boom")
+	mut := &mockMutator{}
+	sched := NewScheduler(mut)
+	r := &fnRunnable{fn: func(cb *CmdBuf, d time.Duration) {
+		AddOne(cb, uid.UID64(1), comp.ID(0), 42)
+	}}
+	sched.Register(r, NewCmdBuf())
+
+	if err := sched.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mut.afterSyncs != 1 {
+		t.Errorf("expected AfterSync once after an empty Sync, got %d", mut.afterSyncs)
+	}
+
+	mut.upsertErr = wantErr
+	sched.Run(r, 0)
+	if err := sched.Sync(); !errors.Is(err, wantErr) {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	if mut.afterSyncs != 1 {
+		t.Errorf("expected no AfterSync after a failed Sync, got %d calls", mut.afterSyncs)
+	}
+}
+
 func TestScheduler_Sync_DispatchesRemoveOne(t *testing.T) {
 	mut := &mockMutator{}
 	sched := NewScheduler(mut)
@@ -0,0 +1,128 @@
+package persist
+
+import (
+	"hash/maphash"
+	"math/rand/v2"
+	"unsafe"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/addr"
+	"github.com/kjkrol/goke/v3/internal/arch"
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// Baseline is what [SaveDelta] diffs against: the snapshot ID of the world
+// as last saved or loaded, its ID pool generations, and a content hash of
+// every non-empty chunk. The zero value holds no snapshot.
+//
+// A chunk's hash is its dirty marker. colstore.Table.Version alone cannot
+// serve — it only counts removals and compactions, while values written in
+// place through a Comp[T] slice change nothing but the chunk's bytes.
+type Baseline struct {
+	id          uint64
+	nextIndex   uint32
+	generations []uint32
+	free        []uint32
+	chunks      map[chunkKey]uint64
+	seed        maphash.Seed
+}
+
+// chunkKey identifies a chunk across saves: chunks never move between
+// archetypes, and a chunk's memory stays put for as long as it's in use.
+type chunkKey struct {
+	archID arch.ID
+	ptr    unsafe.Pointer
+}
+
+// ID returns the snapshot the baseline describes, or 0 if none.
+func (b *Baseline) ID() uint64 { return b.id }
+
+// Reset drops the snapshot — a following SaveDelta fails until the next
+// full Save or Load.
+func (b *Baseline) Reset() {
+	*b = Baseline{}
+}
+
+// capture records the world's current state as snapshot id, reusing
+// chunks if it was already computed by SaveDelta.
+func (b *Baseline) capture(id uint64, book *addr.Book, catalog *arch.Catalog, chunks map[chunkKey]uint64) {
+	if b.chunks == nil {
+		b.seed = maphash.MakeSeed()
+	}
+	if chunks == nil {
+		chunks = make(map[chunkKey]uint64)
+		for archID := arch.RootID; archID < catalog.Len(); archID++ {
+			a := &catalog.Archetypes[archID]
+			_ = walkTableChunks(&a.Table, func(_ int, cur *iter.Cursor) error {
+				chunks[chunkKey{archID, cur.Base}] = b.chunkHash(a, cur)
+				return nil
+			})
+		}
+	}
+	b.id = id
+	b.nextIndex, b.generations, b.free = book.PoolState()
+	b.chunks = chunks
+}
+
+// chunkHash hashes the live rows of cur's chunk of a: every entity ID, then
+// every data-bearing column, then the chunk's per-chunk values.
+func (b *Baseline) chunkHash(a *arch.Archetype, cur *iter.Cursor) uint64 {
+	var h maphash.Hash
+	h.SetSeed(b.seed)
+	n := len(cur.IDs)
+	h.Write(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(cur.IDs))), n*int(unsafe.Sizeof(uid.UID64(0)))))
+	for _, def := range a.Composition().Defs {
+		if def.Size == 0 {
+			continue
+		}
+		ptr := a.Table.ComponentAt(cur.Base, 0, def.ID)
+		h.Write(unsafe.Slice((*byte)(ptr), uintptr(n)*def.Size))
+	}
+	for _, def := range a.Composition().Chunk {
+		h.Write(unsafe.Slice((*byte)(a.Table.ChunkValueAt(cur.Base, def.ID)), def.Size))
+	}
+	return h.Sum64()
+}
+
+// removedSince returns the IDs alive at the baseline that book no longer
+// holds: every index allocated and not free at the baseline whose
+// generation has since moved on.
+func (b *Baseline) removedSince(book *addr.Book) []uid.UID64 {
+	free := make(map[uint32]struct{}, len(b.free))
+	for _, idx := range b.free {
+		free[idx] = struct{}{}
+	}
+	var removed []uid.UID64
+	for idx := range b.nextIndex {
+		if _, ok := free[idx]; ok {
+			continue
+		}
+		id := uid.UID64(uint64(b.generations[idx])<<uid.GenerationShift | uint64(idx))
+		if _, alive := book.Get(id); !alive {
+			removed = append(removed, id)
+		}
+	}
+	return removed
+}
+
+// alwaysDirty reports whether a's chunks must be written on every delta:
+// their content hash cannot see a change behind an opaque codec's pointer.
+func alwaysDirty(a *arch.Archetype) bool {
+	for _, def := range a.Composition().Defs {
+		if comp.HasOpaqueIndirection(def.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// newSnapshotID returns a random, nonzero snapshot ID.
+func newSnapshotID() uint64 {
+	for {
+		if id := rand.Uint64(); id != 0 {
+			return id
+		}
+	}
+}
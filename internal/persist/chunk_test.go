@@ -0,0 +1,322 @@
+package persist_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/colstore"
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/ent"
+	"github.com/kjkrol/goke/v3/internal/persist"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// smallChunks holds a few dozen Position rows per chunk, so a chunkWorld
+// spans several chunks.
+var smallChunks = colstore.Geometry{Bytes: 1024}
+
+// reqPerChunk is req registering T as a per-chunk component.
+func reqPerChunk[T any](di *comp.DefIndex) persist.CompRequest {
+	r := req[T](di)
+	register := r.Register
+	r.Register = func(wantSize *uint32) error {
+		di.InternWith(reflect.TypeFor[T](), comp.RegSpec{PerChunk: true})
+		return register(wantSize)
+	}
+	return r
+}
+
+func chunkRequests(di *comp.DefIndex) []persist.CompRequest {
+	return []persist.CompRequest{req[Position](di), reqPerChunk[Velocity](di)}
+}
+
+// chunkWorld holds positioned entities, X their index, in small chunks
+// that each keep a Velocity, X the chunk's number.
+type chunkWorld struct {
+	di  comp.DefIndex
+	m   *ent.Manager
+	ids []uid.UID64
+}
+
+func newChunkWorld(t *testing.T) *chunkWorld {
+	t.Helper()
+	w := &chunkWorld{m: newManagerWith(smallChunks)}
+	w.di.Init()
+	posDef := w.di.Intern(reflect.TypeFor[Position]())
+	velDef := w.di.InternWith(reflect.TypeFor[Velocity](), comp.RegSpec{PerChunk: true})
+
+	var spec comp.AccessSpec
+	if err := spec.Comp(posDef); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Chunk(velDef); err != nil {
+		t.Fatal(err)
+	}
+	factory := w.m.CreateFactory(spec)
+	factory.Create(150)
+	for chunkNo := 0; factory.Next(); chunkNo++ {
+		entry, _ := w.m.AddressBook.Get(factory.IDs[0])
+		table := &w.m.ArchCatalog.Archetypes[entry.ArchID].Table
+		*(*Velocity)(table.ChunkValueAt(entry.ChunkPtr, velDef.ID)) = Velocity{X: float32(chunkNo + 1)}
+		w.ids = append(w.ids, factory.IDs...)
+	}
+	if w.chunks(t) < 3 {
+		t.Fatalf("expected the entities to span several chunks, got %d", w.chunks(t))
+	}
+	for i, id := range w.ids {
+		ptr, err := w.m.UpsertComp(id, posDef)
+		if err != nil {
+			t.Fatal(err)
+		}
+		*(*Position)(ptr) = Position{X: float32(i)}
+	}
+	return w
+}
+
+// chunks counts the chunks w's entities fill.
+func (w *chunkWorld) chunks(t *testing.T) int {
+	t.Helper()
+	entry, _ := w.m.AddressBook.Get(w.ids[0])
+	var cur iter.Cursor
+	n := 0
+	for from := 0; ; n++ {
+		idx, ok := w.m.ArchCatalog.Archetypes[entry.ArchID].Table.FillCursorNext(&cur, from, nil)
+		if !ok {
+			return n
+		}
+		from = idx + 1
+	}
+}
+
+func newManagerWith(g colstore.Geometry) *ent.Manager {
+	m := newTestManager()
+	m.ArchCatalog.Geometry = g
+	return m
+}
+
+// chunkVelocity returns the Velocity of the chunk holding id in m.
+func chunkVelocity(t *testing.T, di *comp.DefIndex, m *ent.Manager, id uid.UID64) Velocity {
+	t.Helper()
+	velDef, ok := di.ByType(reflect.TypeFor[Velocity]())
+	if !ok || !velDef.PerChunk {
+		t.Fatal("Velocity is not registered per-chunk")
+	}
+	entry, ok := m.AddressBook.Get(id)
+	if !ok {
+		t.Fatalf("entity %v is missing", id)
+	}
+	return *(*Velocity)(m.ArchCatalog.Archetypes[entry.ArchID].Table.ChunkValueAt(entry.ChunkPtr, velDef.ID))
+}
+
+// Loaded with the chunk geometry it was saved with, a world gets its chunks
+// back as they were.
+func TestSaveLoad_RoundTrip_PerChunkValues(t *testing.T) {
+	w := newChunkWorld(t)
+	var saved, encoded, doc bytes.Buffer
+	if err := persist.Save(&saved, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	s, err := persist.Capture(&w.di, &w.m.AddressBook, &w.m.ArchCatalog)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if err := s.Encode(context.Background(), &encoded); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if err := persist.ExportJSON(&doc, &w.di, &w.m.AddressBook, &w.m.ArchCatalog); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+
+	loads := map[string]func(di *comp.DefIndex, m *ent.Manager) error{
+		"Save": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.Load(&saved, di, &m.AddressBook, &m.ArchCatalog, chunkRequests(di), nil)
+		},
+		"Capture": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.Load(&encoded, di, &m.AddressBook, &m.ArchCatalog, chunkRequests(di), nil)
+		},
+		"ExportJSON": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.ImportJSON(&doc, di, &m.AddressBook, &m.ArchCatalog, chunkRequests(di))
+		},
+	}
+	for name, load := range loads {
+		t.Run(name, func(t *testing.T) {
+			var di comp.DefIndex
+			di.Init()
+			m := newManagerWith(smallChunks)
+			if err := load(&di, m); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			posDef, _ := di.ByType(reflect.TypeFor[Position]())
+			for i, id := range w.ids {
+				if got, want := chunkVelocity(t, &di, m, id), chunkVelocity(t, &w.di, w.m, id); got != want {
+					t.Errorf("entity %v: chunk Velocity = %+v, want %+v", id, got, want)
+				}
+				if pos, _ := componentAt[Position](m, id, posDef.ID); pos.X != float32(i) {
+					t.Errorf("entity %v: Position = %+v, want X=%d", id, pos, i)
+				}
+			}
+		})
+	}
+}
+
+// Loaded into larger chunks, the saved chunks' entities share a chunk,
+// which takes the last one's value.
+func TestLoad_PerChunkValues_LargerChunks(t *testing.T) {
+	w := newChunkWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	if err := persist.Load(&buf, &di, &m.AddressBook, &m.ArchCatalog, chunkRequests(&di), nil); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := chunkVelocity(t, &w.di, w.m, w.ids[len(w.ids)-1])
+	for _, id := range w.ids {
+		if got := chunkVelocity(t, &di, m, id); got != want {
+			t.Errorf("entity %v: chunk Velocity = %+v, want the last chunk's %+v", id, got, want)
+		}
+	}
+}
+
+// A per-chunk value must load into a component registered per-chunk.
+func TestLoad_PerChunkValueRegisteredPerEntity_ReturnsError(t *testing.T) {
+	w := newChunkWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	comps := []persist.CompRequest{req[Position](&di), req[Velocity](&di)}
+	if err := persist.Load(&buf, &di, &m.AddressBook, &m.ArchCatalog, comps, nil); err == nil {
+		t.Error("expected an error loading per-chunk values into a per-entity component")
+	}
+}
+
+// A chunk whose value alone changed is dirty, and its entities see the new
+// value after LoadDelta.
+func TestSaveDelta_PerChunkValues(t *testing.T) {
+	w := newChunkWorld(t)
+	var base, replicaBase persist.Baseline
+	var full bytes.Buffer
+	if err := persist.Save(&full, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var di comp.DefIndex
+	di.Init()
+	m := newManagerWith(smallChunks)
+	if err := persist.Load(&full, &di, &m.AddressBook, &m.ArchCatalog, chunkRequests(&di), &replicaBase); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	velDef, _ := w.di.ByType(reflect.TypeFor[Velocity]())
+	changed := w.ids[len(w.ids)-1]
+	entry, _ := w.m.AddressBook.Get(changed)
+	*(*Velocity)(w.m.ArchCatalog.Archetypes[entry.ArchID].Table.ChunkValueAt(entry.ChunkPtr, velDef.ID)) = Velocity{X: 99}
+
+	var delta bytes.Buffer
+	if err := persist.SaveDelta(&delta, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &base); err != nil {
+		t.Fatalf("SaveDelta: %v", err)
+	}
+	if err := persist.LoadDelta(&delta, &di, &m.AddressBook, &m.ArchCatalog, &replicaBase); err != nil {
+		t.Fatalf("LoadDelta: %v", err)
+	}
+	for _, id := range w.ids {
+		e, _ := w.m.AddressBook.Get(id)
+		if e.ChunkPtr != entry.ChunkPtr {
+			continue
+		}
+		if got := chunkVelocity(t, &di, m, id); got != (Velocity{X: 99}) {
+			t.Errorf("entity %v: chunk Velocity = %+v, want X=99", id, got)
+		}
+	}
+}
+
+// LoadMerge brings per-chunk values to the merged entities' chunks.
+func TestLoadMerge_PerChunkValues(t *testing.T) {
+	w := newChunkWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var di comp.DefIndex
+	di.Init()
+	di.Intern(reflect.TypeFor[Tag]()) // shifts the component IDs
+	m := newManagerWith(smallChunks)
+	remap, err := persist.LoadMerge(&buf, &di, &m.AddressBook, &m.ArchCatalog, chunkRequests(&di))
+	if err != nil {
+		t.Fatalf("LoadMerge: %v", err)
+	}
+	for _, id := range w.ids {
+		if got, want := chunkVelocity(t, &di, m, remap[id]), chunkVelocity(t, &w.di, w.m, id); got != want {
+			t.Errorf("entity %v: chunk Velocity = %+v, want %+v", id, got, want)
+		}
+	}
+}
+
+// chunkLeader names the first entity of its chunk.
+type chunkLeader struct {
+	ID uid.UID64 `goke:"ref"`
+}
+
+// LoadMerge rewrites the references held in per-chunk values to the merged
+// entities' new IDs.
+func TestLoadMerge_PerChunkReferences(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	m := newManagerWith(smallChunks)
+	posDef := di.Intern(reflect.TypeFor[Position]())
+	leaderDef := di.InternWith(reflect.TypeFor[chunkLeader](), comp.RegSpec{PerChunk: true})
+	var spec comp.AccessSpec
+	if err := spec.Comp(posDef); err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Chunk(leaderDef); err != nil {
+		t.Fatal(err)
+	}
+	factory := m.CreateFactory(spec)
+	factory.Create(150)
+	var ids []uid.UID64
+	for factory.Next() {
+		entry, _ := m.AddressBook.Get(factory.IDs[0])
+		*(*chunkLeader)(m.ArchCatalog.Archetypes[entry.ArchID].Table.ChunkValueAt(entry.ChunkPtr, leaderDef.ID)) = chunkLeader{ID: factory.IDs[0]}
+		ids = append(ids, factory.IDs...)
+	}
+	leaderOf := func(di *comp.DefIndex, m *ent.Manager, id uid.UID64) uid.UID64 {
+		def, _ := di.ByType(reflect.TypeFor[chunkLeader]())
+		entry, _ := m.AddressBook.Get(id)
+		return (*chunkLeader)(m.ArchCatalog.Archetypes[entry.ArchID].Table.ChunkValueAt(entry.ChunkPtr, def.ID)).ID
+	}
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var di2 comp.DefIndex
+	di2.Init()
+	m2 := newManagerWith(smallChunks)
+	var tags comp.AccessSpec
+	tags.Init(&di2, comp.Include[Tag]())
+	tagged := m2.CreateFactory(tags)
+	for tagged.Create(7); tagged.Next(); { // the merged entities get other IDs
+	}
+	comps := []persist.CompRequest{req[Position](&di2), reqPerChunk[chunkLeader](&di2)}
+	remap, err := persist.LoadMerge(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, comps)
+	if err != nil {
+		t.Fatalf("LoadMerge: %v", err)
+	}
+	for _, id := range ids {
+		if got, want := leaderOf(&di2, m2, remap[id]), remap[leaderOf(&di, m, id)]; got != want {
+			t.Fatalf("entity %v: chunk leader = %v, want %v", id, got, want)
+		}
+	}
+}
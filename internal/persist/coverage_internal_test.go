@@ -2,6 +2,7 @@ package persist
 
 import (
 	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
@@ -129,12 +130,12 @@ func TestSaveTo_PropagatesEveryWriteError(t *testing.T) {
 	di, m := buildCoverageWorld(t)
 
 	var cw countingWriter
-	if err := saveTo(&cw, di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := saveTo(&cw, fileHeader{Kind: kindFull, ID: 1}, di, &m.AddressBook, &m.ArchCatalog, Filter{}); err != nil {
 		t.Fatalf("saveTo with a never-failing writer: %v", err)
 	}
 
 	for n := range cw.n {
-		if err := saveTo(&failAfterNWriter{n: n}, di, &m.AddressBook, &m.ArchCatalog); err == nil {
+		if err := saveTo(&failAfterNWriter{n: n}, fileHeader{Kind: kindFull, ID: 1}, di, &m.AddressBook, &m.ArchCatalog, Filter{}); err == nil {
 			t.Errorf("expected saveTo to fail when the writer fails after %d successful writes", n)
 		}
 	}
@@ -143,7 +144,8 @@ func TestSaveTo_PropagatesEveryWriteError(t *testing.T) {
 func covReq[T any](di *comp.DefIndex) CompRequest {
 	rt := reflect.TypeFor[T]()
 	return CompRequest{
-		Name: rt.String(),
+		Name:       comp.TypeKey(rt),
+		LegacyName: rt.String(),
 		Register: func(wantSize *uint32) error {
 			di.Intern(rt)
 			return nil
@@ -155,7 +157,7 @@ func TestLoadFrom_PropagatesEveryReadError(t *testing.T) {
 	di, m := buildCoverageWorld(t)
 
 	var buf bytes.Buffer
-	if err := saveTo(&buf, di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := saveTo(&buf, fileHeader{Kind: kindFull, ID: 1}, di, &m.AddressBook, &m.ArchCatalog, Filter{}); err != nil {
 		t.Fatalf("saveTo: %v", err)
 	}
 	valid := buf.Bytes()
@@ -166,7 +168,7 @@ func TestLoadFrom_PropagatesEveryReadError(t *testing.T) {
 		var m2 ent.Manager
 		m2.Init(ent.DefaultConfig(), nil)
 		comps := []CompRequest{covReq[covPosition](&di2), covReq[covName](&di2), covReq[covStamp](&di2), covReq[covWide](&di2)}
-		return loadFrom(bytes.NewReader(data), &di2, &m2.AddressBook, &m2.ArchCatalog, comps)
+		return loadFrom(bytes.NewReader(data), &di2, &m2.AddressBook, &m2.ArchCatalog, comps, nil, nil)
 	}
 
 	if err := load(valid); err != nil {
@@ -191,7 +193,7 @@ func TestReadHeader_BadMagic(t *testing.T) {
 	var buf bytes.Buffer
 	buf.WriteString("XXXX")
 	_ = writeUint32(&buf, FormatVersion)
-	if err := readHeader(&buf); err == nil {
+	if _, err := readHeader(&buf); err == nil {
 		t.Fatal("expected an error for a bad magic")
 	}
 }
@@ -200,7 +202,7 @@ func TestReadHeader_BadVersion(t *testing.T) {
 	var buf bytes.Buffer
 	buf.WriteString(Magic)
 	_ = writeUint32(&buf, FormatVersion+1)
-	if err := readHeader(&buf); err == nil {
+	if _, err := readHeader(&buf); err == nil {
 		t.Fatal("expected an error for an unsupported format version")
 	}
 }
@@ -220,12 +222,12 @@ func TestRegisterComponents_DuplicateName_Panics(t *testing.T) {
 			t.Fatal("expected a panic for a duplicate LoadComp registration")
 		}
 	}()
-	_ = registerComponents(nil, []CompRequest{req, req})
+	_ = registerComponents(nil, []CompRequest{req, req}, FormatVersion)
 }
 
 func TestRegisterComponents_MissingHeader_ReturnsError(t *testing.T) {
 	headers := []compHeader{{Name: "an.unknown.Type", Size: 4, Align: 4}}
-	if err := registerComponents(headers, nil); err == nil {
+	if err := registerComponents(headers, nil, FormatVersion); err == nil {
 		t.Fatal("expected an error when the save file needs a component with no matching LoadComp")
 	}
 }
@@ -233,7 +235,8 @@ func TestRegisterComponents_MissingHeader_ReturnsError(t *testing.T) {
 func covReqErr[T any]() CompRequest {
 	rt := reflect.TypeFor[T]()
 	return CompRequest{
-		Name: rt.String(),
+		Name:       comp.TypeKey(rt),
+		LegacyName: rt.String(),
 		Register: func(wantSize *uint32) error {
 			return errors.New("forced registration failure")
 		},
@@ -241,14 +244,14 @@ func covReqErr[T any]() CompRequest {
 }
 
 func TestRegisterComponents_MatchedRegisterError_Propagates(t *testing.T) {
-	headers := []compHeader{{Name: reflect.TypeFor[covPosition]().String(), Size: 4, Align: 4}}
-	if err := registerComponents(headers, []CompRequest{covReqErr[covPosition]()}); err == nil {
+	headers := []compHeader{{Name: comp.TypeKey(reflect.TypeFor[covPosition]()), Size: 4, Align: 4}}
+	if err := registerComponents(headers, []CompRequest{covReqErr[covPosition]()}, FormatVersion); err == nil {
 		t.Fatal("expected the matched Register's error to propagate")
 	}
 }
 
 func TestRegisterComponents_UnmatchedRegisterError_Propagates(t *testing.T) {
-	if err := registerComponents(nil, []CompRequest{covReqErr[covPosition]()}); err == nil {
+	if err := registerComponents(nil, []CompRequest{covReqErr[covPosition]()}, FormatVersion); err == nil {
 		t.Fatal("expected the unmatched (forward-compat) Register's error to propagate")
 	}
 }
@@ -257,7 +260,7 @@ func TestRegisterComponents_UnmatchedForwardCompat(t *testing.T) {
 	var di comp.DefIndex
 	di.Init()
 	req := covReq[covPosition](&di)
-	if err := registerComponents(nil, []CompRequest{req}); err != nil {
+	if err := registerComponents(nil, []CompRequest{req}, FormatVersion); err != nil {
 		t.Fatalf("expected a LoadComp with no matching header to register anyway (forward compatibility): %v", err)
 	}
 	if di.Count() != 1 {
@@ -317,7 +320,7 @@ func TestDecodeValue_Bool_PropagatesReadError(t *testing.T) {
 
 func TestSave_PropagatesGzipCloseError(t *testing.T) {
 	di, m := buildCoverageWorld(t)
-	if err := Save(&failAfterNWriter{n: 0}, di, &m.AddressBook, &m.ArchCatalog); err == nil {
+	if err := Save(&failAfterNWriter{n: 0}, di, &m.AddressBook, &m.ArchCatalog, nil); err == nil {
 		t.Fatal("expected Save to propagate an underlying write failure")
 	}
 }
@@ -327,7 +330,7 @@ func TestLoad_InvalidGzipStream_ReturnsError(t *testing.T) {
 	di.Init()
 	var m ent.Manager
 	m.Init(ent.DefaultConfig(), nil)
-	if err := Load(bytes.NewReader([]byte("not a gzip stream")), &di, &m.AddressBook, &m.ArchCatalog, nil); err == nil {
+	if err := Load(bytes.NewReader([]byte("not a gzip stream")), &di, &m.AddressBook, &m.ArchCatalog, nil, nil); err == nil {
 		t.Fatal("expected Load to reject a non-gzip stream")
 	}
 }
@@ -385,7 +388,7 @@ func TestLoadArchetype_UnrecognizedEntity_ReturnsError(t *testing.T) {
 		t.Fatalf("writeUint64: %v", err)
 	}
 
-	err := loadArchetype(&buf, di, &book, catalog, ah)
+	err := loadArchetype(sectionReader{r: &buf}, di, &book, catalog, ah, false)
 	if err == nil {
 		t.Fatal("expected an error for an entity id not recognized by the restored id pool state")
 	}
@@ -397,7 +400,7 @@ func TestLoadArchetype_UnrecognizedEntity_ReturnsError(t *testing.T) {
 func TestLoad_TrailingData_ReturnsError(t *testing.T) {
 	di, m := buildCoverageWorld(t)
 	var buf bytes.Buffer
-	if err := Save(&buf, di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := Save(&buf, di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
 	buf.WriteByte(0)
@@ -407,7 +410,171 @@ func TestLoad_TrailingData_ReturnsError(t *testing.T) {
 	var m2 ent.Manager
 	m2.Init(ent.DefaultConfig(), nil)
 	comps := []CompRequest{covReq[covPosition](&di2), covReq[covName](&di2), covReq[covStamp](&di2), covReq[covWide](&di2)}
-	if err := Load(bytes.NewReader(buf.Bytes()), &di2, &m2.AddressBook, &m2.ArchCatalog, comps); err == nil {
+	if err := Load(bytes.NewReader(buf.Bytes()), &di2, &m2.AddressBook, &m2.ArchCatalog, comps, nil); err == nil {
 		t.Fatal("expected Load to reject a save file with trailing data after the payload")
 	}
 }
+
+// saveToV1 writes di/m the way a version 1 build did: saveTo without the
+// section framing, with bare reflect.Type.String() names in the component
+// directory.
+func saveToV1(t *testing.T, w io.Writer, di *comp.DefIndex, m *ent.Manager) {
+	t.Helper()
+	_, _ = io.WriteString(w, Magic)
+	_ = writeUint32(w, formatVersionLegacyNames)
+	nextIndex, generations, freeIndices := m.AddressBook.PoolState()
+	_ = writeUint32(w, nextIndex)
+	_ = writeUint32Slice(w, generations)
+	_ = writeUint32Slice(w, freeIndices)
+	_ = writeUint32(w, uint32(di.Count()))
+	for i := range di.Count() {
+		def := di.ByID(comp.ID(i))
+		_ = writeComponentHeader(w, compHeader{Name: def.Type.String(), Size: uint32(def.Size), Align: uint32(def.Align)})
+	}
+	lives := liveArchetypes(&m.ArchCatalog)
+	_ = writeUint32(w, uint32(len(lives)))
+	for _, archID := range lives {
+		a := &m.ArchCatalog.Archetypes[archID]
+		var ids []uint8
+		for id := range a.Mask().AllSet() {
+			ids = append(ids, uint8(id))
+		}
+		_ = writeUint32(w, uint32(len(ids)))
+		for _, id := range ids {
+			_ = writeUint8(w, id)
+		}
+		_ = writeUint32(w, uint32(a.Len()))
+	}
+	for _, archID := range lives {
+		unframed := func(w io.Writer, write func(io.Writer) error) error { return write(w) }
+		if err := saveArchetypeRows(w, &m.ArchCatalog.Archetypes[archID], nil, unframed); err != nil {
+			t.Fatalf("saveArchetypeRows: %v", err)
+		}
+	}
+}
+
+func TestLoadFrom_Version1File_MatchesByLegacyName(t *testing.T) {
+	di, m := buildCoverageWorld(t)
+	var buf bytes.Buffer
+	saveToV1(t, &buf, di, m)
+
+	var di2 comp.DefIndex
+	di2.Init()
+	var m2 ent.Manager
+	m2.Init(ent.DefaultConfig(), nil)
+	comps := []CompRequest{covReq[covPosition](&di2), covReq[covName](&di2), covReq[covStamp](&di2), covReq[covWide](&di2)}
+	if err := loadFrom(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, comps, nil, nil); err != nil {
+		t.Fatalf("loadFrom on a version 1 payload: %v", err)
+	}
+	if di2.Count() != di.Count() {
+		t.Errorf("expected %d components registered, got %d", di.Count(), di2.Count())
+	}
+	if got, want := len(liveArchetypes(&m2.ArchCatalog)), len(liveArchetypes(&m.ArchCatalog)); got != want {
+		t.Errorf("expected %d archetypes restored, got %d", want, got)
+	}
+}
+
+func TestRegisterComponents_Version1_AmbiguousLegacyName(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	a := covReq[covPosition](&di)
+	b := CompRequest{Name: "example.com/other/persist.covPosition", LegacyName: a.LegacyName, Register: a.Register}
+	headers := []compHeader{{Name: a.LegacyName, Size: 8, Align: 4}}
+
+	err := registerComponents(headers, []CompRequest{a, b}, formatVersionLegacyNames)
+	if err == nil || !strings.Contains(err.Error(), b.Name) {
+		t.Fatalf("expected an ambiguity error naming both candidates, got %v", err)
+	}
+}
+
+func TestRegisterComponents_QualifiedNamesDoNotCollide(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	a := covReq[covPosition](&di)
+	var otherRegistered bool
+	b := CompRequest{
+		Name:       "example.com/other/persist.covPosition",
+		LegacyName: a.LegacyName,
+		Register:   func(*uint32) error { otherRegistered = true; return nil },
+	}
+	headers := []compHeader{{Name: a.Name, Size: 8, Align: 4}}
+
+	if err := registerComponents(headers, []CompRequest{a, b}, FormatVersion); err != nil {
+		t.Fatalf("registerComponents: %v", err)
+	}
+	if def, ok := di.ByType(reflect.TypeFor[covPosition]()); !ok || def.ID != 0 {
+		t.Errorf("expected the header to match the request with the same qualified name")
+	}
+	if !otherRegistered {
+		t.Error("expected the unmatched same-named type to be registered for forward compatibility")
+	}
+}
+
+func TestReadHeader_AcceptsVersion1(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString(Magic)
+	_ = writeUint32(&buf, formatVersionLegacyNames)
+	h, err := readHeader(&buf)
+	if err != nil || h.Version != formatVersionLegacyNames || h.Kind != kindFull {
+		t.Fatalf("expected version %d to be accepted as a full snapshot, got %+v, %v", formatVersionLegacyNames, h, err)
+	}
+}
+
+func TestRegisterComponents_MatchesAlias(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	r := covReq[covPosition](&di)
+	r.Aliases = []string{"old.Position"}
+	headers := []compHeader{{Name: "old.Position", Size: 8, Align: 4}}
+	if err := registerComponents(headers, []CompRequest{r}, FormatVersion); err != nil {
+		t.Fatalf("registerComponents: %v", err)
+	}
+	if di.Count() != 1 {
+		t.Fatalf("expected the aliased component to register, got count %d", di.Count())
+	}
+}
+
+func TestRegisterComponents_NameAndAliasBothInFile_ReturnsError(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	r := covReq[covPosition](&di)
+	r.Aliases = []string{"old.Position"}
+	headers := []compHeader{{Name: r.Name, Size: 8, Align: 4}, {Name: "old.Position", Size: 8, Align: 4}}
+	if err := registerComponents(headers, []CompRequest{r}, FormatVersion); err == nil {
+		t.Fatal("expected an error when one request matches two directory entries")
+	}
+}
+
+func TestRegisterComponents_AliasClaimedTwice_ReturnsError(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	pos, name := covReq[covPosition](&di), covReq[covName](&di)
+	pos.Aliases = []string{"old.Thing"}
+	name.Aliases = []string{"old.Thing"}
+	headers := []compHeader{{Name: pos.Name, Size: 8, Align: 4}}
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("expected an error, not a panic, for an alias claimed twice: %v", r)
+			}
+		}()
+		err = registerComponents(headers, []CompRequest{pos, name}, FormatVersion)
+	}()
+	if err == nil {
+		t.Fatal("expected an error when two requests claim the same alias")
+	}
+}
+
+func TestVerify_Version1File_AcceptsUnframedSections(t *testing.T) {
+	di, m := buildCoverageWorld(t)
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	saveToV1(t, gw, di, m)
+	if err := gw.Close(); err != nil {
+		t.Fatalf("gzip Close: %v", err)
+	}
+	if err := Verify(&buf); err != nil {
+		t.Errorf("Verify on a version 1 file: %v", err)
+	}
+}
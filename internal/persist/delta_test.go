@@ -0,0 +1,356 @@
+package persist_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/arch"
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/ent"
+	"github.com/kjkrol/goke/v3/internal/persist"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// inventory is an opaque codec type whose state lives behind a slice — a
+// change to it never shows in its chunk bytes.
+type inventory struct{ items []int32 }
+
+func (v inventory) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint32(nil, uint32(len(v.items))), nil
+}
+
+func (v *inventory) UnmarshalBinary(data []byte) error {
+	v.items = make([]int32, binary.BigEndian.Uint32(data))
+	return nil
+}
+
+// dumpWorld renders every live entity of m as "key=value" pairs, sorted,
+// keyed by ID — independent of archetype IDs, chunk layout, and comp.ID
+// assignment, so two worlds holding the same entities compare equal.
+func dumpWorld(m *ent.Manager) map[uid.UID64]string {
+	out := make(map[uid.UID64]string)
+	for archID := arch.RootID; archID < m.ArchCatalog.Len(); archID++ {
+		a := &m.ArchCatalog.Archetypes[archID]
+		var cur iter.Cursor
+		for from := 0; ; {
+			idx, ok := a.Table.FillCursorNext(&cur, from, nil)
+			if !ok {
+				break
+			}
+			for slot, id := range cur.IDs {
+				entry, _ := m.AddressBook.Get(id)
+				var parts []string
+				for _, def := range a.Composition().Defs {
+					ptr := a.Table.ComponentAt(entry.ChunkPtr, entry.Slot, def.ID)
+					parts = append(parts, fmt.Sprintf("%s=%+v", def.Key, reflect.NewAt(def.Type, ptr).Elem().Interface()))
+				}
+				slices.Sort(parts)
+				if entry.ChunkPtr != cur.Base || int(entry.Slot) != slot {
+					parts = append(parts, "<address book out of sync>")
+				}
+				out[id] = strings.Join(parts, " ")
+			}
+			from = idx + 1
+		}
+	}
+	return out
+}
+
+func assertSameWorld(t *testing.T, got, want *ent.Manager) {
+	t.Helper()
+	g, w := dumpWorld(got), dumpWorld(want)
+	if len(g) != len(w) {
+		t.Errorf("expected %d entities, got %d", len(w), len(g))
+	}
+	for id, row := range w {
+		if g[id] != row {
+			t.Errorf("entity %v: got %q, want %q", id, g[id], row)
+		}
+	}
+}
+
+type deltaWorld struct {
+	di     comp.DefIndex
+	m      *ent.Manager
+	base   persist.Baseline
+	pos    iter.ArrayRef[Position]
+	posIDs []uid.UID64
+}
+
+func newDeltaWorld(t *testing.T, n int) *deltaWorld {
+	t.Helper()
+	w := &deltaWorld{m: newTestManager()}
+	w.di.Init()
+	var spec comp.AccessSpec
+	spec.Init(&w.di, comp.Track(&w.pos))
+	w.di.Intern(reflect.TypeFor[Velocity]())
+	w.di.Intern(reflect.TypeFor[Name]())
+	f := w.m.CreateFactory(spec)
+	f.Create(n)
+	for f.Next() {
+		positions := w.pos.Slice(&f.Cursor)
+		for i := range positions {
+			positions[i] = Position{X: float32(len(w.posIDs) + i), Y: float32(i) * 0.37}
+		}
+		w.posIDs = append(w.posIDs, f.IDs...)
+	}
+	return w
+}
+
+func (w *deltaWorld) requests(di *comp.DefIndex) []persist.CompRequest {
+	return []persist.CompRequest{req[Position](di), req[Velocity](di), req[Name](di)}
+}
+
+func (w *deltaWorld) posAt(id uid.UID64) *Position {
+	entry, _ := w.m.AddressBook.Get(id)
+	posDef, _ := w.di.ByType(reflect.TypeFor[Position]())
+	return (*Position)(w.m.ArchCatalog.Archetypes[entry.ArchID].Table.ComponentAt(entry.ChunkPtr, entry.Slot, posDef.ID))
+}
+
+func (w *deltaWorld) spawnNames(n int) {
+	var nameCol iter.ArrayRef[Name]
+	var spec comp.AccessSpec
+	spec.Init(&w.di, comp.Track(&nameCol))
+	f := w.m.CreateFactory(spec)
+	f.Create(n)
+	for f.Next() {
+		names := nameCol.Slice(&f.Cursor)
+		for i := range names {
+			names[i] = Name{Value: fmt.Sprintf("new-%d", i)}
+		}
+	}
+}
+
+// replica loads w's full save into a fresh world with its own baseline.
+type replica struct {
+	di   comp.DefIndex
+	m    *ent.Manager
+	base persist.Baseline
+}
+
+func (w *deltaWorld) saveAndReplicate(t *testing.T) *replica {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &w.base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	r := &replica{m: newTestManager()}
+	r.di.Init()
+	if err := persist.Load(&buf, &r.di, &r.m.AddressBook, &r.m.ArchCatalog, w.requests(&r.di), &r.base); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if r.base.ID() != w.base.ID() || r.base.ID() == 0 {
+		t.Fatalf("expected both baselines at the saved snapshot, got %x and %x", r.base.ID(), w.base.ID())
+	}
+	return r
+}
+
+func (w *deltaWorld) saveDelta(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := persist.SaveDelta(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &w.base); err != nil {
+		t.Fatalf("SaveDelta: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func (r *replica) apply(data []byte) error {
+	return persist.LoadDelta(bytes.NewReader(data), &r.di, &r.m.AddressBook, &r.m.ArchCatalog, &r.base)
+}
+
+func TestSaveDelta_AppliesEveryKindOfChange(t *testing.T) {
+	w := newDeltaWorld(t, 5000)
+	r := w.saveAndReplicate(t)
+
+	w.posAt(w.posIDs[10]).X = -1
+	w.m.Remove(w.posIDs[20])
+	velDef, _ := w.di.ByType(reflect.TypeFor[Velocity]())
+	if _, err := w.m.UpsertComp(w.posIDs[30], velDef); err != nil {
+		t.Fatal(err)
+	}
+	w.spawnNames(3)
+
+	if err := r.apply(w.saveDelta(t)); err != nil {
+		t.Fatalf("LoadDelta: %v", err)
+	}
+	assertSameWorld(t, r.m, w.m)
+}
+
+func TestSaveDelta_WritesOnlyChangedChunks(t *testing.T) {
+	w := newDeltaWorld(t, 20000)
+	var full bytes.Buffer
+	if err := persist.Save(&full, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &w.base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	unchanged := w.saveDelta(t)
+	w.posAt(w.posIDs[len(w.posIDs)-1]).Y = 99
+	oneChunk := w.saveDelta(t)
+
+	if len(unchanged) >= len(oneChunk) {
+		t.Errorf("expected an empty delta (%d bytes) to be smaller than a one-chunk delta (%d bytes)", len(unchanged), len(oneChunk))
+	}
+	if len(oneChunk)*4 >= full.Len() {
+		t.Errorf("expected a one-chunk delta (%d bytes) to be far smaller than the full save (%d bytes)", len(oneChunk), full.Len())
+	}
+}
+
+func TestLoadDelta_Chain(t *testing.T) {
+	w := newDeltaWorld(t, 3000)
+	r := w.saveAndReplicate(t)
+
+	w.posAt(w.posIDs[0]).X = 1000
+	d1 := w.saveDelta(t)
+	w.m.Remove(w.posIDs[1])
+	w.spawnNames(2)
+	d2 := w.saveDelta(t)
+	w.posAt(w.posIDs[2]).Y = -5
+	d3 := w.saveDelta(t)
+
+	if err := r.apply(d2); err == nil {
+		t.Fatal("expected a delta applied out of order to fail")
+	}
+	for i, d := range [][]byte{d1, d2, d3} {
+		if err := r.apply(d); err != nil {
+			t.Fatalf("LoadDelta #%d: %v", i+1, err)
+		}
+	}
+	assertSameWorld(t, r.m, w.m)
+
+	if err := r.apply(d3); err == nil {
+		t.Fatal("expected re-applying the last delta to fail")
+	}
+}
+
+// A delta damaged in its last section is rejected before its removals or
+// any earlier section reach the world.
+func TestLoadDelta_DamagedSection_LeavesWorldUntouched(t *testing.T) {
+	w := newDeltaWorld(t, 3000)
+	r := w.saveAndReplicate(t)
+	w.m.Remove(w.posIDs[0])
+	w.posAt(w.posIDs[1]).X = -1
+	w.spawnNames(2)
+	delta := w.saveDelta(t)
+
+	payload := ungzip(t, delta)
+	end := len(payload) - emptySparseDirectory
+	flipped := bytes.Clone(payload)
+	flipped[end-5] ^= 0x40 // the last archetype section's last byte
+	want, parent := dumpWorld(r.m), r.base.ID()
+	for name, data := range map[string][]byte{
+		"checksum mismatch": regzip(t, flipped),
+		"truncated":         regzip(t, payload[:end-9]),
+	} {
+		if err := r.apply(data); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+		if got := dumpWorld(r.m); !maps.Equal(got, want) {
+			t.Errorf("%s: the world changed: %d entities, want %d", name, len(got), len(want))
+		}
+		if r.base.ID() != parent {
+			t.Errorf("%s: the baseline moved on", name)
+		}
+	}
+
+	if err := r.apply(delta); err != nil {
+		t.Fatalf("LoadDelta of the intact delta: %v", err)
+	}
+	assertSameWorld(t, r.m, w.m)
+}
+
+func TestSaveDelta_RecycledIndex(t *testing.T) {
+	w := newDeltaWorld(t, 100)
+	r := w.saveAndReplicate(t)
+
+	// Removing then spawning reuses the freed index with a new generation.
+	w.m.Remove(w.posIDs[5])
+	w.spawnNames(1)
+
+	if err := r.apply(w.saveDelta(t)); err != nil {
+		t.Fatalf("LoadDelta: %v", err)
+	}
+	assertSameWorld(t, r.m, w.m)
+}
+
+func TestSaveDelta_OpaqueIndirectionAlwaysWritten(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	var invCol iter.ArrayRef[inventory]
+	var spec comp.AccessSpec
+	spec.Init(&di, comp.Track(&invCol))
+	f := m.CreateFactory(spec)
+	f.Create(1)
+	var inv *inventory
+	var id uid.UID64
+	for f.Next() {
+		inv = &invCol.Slice(&f.Cursor)[0]
+		inv.items = []int32{1, 2}
+		id = f.IDs[0]
+	}
+
+	var base persist.Baseline
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, &base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var di2 comp.DefIndex
+	di2.Init()
+	m2 := newTestManager()
+	var base2 persist.Baseline
+	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{req[inventory](&di2)}, &base2); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	inv.items = append(inv.items, 3) // invisible in the chunk's bytes
+	var delta bytes.Buffer
+	if err := persist.SaveDelta(&delta, &di, &m.AddressBook, &m.ArchCatalog, &base); err != nil {
+		t.Fatalf("SaveDelta: %v", err)
+	}
+	if err := persist.LoadDelta(&delta, &di2, &m2.AddressBook, &m2.ArchCatalog, &base2); err != nil {
+		t.Fatalf("LoadDelta: %v", err)
+	}
+	entry, _ := m2.AddressBook.Get(id)
+	got := (*inventory)(m2.ArchCatalog.Archetypes[entry.ArchID].Table.ComponentAt(entry.ChunkPtr, entry.Slot, 0))
+	if len(got.items) != 3 {
+		t.Errorf("expected the opaque change to be carried by the delta, got %d items", len(got.items))
+	}
+}
+
+func TestSaveDelta_NoBase(t *testing.T) {
+	w := newDeltaWorld(t, 1)
+	var buf bytes.Buffer
+	if err := persist.SaveDelta(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &w.base); err == nil {
+		t.Fatal("expected SaveDelta without a base snapshot to fail")
+	}
+}
+
+func TestLoad_RejectsDelta_LoadDelta_RejectsFull(t *testing.T) {
+	w := newDeltaWorld(t, 10)
+	var full bytes.Buffer
+	if err := persist.Save(&full, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &w.base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	fullBytes := bytes.Clone(full.Bytes())
+	delta := w.saveDelta(t)
+
+	var di2 comp.DefIndex
+	di2.Init()
+	m2 := newTestManager()
+	if err := persist.Load(bytes.NewReader(delta), &di2, &m2.AddressBook, &m2.ArchCatalog, w.requests(&di2), nil); err == nil {
+		t.Error("expected Load to reject a delta save")
+	}
+
+	r := w.saveAndReplicate(t)
+	if err := r.apply(fullBytes); err == nil {
+		t.Error("expected LoadDelta to reject a full save")
+	}
+}
@@ -1,6 +1,8 @@
 // Package persist encodes and decodes a world snapshot as a self-contained
 // byte stream: entity ID pool bookkeeping, component type definitions,
-// archetype compositions, and per-entity component data.
+// archetype compositions with their shared component values, per-entity
+// component data, per-chunk component values, and the values of sparse
+// components.
 //
 // # Value encoding
 //
@@ -21,4 +23,64 @@
 // This mirrors, byte for byte, the rule comp.ValidateEncodable enforces at
 // registration time — by the time a value reaches this package, its type is
 // already known to be encodable.
+//
+// # Snapshots and deltas
+//
+// Every file written by [Save] carries a random snapshot ID. [SaveDelta]
+// writes only what changed since a [Baseline] — the snapshot last saved or
+// loaded: the entities removed since, every row of each chunk whose
+// content hash changed, and every sparse component whole. A delta records
+// its own ID and its parent's, so [LoadDelta] refuses one applied on top of
+// any other snapshot. Files from format versions before 3 carry no ID and
+// cannot start a delta chain.
+//
+// # Background saves
+//
+// [Capture] copies the world into a [Snapshot] at memcpy speed; the
+// snapshot's Encode then writes the same full save as [Save] from the copy,
+// on any goroutine, while the world goes on changing.
+//
+// # Partial saves and loads
+//
+// [SaveOnly] and [LoadOnly] keep only the archetypes a [Filter] selects,
+// built from the same Include/Exclude opts a query takes. The ID pool is
+// always saved and restored whole; the IDs of entities left out are then
+// released, so they read as removed. Sparse component values follow their
+// entities.
+//
+// # Merging
+//
+// [LoadMerge] adds a full snapshot's entities to a world that is already
+// populated, under fresh IDs, and rewrites the entity references among them
+// — uid.UID64 fields tagged `goke:"ref"`, see [comp.RefFields].
+//
+// # Integrity
+//
+// From format version 4, everything after the file header is a sequence of
+// sections — the ID pool state, the component directory, from version 5
+// the component schemas, a delta's removed entities, the archetype
+// directory — from version 7 with each archetype's shared component values
+// — then per archetype its entity IDs, one section per data-bearing
+// component column and, from version 8, one per per-chunk component, and
+// from version 6 the sparse component directory, then per sparse component
+// its entity IDs and values — each framed by its length and a CRC32. A
+// section is checked before any of it is decoded; a damaged one is
+// reported as a [SectionError] naming it, down to the archetype and
+// column. [Verify] runs the same checks without loading.
+//
+// # Inspection
+//
+// [Inspect] reads a file without its component types: the header, pool,
+// directories, and each archetype's and sparse component's IDs and
+// still-encoded values. The [Schema] recorded per component from version 5
+// on describes the value encoding, enough to cut a column into rows and
+// decode each field; cmd/goke-inspect is built on it.
+//
+// # JSON
+//
+// [ExportJSON] writes the same content as a full snapshot as indented JSON,
+// each component value spelled out by the value encoding rule above — a
+// struct as an object keyed by field name. [ImportJSON] reads it back, and
+// accepts hand-authored fixtures that leave out the ID pool, components,
+// or fields.
 package persist
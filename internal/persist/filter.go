@@ -0,0 +1,66 @@
+package persist
+
+import (
+	"fmt"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/addr"
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+// Filter selects the archetypes a partial save or load keeps: those holding
+// every included component and no excluded one, and satisfying every
+// AnyOf/OneOf/AllOf group — the rule a query's filter opts follow. The
+// zero Filter keeps every archetype.
+type Filter struct {
+	include comp.Mask
+	exclude comp.Mask
+	groups  []comp.Group
+}
+
+// NewFilter resolves opts — Track and Include alike require a component,
+// Exclude forbids one — against defIndex. Panics if a component is both
+// required and excluded.
+func NewFilter(defIndex *comp.DefIndex, opts ...comp.AccessOpt) Filter {
+	var spec comp.AccessSpec
+	spec.Init(defIndex, opts...)
+	f := Filter{include: comp.NewMask(&spec), groups: spec.Groups}
+	for _, id := range spec.ExCompIDs {
+		if f.include.IsSet(id) {
+			panic(fmt.Sprintf("persist: filter both requires and excludes component %d", id))
+		}
+		f.exclude = f.exclude.Set(id)
+	}
+	return f
+}
+
+// keeps reports whether f selects an archetype of composition mask.
+func (f Filter) keeps(mask comp.Mask) bool {
+	return mask.Matches(f.include, f.exclude) && mask.Satisfies(f.groups)
+}
+
+// keepsAll reports whether f is the zero Filter, keeping every archetype.
+func (f Filter) keepsAll() bool {
+	return f.include.IsEmpty() && f.exclude.IsEmpty() && len(f.groups) == 0
+}
+
+// releaseUnloaded returns to book's pool every ID its restored state holds
+// as allocated but no loaded entity carries — the entities a partial save
+// or load left behind — so they read as removed rather than leaking.
+func releaseUnloaded(book *addr.Book) {
+	nextIndex, generations, free := book.PoolState()
+	isFree := make([]bool, nextIndex)
+	for _, idx := range free {
+		isFree[idx] = true
+	}
+	for idx := range nextIndex {
+		if isFree[idx] {
+			continue
+		}
+		id := uid.UID64(uint64(generations[idx])<<uid.GenerationShift | uint64(idx))
+		if _, ok := book.Get(id); !ok {
+			book.Delete(id)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package persist_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/ent"
+	"github.com/kjkrol/goke/v3/internal/persist"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// filterWorld holds three archetypes: Position, Position+Tag, and
+// Position+Velocity.
+type filterWorld struct {
+	di                    comp.DefIndex
+	m                     *ent.Manager
+	plain, tagged, moving []uid.UID64
+}
+
+func newFilterWorld(t *testing.T) *filterWorld {
+	t.Helper()
+	w := &filterWorld{m: newTestManager()}
+	w.di.Init()
+	spawn := func(n int, opts ...comp.AccessOpt) []uid.UID64 {
+		var pos iter.ArrayRef[Position]
+		var spec comp.AccessSpec
+		spec.Init(&w.di, append([]comp.AccessOpt{comp.Track(&pos)}, opts...)...)
+		f := w.m.CreateFactory(spec)
+		f.Create(n)
+		var ids []uid.UID64
+		for f.Next() {
+			positions := pos.Slice(&f.Cursor)
+			for i := range positions {
+				positions[i] = Position{X: float32(len(ids) + i)}
+			}
+			ids = append(ids, f.IDs...)
+		}
+		return ids
+	}
+	var vel iter.ArrayRef[Velocity]
+	w.plain = spawn(5)
+	w.tagged = spawn(7, comp.Include[Tag]())
+	w.moving = spawn(9, comp.Track(&vel))
+	return w
+}
+
+func (w *filterWorld) requests(di *comp.DefIndex) []persist.CompRequest {
+	return []persist.CompRequest{req[Position](di), req[Tag](di), req[Velocity](di)}
+}
+
+// loaded reports which of ids m holds, and fails if m holds some but not
+// all of them.
+func loaded(t *testing.T, m *ent.Manager, what string, ids []uid.UID64) bool {
+	t.Helper()
+	n := 0
+	for _, id := range ids {
+		if _, ok := m.AddressBook.Get(id); ok {
+			n++
+		}
+	}
+	if n != 0 && n != len(ids) {
+		t.Fatalf("%s: %d of %d entities loaded", what, n, len(ids))
+	}
+	return n > 0
+}
+
+func TestSaveOnly_KeepsMatchingArchetypes(t *testing.T) {
+	w := newFilterWorld(t)
+	tests := []struct {
+		name                  string
+		opts                  []comp.AccessOpt
+		plain, tagged, moving bool
+	}{
+		{"include", []comp.AccessOpt{comp.Include[Tag]()}, false, true, false},
+		{"exclude", []comp.AccessOpt{comp.Exclude[Velocity]()}, true, true, false},
+		{"anyOf", []comp.AccessOpt{comp.AnyOf(comp.Include[Tag](), comp.Include[Velocity]())}, false, true, true},
+		{"none", nil, true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			filter := persist.NewFilter(&w.di, tt.opts...)
+			if err := persist.SaveOnly(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, filter); err != nil {
+				t.Fatalf("SaveOnly: %v", err)
+			}
+			var di comp.DefIndex
+			di.Init()
+			m := newTestManager()
+			if err := persist.Load(&buf, &di, &m.AddressBook, &m.ArchCatalog, w.requests(&di), nil); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got := loaded(t, m, "plain", w.plain); got != tt.plain {
+				t.Errorf("plain entities loaded = %v, want %v", got, tt.plain)
+			}
+			if got := loaded(t, m, "tagged", w.tagged); got != tt.tagged {
+				t.Errorf("tagged entities loaded = %v, want %v", got, tt.tagged)
+			}
+			if got := loaded(t, m, "moving", w.moving); got != tt.moving {
+				t.Errorf("moving entities loaded = %v, want %v", got, tt.moving)
+			}
+			assertIDsReleased(t, m)
+		})
+	}
+}
+
+func TestLoadOnly_SkipsOtherArchetypes(t *testing.T) {
+	w := newFilterWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	var base persist.Baseline
+	opts := []comp.AccessOpt{comp.Exclude[Tag]()}
+	if err := persist.LoadOnly(&buf, &di, &m.AddressBook, &m.ArchCatalog, w.requests(&di), &base, opts); err != nil {
+		t.Fatalf("LoadOnly: %v", err)
+	}
+	if !loaded(t, m, "plain", w.plain) || loaded(t, m, "tagged", w.tagged) || !loaded(t, m, "moving", w.moving) {
+		t.Error("expected exactly the untagged entities to be loaded")
+	}
+	if _, ok := di.ByType(reflect.TypeFor[Tag]()); !ok {
+		t.Error("expected every component in the file to be registered, skipped ones too")
+	}
+	if base.ID() != 0 {
+		t.Error("expected no baseline after a partial load")
+	}
+	assertIDsReleased(t, m)
+
+	// A released ID's index is handed out again, under a new generation.
+	var pos iter.ArrayRef[Position]
+	var spec comp.AccessSpec
+	spec.Init(&di, comp.Track(&pos))
+	f := m.CreateFactory(spec)
+	f.Create(len(w.tagged))
+	for f.Next() {
+		for _, id := range f.IDs {
+			for _, old := range w.tagged {
+				if id == old {
+					t.Fatalf("new entity reuses skipped ID %v", id)
+				}
+			}
+		}
+	}
+}
+
+// assertIDsReleased checks that every index m's pool holds as allocated
+// belongs to a loaded entity.
+func assertIDsReleased(t *testing.T, m *ent.Manager) {
+	t.Helper()
+	nextIndex, generations, free := m.AddressBook.PoolState()
+	isFree := make(map[uint32]bool, len(free))
+	for _, idx := range free {
+		isFree[idx] = true
+	}
+	for idx := range nextIndex {
+		id := uid.UID64(uint64(generations[idx])<<uid.GenerationShift | uint64(idx))
+		if _, ok := m.AddressBook.Get(id); !ok && !isFree[idx] {
+			t.Errorf("index %d: allocated in the pool, but no loaded entity holds it", idx)
+		}
+	}
+}
@@ -9,36 +9,129 @@ import (
 // checked by Load.
 const Magic = "GKSV"
 
-// FormatVersion is the current save-file format version.
-const FormatVersion uint32 = 1
+// FormatVersion is the current save-file format version. Each version
+// extends the one before it:
+//
+//   - Version 1 records each component under its bare reflect.Type.String()
+//     and is still read, matched against each request's LegacyName (see
+//     [CompRequest]).
+//   - Version 2 records it under its package-qualified [comp.TypeKey].
+//   - Version 3 adds a snapshot header (kind, ID, parent ID) after the
+//     version, so a delta save can name the snapshot it applies on top of;
+//     versions 1 and 2 are always full snapshots, with no ID.
+//   - Version 4 frames everything after the file header as sections — the
+//     ID pool state, the component directory, a delta's removed entities,
+//     the archetype directory, then each archetype's entity IDs and each of
+//     its component columns — each prefixed with its length and followed by
+//     its CRC32.
+//   - Version 5 adds a [Schema] per component after the component
+//     directory, so a file can be inspected without its Go types.
+//   - Version 6 adds, after the archetype data, a directory of sparse
+//     components, each with its entity count, followed by each one's entity
+//     IDs and values, as sections.
+//   - Version 7 adds to each archetype directory entry the encoded values of
+//     its shared components.
+//   - Version 8 adds to each archetype directory entry its per-chunk
+//     components and the entity count of each saved chunk, and after the
+//     archetype's columns a section per per-chunk component holding one
+//     value per saved chunk.
+const FormatVersion uint32 = 8
 
-func writeHeader(w io.Writer) error {
+// formatVersionLegacyNames is the oldest version Load still reads — its
+// component directory names types without their import path.
+const formatVersionLegacyNames uint32 = 1
+
+// formatVersionSnapshotIDs is the first version carrying a snapshot header.
+const formatVersionSnapshotIDs uint32 = 3
+
+// formatVersionChecksums is the first version framing its sections with a
+// length and checksum.
+const formatVersionChecksums uint32 = 4
+
+// formatVersionSchemas is the first version recording component schemas.
+const formatVersionSchemas uint32 = 5
+
+// formatVersionSparse is the first version recording sparse components.
+const formatVersionSparse uint32 = 6
+
+// formatVersionSharedValues is the first version recording shared
+// component values.
+const formatVersionSharedValues uint32 = 7
+
+// formatVersionChunkValues is the first version recording per-chunk
+// component values.
+const formatVersionChunkValues uint32 = 8
+
+// Snapshot kinds, recorded in the snapshot header.
+const (
+	kindFull  uint8 = 0 // the whole world
+	kindDelta uint8 = 1 // only what changed since the parent snapshot
+)
+
+// fileHeader is everything before a save file's ID pool state.
+type fileHeader struct {
+	Version uint32
+	Kind    uint8
+	ID      uint64 // 0 for files older than formatVersionSnapshotIDs
+	Parent  uint64 // the snapshot a delta applies on top of; 0 for a full one
+}
+
+func writeHeader(w io.Writer, h fileHeader) error {
 	if _, err := io.WriteString(w, Magic); err != nil {
 		return err
 	}
-	return writeUint32(w, FormatVersion)
+	if err := writeUint32(w, FormatVersion); err != nil {
+		return err
+	}
+	if err := writeUint8(w, h.Kind); err != nil {
+		return err
+	}
+	if err := writeUint64(w, h.ID); err != nil {
+		return err
+	}
+	return writeUint64(w, h.Parent)
 }
 
-func readHeader(r io.Reader) error {
+// readHeader checks the magic and reads the file's format version — any
+// version from formatVersionLegacyNames up to FormatVersion — and, from
+// formatVersionSnapshotIDs on, its snapshot header.
+func readHeader(r io.Reader) (fileHeader, error) {
 	magic := make([]byte, len(Magic))
 	if _, err := io.ReadFull(r, magic); err != nil {
-		return fmt.Errorf("persist: reading magic: %w", err)
+		return fileHeader{}, fmt.Errorf("persist: reading magic: %w", err)
 	}
 	if string(magic) != Magic {
-		return fmt.Errorf("persist: not a goke save file (bad magic %q)", magic)
+		return fileHeader{}, fmt.Errorf("persist: not a goke save file (bad magic %q)", magic)
 	}
 	version, err := readUint32(r)
 	if err != nil {
-		return fmt.Errorf("persist: reading format version: %w", err)
+		return fileHeader{}, fmt.Errorf("persist: reading format version: %w", err)
+	}
+	if version < formatVersionLegacyNames || version > FormatVersion {
+		return fileHeader{}, fmt.Errorf("persist: unsupported save file version %d (this build supports %d through %d)", version, formatVersionLegacyNames, FormatVersion)
+	}
+	h := fileHeader{Version: version, Kind: kindFull}
+	if version < formatVersionSnapshotIDs {
+		return h, nil
 	}
-	if version != FormatVersion {
-		return fmt.Errorf("persist: unsupported save file version %d (this build supports %d)", version, FormatVersion)
+	if h.Kind, err = readUint8(r); err != nil {
+		return fileHeader{}, fmt.Errorf("persist: reading snapshot kind: %w", err)
 	}
-	return nil
+	if h.Kind != kindFull && h.Kind != kindDelta {
+		return fileHeader{}, fmt.Errorf("persist: unknown snapshot kind %d", h.Kind)
+	}
+	if h.ID, err = readUint64(r); err != nil {
+		return fileHeader{}, fmt.Errorf("persist: reading snapshot ID: %w", err)
+	}
+	if h.Parent, err = readUint64(r); err != nil {
+		return fileHeader{}, fmt.Errorf("persist: reading parent snapshot ID: %w", err)
+	}
+	return h, nil
 }
 
 // compHeader is one entry in the save file's component directory: the
-// recorded Go type name and layout, in comp.ID order.
+// recorded key (see [comp.Def.Key]; a bare reflect.Type.String() in
+// version 1 files) and layout, in comp.ID order.
 type compHeader struct {
 	Name  string
 	Size  uint32
@@ -72,10 +165,25 @@ func readComponentHeader(r io.Reader) (compHeader, error) {
 }
 
 // archHeader is one entry in the save file's archetype directory: its
-// composition (as component IDs, including tags) and live entity count.
+// composition (as component IDs, including tags and shared components),
+// live entity count, and — from formatVersionSharedValues on — the values
+// of its shared components. From formatVersionChunkValues on it lists its
+// per-chunk components and, if there are any, the entity count of each
+// chunk saved, which the per-chunk value sections hold one value for.
 type archHeader struct {
 	CompIDs     []uint8
 	EntityCount uint32
+	Shared      []sharedEntry
+	Chunked     []uint8
+	Runs        []uint32
+}
+
+// sharedEntry is one shared component's value in an archHeader, encoded
+// by EncodeValue and written length-prefixed, so the directory can be read
+// without the component's type.
+type sharedEntry struct {
+	CompID uint8
+	Value  []byte
 }
 
 func writeArchHeader(w io.Writer, h archHeader) error {
@@ -87,10 +195,32 @@ func writeArchHeader(w io.Writer, h archHeader) error {
 			return err
 		}
 	}
-	return writeUint32(w, h.EntityCount)
+	if err := writeUint32(w, h.EntityCount); err != nil {
+		return err
+	}
+	if err := writeUint32(w, uint32(len(h.Shared))); err != nil {
+		return err
+	}
+	for _, s := range h.Shared {
+		if err := writeUint8(w, s.CompID); err != nil {
+			return err
+		}
+		if err := writeBytes(w, s.Value); err != nil {
+			return err
+		}
+	}
+	if err := writeUint32(w, uint32(len(h.Chunked))); err != nil {
+		return err
+	}
+	for _, id := range h.Chunked {
+		if err := writeUint8(w, id); err != nil {
+			return err
+		}
+	}
+	return writeUint32Slice(w, h.Runs)
 }
 
-func readArchHeader(r io.Reader) (archHeader, error) {
+func readArchHeader(r io.Reader, version uint32) (archHeader, error) {
 	n, err := readUint32(r)
 	if err != nil {
 		return archHeader{}, err
@@ -107,5 +237,71 @@ func readArchHeader(r io.Reader) (archHeader, error) {
 	if err != nil {
 		return archHeader{}, err
 	}
-	return archHeader{CompIDs: ids, EntityCount: count}, nil
+	h := archHeader{CompIDs: ids, EntityCount: count}
+	if version < formatVersionSharedValues {
+		return h, nil
+	}
+	if n, err = readUint32(r); err != nil {
+		return archHeader{}, err
+	}
+	for range n {
+		id, err := readUint8(r)
+		if err != nil {
+			return archHeader{}, err
+		}
+		value, err := readBytes(r)
+		if err != nil {
+			return archHeader{}, err
+		}
+		h.Shared = append(h.Shared, sharedEntry{CompID: id, Value: value})
+	}
+	if version < formatVersionChunkValues {
+		return h, nil
+	}
+	if n, err = readUint32(r); err != nil {
+		return archHeader{}, err
+	}
+	h.Chunked = make([]uint8, n)
+	for i := range h.Chunked {
+		if h.Chunked[i], err = readUint8(r); err != nil {
+			return archHeader{}, err
+		}
+	}
+	if h.Runs, err = readUint32Slice(r); err != nil {
+		return archHeader{}, err
+	}
+	var total uint64
+	for _, n := range h.Runs {
+		total += uint64(n)
+	}
+	if len(h.Chunked) > 0 && total != uint64(h.EntityCount) {
+		return archHeader{}, fmt.Errorf("persist: corrupt save file: chunks of %d entities in an archetype of %d", total, h.EntityCount)
+	}
+	return h, nil
+}
+
+// sparseHeader is one entry in the save file's sparse component directory:
+// the component's ID and how many entities hold it.
+type sparseHeader struct {
+	CompID      uint8
+	EntityCount uint32
+}
+
+func writeSparseHeader(w io.Writer, h sparseHeader) error {
+	if err := writeUint8(w, h.CompID); err != nil {
+		return err
+	}
+	return writeUint32(w, h.EntityCount)
+}
+
+func readSparseHeader(r io.Reader) (sparseHeader, error) {
+	id, err := readUint8(r)
+	if err != nil {
+		return sparseHeader{}, err
+	}
+	count, err := readUint32(r)
+	if err != nil {
+		return sparseHeader{}, err
+	}
+	return sparseHeader{CompID: id, EntityCount: count}, nil
 }
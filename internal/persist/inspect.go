@@ -0,0 +1,272 @@
+package persist
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kjkrol/uid"
+)
+
+// FileInfo is a save file's content as read without its component types —
+// see [Inspect].
+type FileInfo struct {
+	Version uint32
+	Delta   bool   // a delta save, applying on top of Parent
+	ID      uint64 // 0 before format version 3
+	Parent  uint64
+
+	NextIndex   uint32   // ID pool high-water mark
+	Generations []uint32 // current generation per index
+	Free        []uint32 // indices available for reuse
+
+	Components []ComponentInfo
+	Removed    []uid.UID64 // a delta's removed entities
+	Archetypes []ArchetypeInfo
+	Sparse     []SparseInfo // nil before format version 6
+
+	// RowsReadable reports whether entity rows can be printed: from format
+	// version 4 on, section framing lets Inspect read each archetype's IDs
+	// and encoded columns without the types, but only version 5's schemas
+	// cut a column into one value per entity (see Rows). For older files
+	// only the directories are read.
+	RowsReadable bool
+}
+
+// ComponentInfo is one component directory entry.
+type ComponentInfo struct {
+	Name        string
+	Size, Align uint32
+	Schema      *Schema // nil before format version 5
+}
+
+// ArchetypeInfo is one archetype directory entry and, if the file's rows
+// are readable, its data.
+type ArchetypeInfo struct {
+	Components  []int // indices into FileInfo.Components, tags included
+	EntityCount int
+	Shared      []ColumnInfo // one value per shared component; nil before format version 7
+	Chunks      []int        // entity count of each saved chunk, if it has per-chunk components; nil before format version 8
+	IDs         []uid.UID64
+	Columns     []ColumnInfo // one per other data-bearing component, in Components order
+	ChunkValues []ColumnInfo // one per per-chunk component, a value per entry of Chunks
+}
+
+// ColumnInfo is one component's values for every entity of an archetype,
+// a shared component's single value, or a per-chunk component's value for
+// every saved chunk, still encoded.
+type ColumnInfo struct {
+	Component int // index into FileInfo.Components
+	Data      []byte
+}
+
+// isShared reports whether component c has a shared value in a.
+func (a *ArchetypeInfo) isShared(c int) bool {
+	for _, s := range a.Shared {
+		if s.Component == c {
+			return true
+		}
+	}
+	return false
+}
+
+// isChunked reports whether component c has per-chunk values in a.
+func (a *ArchetypeInfo) isChunked(c int) bool {
+	for _, v := range a.ChunkValues {
+		if v.Component == c {
+			return true
+		}
+	}
+	return false
+}
+
+// SparseInfo is one sparse component directory entry and its data.
+type SparseInfo struct {
+	Component int // index into FileInfo.Components
+	IDs       []uid.UID64
+	Data      []byte // the entities' values, still encoded; nil for a tag
+}
+
+// Rows cuts c into one encoded value per entity of a, using the
+// component's schema; it returns an error for a file without schemas.
+func (info *FileInfo) Rows(a *ArchetypeInfo, c ColumnInfo) ([][]byte, error) {
+	schema := info.Components[c.Component].Schema
+	if schema == nil {
+		return nil, fmt.Errorf("persist: version %d files record no component schemas", info.Version)
+	}
+	return schema.Split(c.Data, a.EntityCount)
+}
+
+// ChunkRows cuts per-chunk values c into one encoded value per entry of
+// a.Chunks, as Rows does for a column.
+func (info *FileInfo) ChunkRows(a *ArchetypeInfo, c ColumnInfo) ([][]byte, error) {
+	schema := info.Components[c.Component].Schema
+	if schema == nil {
+		return nil, fmt.Errorf("persist: version %d files record no component schemas", info.Version)
+	}
+	return schema.Split(c.Data, len(a.Chunks))
+}
+
+// SparseRows cuts s's data into one encoded value per entity, as Rows does
+// for a column.
+func (info *FileInfo) SparseRows(s *SparseInfo) ([][]byte, error) {
+	schema := info.Components[s.Component].Schema
+	if schema == nil {
+		return nil, fmt.Errorf("persist: version %d files record no component schemas", info.Version)
+	}
+	return schema.Split(s.Data, len(s.IDs))
+}
+
+// Inspect reads a save file — full snapshot or delta — without registering
+// or knowing any component type, checking each section as Load would.
+func Inspect(r io.Reader) (*FileInfo, error) {
+	var info *FileInfo
+	err := readGzip(r, func(gr io.Reader) error {
+		var err error
+		info, err = inspectFrom(gr)
+		return err
+	})
+	return info, err
+}
+
+func inspectFrom(r io.Reader) (*FileInfo, error) {
+	fh, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	info := &FileInfo{Version: fh.Version, Delta: fh.Kind == kindDelta, ID: fh.ID, Parent: fh.Parent}
+	sr := newSectionReader(r, fh.Version)
+
+	pool, err := sr.poolState()
+	if err != nil {
+		return nil, err
+	}
+	info.NextIndex, info.Generations, info.Free = pool.nextIndex, pool.generations, pool.freeIndices
+
+	headers, schemas, err := sr.componentDirectory()
+	if err != nil {
+		return nil, err
+	}
+	info.Components = make([]ComponentInfo, len(headers))
+	keys := make([]string, len(headers))
+	for i, h := range headers {
+		info.Components[i] = ComponentInfo{Name: h.Name, Size: h.Size, Align: h.Align}
+		if schemas != nil {
+			info.Components[i].Schema = &schemas[i]
+		}
+		keys[i] = h.Name
+	}
+
+	if info.Delta {
+		if info.Removed, err = sr.removedIDs(); err != nil {
+			return nil, err
+		}
+	}
+	archHeaders, err := sr.archDirectory()
+	if err != nil {
+		return nil, err
+	}
+	info.Archetypes = make([]ArchetypeInfo, len(archHeaders))
+	for i, ah := range archHeaders {
+		a := &info.Archetypes[i]
+		a.EntityCount = int(ah.EntityCount)
+		for _, id := range ah.CompIDs {
+			if int(id) >= len(headers) {
+				return nil, fmt.Errorf("persist: corrupt save file: archetype names component %d of %d", id, len(headers))
+			}
+			a.Components = append(a.Components, int(id))
+		}
+		for _, sh := range ah.Shared {
+			if int(sh.CompID) >= len(headers) {
+				return nil, fmt.Errorf("persist: corrupt save file: archetype names shared component %d of %d", sh.CompID, len(headers))
+			}
+			a.Shared = append(a.Shared, ColumnInfo{Component: int(sh.CompID), Data: sh.Value})
+		}
+		for _, id := range ah.Chunked {
+			if int(id) >= len(headers) {
+				return nil, fmt.Errorf("persist: corrupt save file: archetype names per-chunk component %d of %d", id, len(headers))
+			}
+			a.ChunkValues = append(a.ChunkValues, ColumnInfo{Component: int(id)})
+		}
+		if len(ah.Chunked) > 0 {
+			for _, n := range ah.Runs {
+				a.Chunks = append(a.Chunks, int(n))
+			}
+		}
+	}
+
+	if !sr.framed {
+		// Without section lengths, the rows can't be told apart without
+		// the types that wrote them.
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return nil, fmt.Errorf("persist: %w", err)
+		}
+		return info, nil
+	}
+	info.RowsReadable = schemas != nil
+	for i, ah := range archHeaders {
+		a := &info.Archetypes[i]
+		at := SectionError{Section: sectionArchetypeData, Archetype: archetypeName(keys, ah)}
+		a.IDs = make([]uid.UID64, a.EntityCount)
+		if err := sr.read(at, func(r io.Reader) error { return readIDsInto(r, a.IDs) }); err != nil {
+			return nil, err
+		}
+		for _, id := range a.Components {
+			if headers[id].Size == 0 || a.isShared(id) || a.isChunked(id) {
+				continue
+			}
+			at.Component = headers[id].Name
+			col := ColumnInfo{Component: id}
+			if err := sr.read(at, func(r io.Reader) error {
+				var err error
+				col.Data, err = io.ReadAll(r)
+				return err
+			}); err != nil {
+				return nil, err
+			}
+			a.Columns = append(a.Columns, col)
+		}
+		for j := range a.ChunkValues {
+			v := &a.ChunkValues[j]
+			at.Component = headers[v.Component].Name
+			if err := sr.read(at, func(r io.Reader) error {
+				var err error
+				v.Data, err = io.ReadAll(r)
+				return err
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if fh.Version < formatVersionSparse {
+		return info, nil
+	}
+
+	sparse, err := sr.sparseDirectory()
+	if err != nil {
+		return nil, err
+	}
+	info.Sparse = make([]SparseInfo, len(sparse))
+	for i, sh := range sparse {
+		if int(sh.CompID) >= len(headers) {
+			return nil, fmt.Errorf("persist: corrupt save file: sparse directory names component %d of %d", sh.CompID, len(headers))
+		}
+		sp := &info.Sparse[i]
+		sp.Component = int(sh.CompID)
+		at := SectionError{Section: sectionSparseData, Component: headers[sh.CompID].Name}
+		sp.IDs = make([]uid.UID64, sh.EntityCount)
+		if err := sr.read(at, func(r io.Reader) error { return readIDsInto(r, sp.IDs) }); err != nil {
+			return nil, err
+		}
+		if headers[sh.CompID].Size == 0 {
+			continue
+		}
+		if err := sr.read(at, func(r io.Reader) error {
+			var err error
+			sp.Data, err = io.ReadAll(r)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,136 @@
+package persist_test
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/persist"
+)
+
+func TestSchemaOf_DescribesTheEncoding(t *testing.T) {
+	got := persist.SchemaOf(reflect.TypeFor[kitchenSink]()).String()
+	want := "struct{I8 int8; I int64; U64 uint64; F32 float32; F64 float64; C64 complex64; C128 complex128; " +
+		"B bool; S string; A [2]int16; Stamp opaque; Nested struct{X float32; Y float32}}"
+	if got != want {
+		t.Errorf("SchemaOf(kitchenSink) =\n  %s\nwant\n  %s", got, want)
+	}
+}
+
+func TestSchema_DecodesWhatEncodeValueWrites(t *testing.T) {
+	v := kitchenSink{I8: -8, I: 1 << 40, F32: 0.25, S: "hi", A: [2]int16{3, -4}, Stamp: stamp{V: 7}, Nested: Position{X: 1}}
+	var buf bytes.Buffer
+	if err := persist.EncodeValue(&buf, reflect.TypeOf(v), reflect.ValueOf(&v).UnsafePointer()); err != nil {
+		t.Fatalf("EncodeValue: %v", err)
+	}
+	got, err := persist.SchemaOf(reflect.TypeOf(v)).Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	fields := got.([]persist.Field)
+	byName := make(map[string]any)
+	for _, f := range fields {
+		byName[f.Name] = f.Value
+	}
+	checks := map[string]any{
+		"I8": int64(-8), "I": int64(1 << 40), "F32": float32(0.25), "S": "hi",
+		"A": []any{int64(3), int64(-4)}, "Stamp": []byte("7"),
+		"Nested": []persist.Field{{Name: "X", Value: float32(1)}, {Name: "Y", Value: float32(0)}},
+	}
+	for name, want := range checks {
+		if !reflect.DeepEqual(byName[name], want) {
+			t.Errorf("%s = %#v, want %#v", name, byName[name], want)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Decode left %d bytes unread", buf.Len())
+	}
+}
+
+func TestInspect_ReadsAFileWithoutItsTypes(t *testing.T) {
+	w := newFilterWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	info, err := persist.Inspect(&buf)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+
+	if info.Version != persist.FormatVersion || info.Delta || info.ID == 0 || !info.RowsReadable {
+		t.Errorf("unexpected header: %+v", info)
+	}
+	if len(info.Components) != w.di.Count() {
+		t.Fatalf("expected %d components, got %d", w.di.Count(), len(info.Components))
+	}
+	for i, c := range info.Components {
+		def := w.di.ByID(comp.ID(i))
+		if c.Name != def.Key || c.Schema == nil || c.Schema.String() != persist.SchemaOf(def.Type).String() {
+			t.Errorf("component %d: %+v does not describe %s", i, c, def.Key)
+		}
+	}
+
+	posDef, _ := w.di.ByType(reflect.TypeFor[Position]())
+	for _, ids := range [][]uid.UID64{w.plain, w.tagged, w.moving} {
+		want := idsOf(ids)
+		a := archetypeHolding(info, want[0])
+		if a == nil || a.EntityCount != len(want) || !slices.Equal(idsOf(a.IDs), want) {
+			t.Fatalf("expected an archetype holding exactly %v, got %+v", want, a)
+		}
+		for _, col := range a.Columns {
+			if col.Component != int(posDef.ID) {
+				continue
+			}
+			rows, err := info.Rows(a, col)
+			if err != nil {
+				t.Fatalf("Rows: %v", err)
+			}
+			for i, raw := range rows {
+				v, err := info.Components[col.Component].Schema.Decode(bytes.NewReader(raw))
+				if err != nil {
+					t.Fatalf("Decode: %v", err)
+				}
+				if x := v.([]persist.Field)[0].Value; x != float32(i) {
+					t.Errorf("row %d: Position.X = %v, want %d", i, x, i)
+				}
+			}
+		}
+	}
+}
+
+func TestInspect_ReadsDeltas(t *testing.T) {
+	w := newDeltaWorld(t, 10)
+	if err := persist.Save(&bytes.Buffer{}, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &w.base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	w.m.Remove(w.posIDs[3])
+	info, err := persist.Inspect(bytes.NewReader(w.saveDelta(t)))
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if !info.Delta || info.Parent == 0 || !slices.Equal(info.Removed, w.posIDs[3:4]) {
+		t.Errorf("expected a delta removing %v, got %+v", w.posIDs[3], info)
+	}
+}
+
+func archetypeHolding(info *persist.FileInfo, id uint64) *persist.ArchetypeInfo {
+	for i := range info.Archetypes {
+		if slices.Contains(idsOf(info.Archetypes[i].IDs), id) {
+			return &info.Archetypes[i]
+		}
+	}
+	return nil
+}
+
+func idsOf(ids []uid.UID64) []uint64 {
+	out := make([]uint64, len(ids))
+	for i, id := range ids {
+		out[i] = uint64(id)
+	}
+	return out
+}
@@ -0,0 +1,644 @@
+package persist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/addr"
+	"github.com/kjkrol/goke/v3/internal/arch"
+	"github.com/kjkrol/goke/v3/internal/colstore"
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// JSONFormat and JSONVersion identify a JSON export; [ImportJSON] checks
+// both.
+const (
+	JSONFormat  = "goke-world"
+	JSONVersion = 1
+)
+
+// jsonWorld is the document ExportJSON writes: the same content as a full
+// binary snapshot, with each component value spelled out field by field.
+type jsonWorld struct {
+	Format     string          `json:"format"`
+	Version    int             `json:"version"`
+	Pool       *jsonPool       `json:"pool,omitempty"`
+	Components []jsonComponent `json:"components"`
+	Archetypes []jsonArchetype `json:"archetypes"`
+	Sparse     []jsonSparse    `json:"sparse,omitempty"`
+}
+
+type jsonPool struct {
+	NextIndex   uint32   `json:"nextIndex"`
+	Generations []uint32 `json:"generations"`
+	Free        []uint32 `json:"free"`
+}
+
+type jsonComponent struct {
+	Name string `json:"name"`
+	Size uint32 `json:"size"`
+}
+
+type jsonArchetype struct {
+	Components []string                   `json:"components"`       // tags, shared and per-chunk components included
+	Shared     map[string]json.RawMessage `json:"shared,omitempty"` // shared components' values
+	Chunks     []jsonChunk                `json:"chunks,omitempty"` // per-chunk components' values
+	Entities   []jsonEntity               `json:"entities"`
+}
+
+// jsonChunk is one saved chunk of an archetype: the number of its entities,
+// the next ones in the archetype's list, and its per-chunk values.
+type jsonChunk struct {
+	Entities int                        `json:"entities"`
+	Values   map[string]json.RawMessage `json:"values,omitempty"`
+}
+
+type jsonEntity struct {
+	ID         uid.UID64                  `json:"id"`
+	Components map[string]json.RawMessage `json:"components,omitempty"` // data-bearing components only
+}
+
+// jsonSparse is one sparse component's entities and their values.
+type jsonSparse struct {
+	Component string            `json:"component"`
+	Entities  []jsonSparseValue `json:"entities"`
+}
+
+type jsonSparseValue struct {
+	ID    uid.UID64       `json:"id"`
+	Value json.RawMessage `json:"value,omitempty"` // absent for a tag
+}
+
+// ExportJSON writes the world to w as an indented JSON document — the ID
+// pool bookkeeping, the component directory, every archetype with its
+// entities, and every non-empty sparse component, each component value as
+// JSON built by the same rule
+// [EncodeValue] follows:
+//
+//   - a type implementing encoding.BinaryMarshaler becomes a base64 string
+//     of its MarshalBinary bytes;
+//   - a string becomes a JSON string, a bool a JSON bool;
+//   - a struct becomes an object keyed by field name, a fixed-size array
+//     an array;
+//   - an integer or float becomes a number — a NaN or infinite float the
+//     string "NaN", "+Inf" or "-Inf" — and a complex number a [real, imag]
+//     pair.
+//
+// An archetype's shared component values are an object keyed by component,
+// beside its entities; its per-chunk values are listed per chunk, each
+// with the count of the entities it holds.
+func ExportJSON(w io.Writer, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog) error {
+	nextIndex, generations, free := book.PoolState()
+	doc := jsonWorld{
+		Format:     JSONFormat,
+		Version:    JSONVersion,
+		Pool:       &jsonPool{NextIndex: nextIndex, Generations: generations, Free: free},
+		Components: make([]jsonComponent, defIndex.Count()),
+		Archetypes: []jsonArchetype{},
+	}
+	keys := componentKeys(defIndex)
+	for i, h := range componentDirectory(defIndex) {
+		doc.Components[i] = jsonComponent{Name: h.Name, Size: h.Size}
+	}
+
+	for _, archID := range liveArchetypes(catalog) {
+		a := &catalog.Archetypes[archID]
+		ja := jsonArchetype{}
+		for _, id := range compIDs(a) {
+			ja.Components = append(ja.Components, keys[id])
+		}
+		for _, v := range a.Composition().Shared {
+			def := defIndex.ByID(v.ID)
+			raw, err := appendJSONValue(nil, reflect.NewAt(def.Type, a.SharedValue(v.ID)).Elem())
+			if err != nil {
+				return fmt.Errorf("persist: archetype %v shared component %q: %w", ja.Components, def.Key, err)
+			}
+			if ja.Shared == nil {
+				ja.Shared = make(map[string]json.RawMessage)
+			}
+			ja.Shared[def.Key] = raw
+		}
+		defs, chunked := columnDefs(a), chunkDefs(a)
+		if err := walkTableChunks(&a.Table, func(_ int, cur *iter.Cursor) error {
+			if len(chunked) > 0 {
+				jc := jsonChunk{Entities: len(cur.IDs), Values: make(map[string]json.RawMessage, len(chunked))}
+				for _, def := range chunked {
+					raw, err := appendJSONValue(nil, reflect.NewAt(def.Type, a.Table.ChunkValueAt(cur.Base, def.ID)).Elem())
+					if err != nil {
+						return fmt.Errorf("persist: archetype %v per-chunk component %q: %w", ja.Components, def.Key, err)
+					}
+					jc.Values[def.Key] = raw
+				}
+				ja.Chunks = append(ja.Chunks, jc)
+			}
+			for slot, id := range cur.IDs {
+				e := jsonEntity{ID: id}
+				for _, def := range defs {
+					ptr := a.Table.ComponentAt(cur.Base, colstore.Slot(slot), def.ID)
+					raw, err := appendJSONValue(nil, reflect.NewAt(def.Type, ptr).Elem())
+					if err != nil {
+						return fmt.Errorf("persist: entity %v component %q: %w", id, def.Key, err)
+					}
+					if e.Components == nil {
+						e.Components = make(map[string]json.RawMessage, len(defs))
+					}
+					e.Components[def.Key] = raw
+				}
+				ja.Entities = append(ja.Entities, e)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		doc.Archetypes = append(doc.Archetypes, ja)
+	}
+
+	for _, rows := range savedSparse(defIndex, catalog, nil) {
+		if len(rows.ids) == 0 {
+			continue
+		}
+		js := jsonSparse{Component: rows.def.Key}
+		for i, id := range rows.ids {
+			e := jsonSparseValue{ID: id}
+			if rows.def.Size > 0 {
+				raw, err := appendJSONValue(nil, reflect.NewAt(rows.def.Type, rows.set.At(rows.pos[i])).Elem())
+				if err != nil {
+					return fmt.Errorf("persist: entity %v component %q: %w", id, rows.def.Key, err)
+				}
+				e.Value = raw
+			}
+			js.Entities = append(js.Entities, e)
+		}
+		doc.Sparse = append(doc.Sparse, js)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// ImportJSON reads a document written by ExportJSON — or authored by hand
+// in the same shape — into an empty world, registering components via comps
+// as [Load] does and restoring every entity under its recorded ID, then
+// the sparse components' values of those entities. Per-chunk values come
+// back as Load brings them. The "pool" object may be left out: the ID pool
+// is then rebuilt from the entities' IDs, every index between them free.
+// Within an entity, a component may be left out (it is zeroed) and so may
+// a struct field; so may an archetype's "chunks", or a value within one.
+func ImportJSON(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, comps []CompRequest) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var doc jsonWorld
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("persist: JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("persist: JSON: unexpected data after the document")
+	}
+	if doc.Format != JSONFormat || doc.Version != JSONVersion {
+		return fmt.Errorf("persist: JSON: not a %s version %d document (format %q, version %d)", JSONFormat, JSONVersion, doc.Format, doc.Version)
+	}
+
+	headers := make([]compHeader, len(doc.Components))
+	for i, c := range doc.Components {
+		headers[i] = compHeader{Name: c.Name, Size: c.Size}
+	}
+	keys, err := registerComponentKeys(headers, comps, FormatVersion)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]comp.Def, len(headers))
+	for i, h := range headers {
+		byName[h.Name], _ = defIndex.ByKey(keys[i])
+	}
+
+	pool := doc.Pool
+	if pool == nil {
+		pool = poolFromIDs(doc.Archetypes)
+	} else if err := pool.validate(); err != nil {
+		return err
+	}
+	book.RestorePoolState(pool.NextIndex, pool.Generations, pool.Free)
+
+	seen := make(map[uid.UID64]bool)
+	for _, ja := range doc.Archetypes {
+		if err := importArchetype(ja, byName, book, catalog, seen); err != nil {
+			return err
+		}
+	}
+	for _, js := range doc.Sparse {
+		if err := importSparse(js, byName, catalog, seen); err != nil {
+			return err
+		}
+	}
+	releaseUnloaded(book)
+	return nil
+}
+
+func importArchetype(ja jsonArchetype, byName map[string]comp.Def, book *addr.Book, catalog *arch.Catalog, seen map[uid.UID64]bool) error {
+	var composition comp.Composition
+	for _, name := range ja.Components {
+		def, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("persist: JSON: archetype %v names component %q, which is not in the component list", ja.Components, name)
+		}
+		if def.Sparse {
+			return fmt.Errorf("persist: JSON: archetype %v names sparse component %q, which belongs to no archetype", ja.Components, name)
+		}
+		if !def.Shared {
+			composition = composition.With(def)
+			continue
+		}
+		raw, ok := ja.Shared[name]
+		if !ok {
+			return fmt.Errorf("persist: JSON: archetype %v has no value for shared component %q", ja.Components, name)
+		}
+		v := reflect.New(def.Type)
+		if err := setJSONRaw(v.Elem(), raw); err != nil {
+			return fmt.Errorf("persist: JSON: archetype %v shared component %q: %w", ja.Components, name, err)
+		}
+		composition = composition.WithShared(def.ID, catalog.InternShared(def, v.UnsafePointer()))
+	}
+	for name := range ja.Shared {
+		if def, ok := byName[name]; !ok || !def.Shared || !composition.Mask.IsSet(def.ID) {
+			return fmt.Errorf("persist: JSON: archetype %v has a shared value for %q, which is not one of its shared components", ja.Components, name)
+		}
+	}
+	runs, err := jsonRuns(ja, byName, composition)
+	if err != nil {
+		return err
+	}
+	archID := catalog.Upsert(composition)
+	table := &catalog.Archetypes[archID].Table
+
+	batches := reserveBatches(table, len(ja.Entities))
+	offset := 0
+	for _, b := range batches {
+		for i := range b.n {
+			e := ja.Entities[offset+i]
+			if seen[e.ID] {
+				return fmt.Errorf("persist: JSON: entity %v listed twice", e.ID)
+			}
+			seen[e.ID] = true
+			slot := b.start + colstore.Slot(i)
+			table.SetEntityRange(b.ptr, slot, []uid.UID64{e.ID})
+			if !book.RestoreKnown(e.ID, archID, b.ptr, slot) {
+				return fmt.Errorf("persist: JSON: entity %v not recognized by the pool state", e.ID)
+			}
+			for _, def := range composition.Defs {
+				reflect.NewAt(def.Type, table.ComponentAt(b.ptr, slot, def.ID)).Elem().SetZero()
+			}
+			for name, raw := range e.Components {
+				def, ok := byName[name]
+				if !ok || !composition.Mask.IsSet(def.ID) {
+					return fmt.Errorf("persist: JSON: entity %v has component %q, which its archetype %v lacks", e.ID, name, ja.Components)
+				}
+				if def.Size == 0 {
+					return fmt.Errorf("persist: JSON: entity %v: component %q is a tag and carries no value", e.ID, name)
+				}
+				if def.Shared {
+					return fmt.Errorf("persist: JSON: entity %v: component %q is shared — its value belongs in the archetype's \"shared\" object", e.ID, name)
+				}
+				if def.PerChunk {
+					return fmt.Errorf("persist: JSON: entity %v: component %q is per-chunk — its value belongs in the archetype's \"chunks\" list", e.ID, name)
+				}
+				ptr := table.ComponentAt(b.ptr, slot, def.ID)
+				if err := setJSONRaw(reflect.NewAt(def.Type, ptr).Elem(), raw); err != nil {
+					return fmt.Errorf("persist: JSON: entity %v component %q: %w", e.ID, name, err)
+				}
+			}
+		}
+		offset += b.n
+	}
+
+	for _, def := range composition.Chunk {
+		values := reflect.MakeSlice(reflect.SliceOf(def.Type), len(runs), len(runs))
+		for i, jc := range ja.Chunks {
+			if raw, ok := jc.Values[def.Key]; ok {
+				if err := setJSONRaw(values.Index(i), raw); err != nil {
+					return fmt.Errorf("persist: JSON: archetype %v per-chunk component %q: %w", ja.Components, def.Key, err)
+				}
+			}
+		}
+		spreadRuns(runs, batches, func(run int, b slotBatch) {
+			reflect.NewAt(def.Type, table.ChunkValueAt(b.ptr, def.ID)).Elem().Set(values.Index(run))
+		})
+	}
+	return nil
+}
+
+// jsonRuns checks ja's chunks against its entities and composition, and
+// returns their entity counts.
+func jsonRuns(ja jsonArchetype, byName map[string]comp.Def, composition comp.Composition) ([]uint32, error) {
+	runs := make([]uint32, len(ja.Chunks))
+	total := 0
+	for i, jc := range ja.Chunks {
+		if jc.Entities <= 0 {
+			return nil, fmt.Errorf("persist: JSON: archetype %v has a chunk of %d entities", ja.Components, jc.Entities)
+		}
+		for name := range jc.Values {
+			if def, ok := byName[name]; !ok || !def.PerChunk || !composition.Mask.IsSet(def.ID) {
+				return nil, fmt.Errorf("persist: JSON: archetype %v has a chunk value for %q, which is not one of its per-chunk components", ja.Components, name)
+			}
+		}
+		runs[i] = uint32(jc.Entities)
+		total += jc.Entities
+	}
+	if len(ja.Chunks) > 0 && total != len(ja.Entities) {
+		return nil, fmt.Errorf("persist: JSON: archetype %v lists chunks of %d entities for %d entities", ja.Components, total, len(ja.Entities))
+	}
+	return runs, nil
+}
+
+// importSparse fills js's component's set; every entity it names must
+// already be imported, as seen records.
+func importSparse(js jsonSparse, byName map[string]comp.Def, catalog *arch.Catalog, seen map[uid.UID64]bool) error {
+	def, ok := byName[js.Component]
+	if !ok {
+		return fmt.Errorf("persist: JSON: sparse component %q is not in the component list", js.Component)
+	}
+	if !def.Sparse {
+		return fmt.Errorf("persist: JSON: component %q is listed as sparse, but not registered with sparse storage", js.Component)
+	}
+	set := catalog.SparseSet(def)
+	for _, e := range js.Entities {
+		if !seen[e.ID] {
+			return fmt.Errorf("persist: JSON: sparse component %q names entity %v, which no archetype holds", js.Component, e.ID)
+		}
+		if set.Has(e.ID) {
+			return fmt.Errorf("persist: JSON: sparse component %q lists entity %v twice", js.Component, e.ID)
+		}
+		ptr := set.Upsert(e.ID)
+		if e.Value == nil {
+			continue
+		}
+		if def.Size == 0 {
+			return fmt.Errorf("persist: JSON: entity %v: component %q is a tag and carries no value", e.ID, js.Component)
+		}
+		if err := setJSONRaw(reflect.NewAt(def.Type, ptr).Elem(), e.Value); err != nil {
+			return fmt.Errorf("persist: JSON: entity %v component %q: %w", e.ID, js.Component, err)
+		}
+	}
+	return nil
+}
+
+// poolFromIDs builds the pool state that holds exactly the given entities'
+// IDs as allocated.
+func poolFromIDs(archetypes []jsonArchetype) *jsonPool {
+	p := &jsonPool{}
+	used := make(map[uint32]bool)
+	for _, ja := range archetypes {
+		for _, e := range ja.Entities {
+			idx, gen := e.ID.Unpack()
+			if idx >= p.NextIndex {
+				p.NextIndex = idx + 1
+				p.Generations = slices.Grow(p.Generations, int(p.NextIndex)-len(p.Generations))[:p.NextIndex]
+			}
+			p.Generations[idx] = gen
+			used[idx] = true
+		}
+	}
+	for idx := range p.NextIndex {
+		if !used[idx] {
+			p.Free = append(p.Free, idx)
+		}
+	}
+	return p
+}
+
+func (p *jsonPool) validate() error {
+	if uint32(len(p.Generations)) < p.NextIndex {
+		return fmt.Errorf("persist: JSON: pool has %d generations for nextIndex %d", len(p.Generations), p.NextIndex)
+	}
+	for _, idx := range p.Free {
+		if idx >= p.NextIndex {
+			return fmt.Errorf("persist: JSON: pool frees index %d, past nextIndex %d", idx, p.NextIndex)
+		}
+	}
+	return nil
+}
+
+// appendJSONValue appends v to dst as JSON, by the rule ExportJSON
+// documents.
+func appendJSONValue(dst []byte, v reflect.Value) ([]byte, error) {
+	if m, ok := asBinaryMarshaler(v); ok {
+		data, err := m.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendQuote(dst, base64.StdEncoding.EncodeToString(data)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s, err := json.Marshal(v.String())
+		return append(dst, s...), err
+	case reflect.Bool:
+		return strconv.AppendBool(dst, v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(dst, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.AppendUint(dst, v.Uint(), 10), nil
+	case reflect.Float32:
+		return appendJSONFloat(dst, v.Float(), 32), nil
+	case reflect.Float64:
+		return appendJSONFloat(dst, v.Float(), 64), nil
+	case reflect.Complex64, reflect.Complex128:
+		bits := 64
+		if v.Kind() == reflect.Complex64 {
+			bits = 32
+		}
+		c := v.Complex()
+		dst = append(dst, '[')
+		dst = appendJSONFloat(dst, real(c), bits)
+		dst = append(dst, ',')
+		dst = appendJSONFloat(dst, imag(c), bits)
+		return append(dst, ']'), nil
+	case reflect.Array:
+		dst = append(dst, '[')
+		for i := range v.Len() {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			var err error
+			if dst, err = appendJSONValue(dst, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, ']'), nil
+	case reflect.Struct:
+		dst = append(dst, '{')
+		for i := range v.NumField() {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = strconv.AppendQuote(dst, v.Type().Field(i).Name)
+			dst = append(dst, ':')
+			var err error
+			if dst, err = appendJSONValue(dst, v.Field(i)); err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, '}'), nil
+	default:
+		panic(fmt.Sprintf("persist: unencodable kind %s reached the JSON encoder — comp.ValidateEncodable should have rejected this type at RegComp", v.Kind()))
+	}
+}
+
+func appendJSONFloat(dst []byte, f float64, bits int) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(dst, `"NaN"`...)
+	case math.IsInf(f, 1):
+		return append(dst, `"+Inf"`...)
+	case math.IsInf(f, -1):
+		return append(dst, `"-Inf"`...)
+	}
+	return strconv.AppendFloat(dst, f, 'g', -1, bits)
+}
+
+// setJSONRaw decodes raw, numbers as json.Number, and stores it into v.
+func setJSONRaw(v reflect.Value, raw json.RawMessage) error {
+	var x any
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&x); err != nil {
+		return err
+	}
+	return setJSONValue(v, x)
+}
+
+// setJSONValue stores x — decoded JSON, numbers as json.Number — into v,
+// the inverse of appendJSONValue.
+func setJSONValue(v reflect.Value, x any) error {
+	if u, ok := asBinaryUnmarshaler(v); ok {
+		s, ok := x.(string)
+		if !ok {
+			return jsonTypeError(v, x)
+		}
+		data, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		return u.UnmarshalBinary(data)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := x.(string)
+		if !ok {
+			return jsonTypeError(v, x)
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, ok := x.(bool)
+		if !ok {
+			return jsonTypeError(v, x)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := x.(json.Number)
+		if !ok {
+			return jsonTypeError(v, x)
+		}
+		i, err := strconv.ParseInt(string(n), 10, 64)
+		if err != nil || v.OverflowInt(i) {
+			return fmt.Errorf("%s does not fit a %s", n, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := x.(json.Number)
+		if !ok {
+			return jsonTypeError(v, x)
+		}
+		u, err := strconv.ParseUint(string(n), 10, 64)
+		if err != nil || v.OverflowUint(u) {
+			return fmt.Errorf("%s does not fit a %s", n, v.Type())
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := jsonFloat(x, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Complex64, reflect.Complex128:
+		pair, ok := x.([]any)
+		if !ok || len(pair) != 2 {
+			return fmt.Errorf("want a [real, imag] pair for a %s, got %v", v.Type(), x)
+		}
+		bits := v.Type().Bits() / 2
+		re, err := jsonFloat(pair[0], bits)
+		if err != nil {
+			return err
+		}
+		im, err := jsonFloat(pair[1], bits)
+		if err != nil {
+			return err
+		}
+		v.SetComplex(complex(re, im))
+	case reflect.Array:
+		elems, ok := x.([]any)
+		if !ok || len(elems) != v.Len() {
+			return fmt.Errorf("want an array of %d elements for a %s, got %v", v.Len(), v.Type(), x)
+		}
+		for i, elem := range elems {
+			if err := setJSONValue(v.Index(i), elem); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+	case reflect.Struct:
+		fields, ok := x.(map[string]any)
+		if !ok {
+			return jsonTypeError(v, x)
+		}
+		for name, fx := range fields {
+			f, ok := v.Type().FieldByName(name)
+			if !ok || len(f.Index) != 1 {
+				return fmt.Errorf("%s has no field %q", v.Type(), name)
+			}
+			if err := setJSONValue(v.Field(f.Index[0]), fx); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	default:
+		panic(fmt.Sprintf("persist: unencodable kind %s reached the JSON decoder — comp.ValidateEncodable should have rejected this type at RegComp", v.Kind()))
+	}
+	return nil
+}
+
+// jsonFloat reads a float written by appendJSONFloat.
+func jsonFloat(x any, bits int) (float64, error) {
+	switch x := x.(type) {
+	case json.Number:
+		return strconv.ParseFloat(string(x), bits)
+	case string:
+		switch x {
+		case "NaN":
+			return math.NaN(), nil
+		case "+Inf":
+			return math.Inf(1), nil
+		case "-Inf":
+			return math.Inf(-1), nil
+		}
+	}
+	return 0, fmt.Errorf("want a number, \"NaN\", \"+Inf\" or \"-Inf\", got %v", x)
+}
+
+func jsonTypeError(v reflect.Value, x any) error {
+	if x == nil {
+		return errors.New("null is not a valid " + v.Type().String())
+	}
+	return fmt.Errorf("want a %s, got JSON %T %v", v.Type(), x, x)
+}
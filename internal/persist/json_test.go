@@ -0,0 +1,158 @@
+package persist_test
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/persist"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// kitchenSink holds one field of every kind the JSON rule spells out.
+type kitchenSink struct {
+	I8     int8
+	I      int
+	U64    uint64
+	F32    float32
+	F64    float64
+	C64    complex64
+	C128   complex128
+	B      bool
+	S      string
+	A      [2]int16
+	Stamp  stamp
+	Nested Position
+}
+
+func exportJSON(t *testing.T, di *comp.DefIndex, w *filterWorld) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := persist.ExportJSON(&buf, di, &w.m.AddressBook, &w.m.ArchCatalog); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func importJSON(doc string) (*filterWorld, error) {
+	w := &filterWorld{m: newTestManager()}
+	w.di.Init()
+	requests := append(w.requests(&w.di), req[kitchenSink](&w.di))
+	return w, persist.ImportJSON(strings.NewReader(doc), &w.di, &w.m.AddressBook, &w.m.ArchCatalog, requests)
+}
+
+func TestExportJSON_ImportJSON_RoundTrip(t *testing.T) {
+	w := newFilterWorld(t)
+	var sink iter.ArrayRef[kitchenSink]
+	var spec comp.AccessSpec
+	spec.Init(&w.di, comp.Track(&sink), comp.Include[Tag]())
+	f := w.m.CreateFactory(spec)
+	f.Create(3)
+	for f.Next() {
+		values := sink.Slice(&f.Cursor)
+		for i := range values {
+			values[i] = kitchenSink{
+				I8: -8, I: -1 << 40, U64: math.MaxUint64, F32: 0.1, F64: float64(i) / 3,
+				C64: complex(1, -2), C128: complex(math.Inf(1), math.NaN()), B: true,
+				S: "quote \" and ✓", A: [2]int16{-1, 2}, Stamp: stamp{V: int64(i)}, Nested: Position{X: 1.5},
+			}
+		}
+	}
+	w.m.Remove(w.plain[0])
+
+	want := exportJSON(t, &w.di, w)
+	for _, s := range []string{`"NaN"`, `"+Inf"`, `"I8": -8`, `"U64": 18446744073709551615`} {
+		if !bytes.Contains(want, []byte(s)) {
+			t.Errorf("expected the export to contain %s", s)
+		}
+	}
+
+	got, err := importJSON(string(want))
+	if err != nil {
+		t.Fatalf("ImportJSON: %v", err)
+	}
+	if again := exportJSON(t, &got.di, got); !bytes.Equal(again, want) {
+		t.Errorf("re-export differs from the original export:\n%s\nwant:\n%s", again, want)
+	}
+	if loaded(t, got.m, "removed", w.plain[:1]) || !loaded(t, got.m, "plain", w.plain[1:]) {
+		t.Error("expected exactly the entities alive at export to be imported")
+	}
+	assertIDsReleased(t, got.m)
+}
+
+func TestImportJSON_HandAuthoredFixture(t *testing.T) {
+	pos := comp.TypeKey(reflect.TypeFor[Position]())
+	tag := comp.TypeKey(reflect.TypeFor[Tag]())
+	doc := `{
+		"format": "goke-world", "version": 1,
+		"components": [{"name": "` + pos + `", "size": 8}, {"name": "` + tag + `", "size": 0}],
+		"archetypes": [{
+			"components": ["` + pos + `", "` + tag + `"],
+			"entities": [
+				{"id": 4, "components": {"` + pos + `": {"X": 2}}},
+				{"id": 1}
+			]
+		}]
+	}`
+	w, err := importJSON(doc)
+	if err != nil {
+		t.Fatalf("ImportJSON: %v", err)
+	}
+	def, _ := w.di.ByType(reflect.TypeFor[Position]())
+	if p, ok := componentAt[Position](w.m, 4, def.ID); !ok || p != (Position{X: 2}) {
+		t.Errorf("entity 4: Position = %+v, %v; want {X:2}", p, ok)
+	}
+	if p, ok := componentAt[Position](w.m, 1, def.ID); !ok || p != (Position{}) {
+		t.Errorf("entity 1: Position = %+v, %v; want the zero value", p, ok)
+	}
+	assertIDsReleased(t, w.m)
+
+	// The pool is rebuilt around the fixture's IDs: new entities take the
+	// free indices in between, never an imported one.
+	var p iter.ArrayRef[Position]
+	var spec comp.AccessSpec
+	spec.Init(&w.di, comp.Track(&p))
+	f := w.m.CreateFactory(spec)
+	f.Create(5)
+	for f.Next() {
+		for _, id := range f.IDs {
+			if id == 1 || id == 4 {
+				t.Errorf("new entity reuses imported ID %v", id)
+			}
+		}
+	}
+}
+
+func TestImportJSON_RejectsMalformedDocuments(t *testing.T) {
+	pos := comp.TypeKey(reflect.TypeFor[Position]())
+	tag := comp.TypeKey(reflect.TypeFor[Tag]())
+	head := `{"format": "goke-world", "version": 1, "components": [{"name": "` + pos + `", "size": 8}, {"name": "` + tag + `", "size": 0}], "archetypes": [`
+	entity := func(body string) string {
+		return head + `{"components": ["` + pos + `", "` + tag + `"], "entities": [` + body + `]}]}`
+	}
+	tests := []struct {
+		name, doc, want string
+	}{
+		{"wrong format", `{"format": "other", "version": 1}`, "not a goke-world"},
+		{"unknown key", `{"format": "goke-world", "version": 1, "extra": 1}`, "extra"},
+		{"trailing data", head + `]} {}`, "after the document"},
+		{"unknown field", entity(`{"id": 0, "components": {"` + pos + `": {"Z": 1}}}`), `no field "Z"`},
+		{"wrong type", entity(`{"id": 0, "components": {"` + pos + `": {"X": "one"}}}`), "want a number"},
+		{"tag value", entity(`{"id": 0, "components": {"` + tag + `": {}}}`), "is a tag"},
+		{"duplicate ID", entity(`{"id": 3}, {"id": 3}`), "listed twice"},
+		{"foreign component", head + `{"components": ["` + tag + `"], "entities": [{"id": 0, "components": {"` + pos + `": {}}}]}]}`, "lacks"},
+		{"unlisted component", head + `{"components": ["nope"], "entities": []}]}`, `"nope"`},
+		{"short pool", head + `], "pool": {"nextIndex": 3, "generations": [0], "free": []}}`, "generations"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importJSON(tt.doc)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package persist
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"unsafe"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/addr"
+	"github.com/kjkrol/goke/v3/internal/arch"
+	"github.com/kjkrol/goke/v3/internal/colstore"
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+var uidType = reflect.TypeFor[uid.UID64]()
+
+// ValidateRefs returns an error naming the first field of t tagged
+// `goke:"ref"` (see [comp.RefFields]) that is not a uid.UID64.
+func ValidateRefs(t reflect.Type) error {
+	for _, f := range comp.RefFields(t) {
+		if f.Type != uidType {
+			return fmt.Errorf("field %s is tagged %s:%q but is a %s, not a uid.UID64", f.Path, comp.RefTagKey, comp.RefTagValue, f.Type)
+		}
+	}
+	return nil
+}
+
+// LoadMerge reads a full snapshot written by Save into a world that may
+// already hold entities and components of its own, and returns the
+// snapshot's IDs mapped to the ones its entities were given. Unlike [Load],
+// it leaves the ID pool's history alone: every entity gets a fresh ID from
+// book. Components are matched by key via comps, as in Load, and may
+// already be registered.
+//
+// Once every entity is in, each uid.UID64 field tagged `goke:"ref"` — in a
+// sparse or per-chunk component too — that holds one of the snapshot's IDs
+// is rewritten to its new one, so links between the snapshot's entities
+// survive; an ID from outside the snapshot is kept as is. Shared
+// components cannot hold references (see [comp.DefIndex.InternWith]). On
+// error, the entities merged so far stay in the world.
+func LoadMerge(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, comps []CompRequest) (map[uid.UID64]uid.UID64, error) {
+	var remap map[uid.UID64]uid.UID64
+	err := readGzip(r, func(gr io.Reader) error {
+		var err error
+		remap, err = loadMergeFrom(gr, defIndex, book, catalog, comps)
+		return err
+	})
+	return remap, err
+}
+
+func loadMergeFrom(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, comps []CompRequest) (map[uid.UID64]uid.UID64, error) {
+	fh, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if fh.Kind == kindDelta {
+		return nil, fmt.Errorf("persist: this is a delta save — only a full snapshot can be merged")
+	}
+	sr := newSectionReader(r, fh.Version)
+	if _, err := sr.poolState(); err != nil {
+		return nil, err
+	}
+	headers, _, err := sr.componentDirectory()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := registerComponentKeys(headers, comps, fh.Version)
+	if err != nil {
+		return nil, err
+	}
+	fileToLocal := make([]comp.ID, len(keys))
+	for i, key := range keys {
+		def, ok := defIndex.ByKey(key)
+		if !ok {
+			return nil, fmt.Errorf("persist: component %q did not register under its key", key)
+		}
+		if err := ValidateRefs(def.Type); err != nil {
+			return nil, fmt.Errorf("persist: component %q: %w", key, err)
+		}
+		fileToLocal[i] = def.ID
+	}
+
+	archHeaders, err := sr.archDirectory()
+	if err != nil {
+		return nil, err
+	}
+	if err := translateCompIDs(archHeaders, fileToLocal); err != nil {
+		return nil, err
+	}
+
+	remap := make(map[uid.UID64]uid.UID64)
+	var refs []unsafe.Pointer // reference fields of loaded rows, awaiting their rewrite
+	for _, ah := range archHeaders {
+		if err := mergeArchetype(sr, defIndex, book, catalog, ah, remap, &refs); err != nil {
+			return nil, err
+		}
+	}
+
+	if fh.Version >= formatVersionSparse {
+		sparse, err := loadSparse(sr, defIndex, catalog, fileToLocal, func(id uid.UID64) (uid.UID64, bool) {
+			id, ok := remap[id]
+			return id, ok
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+		// Taken once every set is filled: an Upsert may move a set's values.
+		for _, sh := range sparse {
+			def := defIndex.ByID(comp.ID(sh.CompID))
+			refFields := comp.RefFields(def.Type)
+			if len(refFields) == 0 {
+				continue
+			}
+			set := catalog.SparseSet(def)
+			for _, id := range remap {
+				if ptr := set.Get(id); ptr != nil {
+					for _, f := range refFields {
+						refs = append(refs, unsafe.Add(ptr, f.Offset))
+					}
+				}
+			}
+		}
+	}
+
+	for _, ptr := range refs {
+		ref := (*uid.UID64)(ptr)
+		if id, ok := remap[*ref]; ok {
+			*ref = id
+		}
+	}
+	return remap, nil
+}
+
+// mergeArchetype is loadArchetype under fresh IDs: it records each row's
+// old→new ID in remap and each reference field's address in refs.
+func mergeArchetype(sr sectionReader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, ah archHeader, remap map[uid.UID64]uid.UID64, refs *[]unsafe.Pointer) error {
+	composition, err := compositionOf(defIndex, catalog, ah)
+	if err != nil {
+		return err
+	}
+	archID := catalog.Upsert(composition)
+	table := &catalog.Archetypes[archID].Table
+	at := SectionError{Section: sectionArchetypeData, Archetype: archetypeName(componentKeys(defIndex), ah)}
+
+	oldIDs := make([]uid.UID64, ah.EntityCount)
+	if err := sr.read(at, func(r io.Reader) error { return readIDsInto(r, oldIDs) }); err != nil {
+		return err
+	}
+	for _, id := range oldIDs {
+		if _, dup := remap[id]; dup {
+			return fmt.Errorf("persist: corrupt save file: entity %v listed twice", id)
+		}
+	}
+
+	batches := reserveBatches(table, int(ah.EntityCount))
+	offset := 0
+	for _, b := range batches {
+		newIDs := make([]uid.UID64, b.n)
+		book.Seed(newIDs, archID, b.ptr, b.start)
+		table.SetEntityRange(b.ptr, b.start, newIDs)
+		for i, id := range newIDs {
+			remap[oldIDs[offset+i]] = id
+		}
+		offset += b.n
+	}
+
+	for _, def := range composition.Defs {
+		at.Component = def.Key
+		refFields := comp.RefFields(def.Type)
+		if err := sr.read(at, func(r io.Reader) error {
+			for _, b := range batches {
+				for i := range b.n {
+					ptr := table.ComponentAt(b.ptr, b.start+colstore.Slot(i), def.ID)
+					if err := DecodeValue(r, def.Type, ptr); err != nil {
+						return err
+					}
+					for _, f := range refFields {
+						*refs = append(*refs, unsafe.Add(ptr, f.Offset))
+					}
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if err := loadChunkValues(sr, at, table, composition.Chunk, ah.Runs, batches); err != nil {
+		return err
+	}
+	// A chunk's value is rewritten once, however many batches reach it.
+	for _, def := range composition.Chunk {
+		for _, f := range comp.RefFields(def.Type) {
+			var last unsafe.Pointer
+			for _, b := range batches {
+				if b.ptr != last {
+					*refs = append(*refs, unsafe.Add(table.ChunkValueAt(b.ptr, def.ID), f.Offset))
+					last = b.ptr
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package persist_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/ent"
+	"github.com/kjkrol/goke/v3/internal/persist"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// chainLink points at the next entity of a chain, and at an anchor that
+// lives outside the saved snapshot.
+type chainLink struct {
+	Next   uid.UID64 `goke:"ref"`
+	Anchor uid.UID64 `goke:"ref"`
+	Hops   uid.UID64 // not a reference — never rewritten
+}
+
+type badRef struct {
+	Target uint64 `goke:"ref"`
+}
+
+func linkAt(m *ent.Manager, di *comp.DefIndex, id uid.UID64) *chainLink {
+	entry, ok := m.AddressBook.Get(id)
+	if !ok {
+		return nil
+	}
+	def, _ := di.ByType(reflect.TypeFor[chainLink]())
+	return (*chainLink)(m.ArchCatalog.Archetypes[entry.ArchID].Table.ComponentAt(entry.ChunkPtr, entry.Slot, def.ID))
+}
+
+// spawnChain creates a ring of n entities, each chainLink.Next pointing at
+// the one after, all anchored to anchor.
+func spawnChain(di *comp.DefIndex, m *ent.Manager, n int, anchor uid.UID64) []uid.UID64 {
+	var linkCol iter.ArrayRef[chainLink]
+	var pos iter.ArrayRef[Position]
+	var spec comp.AccessSpec
+	spec.Init(di, comp.Track(&linkCol), comp.Track(&pos))
+	f := m.CreateFactory(spec)
+	f.Create(n)
+	var ids []uid.UID64
+	for f.Next() {
+		positions := pos.Slice(&f.Cursor)
+		for i := range positions {
+			positions[i] = Position{X: float32(len(ids) + i)}
+		}
+		ids = append(ids, f.IDs...)
+	}
+	for i, id := range ids {
+		l := linkAt(m, di, id)
+		l.Next, l.Anchor, l.Hops = ids[(i+1)%len(ids)], anchor, uid.UID64(i)
+	}
+	return ids
+}
+
+func TestLoadMerge_RemapsIDsAndReferences(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	const anchor = uid.UID64(0xdead)
+	saved := spawnChain(&di, m, 600, anchor)
+
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// A live world, with its own components (in a different order) and
+	// entities, some of which share IDs with the saved ones.
+	var di2 comp.DefIndex
+	di2.Init()
+	m2 := newTestManager()
+	di2.Intern(reflect.TypeFor[Velocity]())
+	existing := spawnChain(&di2, m2, 10, 0)
+
+	requests := []persist.CompRequest{req[Position](&di2), req[chainLink](&di2)}
+	remap, err := persist.LoadMerge(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, requests)
+	if err != nil {
+		t.Fatalf("LoadMerge: %v", err)
+	}
+	if len(remap) != len(saved) {
+		t.Fatalf("expected %d remapped IDs, got %d", len(saved), len(remap))
+	}
+
+	seen := make(map[uid.UID64]bool)
+	for _, id := range existing {
+		seen[id] = true
+	}
+	for i, old := range saved {
+		id, ok := remap[old]
+		if !ok {
+			t.Fatalf("saved entity %v: missing from the remap", old)
+		}
+		if seen[id] {
+			t.Fatalf("saved entity %v: new ID %v collides with another entity", old, id)
+		}
+		seen[id] = true
+
+		l := linkAt(m2, &di2, id)
+		if l == nil {
+			t.Fatalf("saved entity %v: not found under its new ID %v", old, id)
+		}
+		if wantNext := remap[saved[(i+1)%len(saved)]]; l.Next != wantNext {
+			t.Errorf("entity %v: Next = %v, want %v", id, l.Next, wantNext)
+		}
+		if l.Anchor != anchor {
+			t.Errorf("entity %v: Anchor = %v, want %v (outside the snapshot, kept)", id, l.Anchor, anchor)
+		}
+		if l.Hops != uid.UID64(i) {
+			t.Errorf("entity %v: untagged Hops = %v, want %v", id, l.Hops, i)
+		}
+	}
+
+	for i, id := range existing {
+		l := linkAt(m2, &di2, id)
+		if l == nil || l.Hops != uid.UID64(i) {
+			t.Errorf("existing entity %v: disturbed by the merge: %+v", id, l)
+		}
+	}
+}
+
+func TestLoadMerge_RejectsDelta(t *testing.T) {
+	w := newDeltaWorld(t, 10)
+	if err := persist.Save(&bytes.Buffer{}, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &w.base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	delta := w.saveDelta(t)
+
+	var di2 comp.DefIndex
+	di2.Init()
+	m2 := newTestManager()
+	if _, err := persist.LoadMerge(bytes.NewReader(delta), &di2, &m2.AddressBook, &m2.ArchCatalog, w.requests(&di2)); err == nil {
+		t.Error("expected LoadMerge to reject a delta save")
+	}
+}
+
+func TestLoadMerge_UnregisteredKey_ReturnsError(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	spawnChain(&di, m, 3, 0)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var di2 comp.DefIndex
+	di2.Init()
+	m2 := newTestManager()
+	requests := []persist.CompRequest{req[Position](&di2), req[chainLink](&di2)}
+	requests[1].Register = func(*uint32) error { return nil } // matches, but registers nothing
+	if _, err := persist.LoadMerge(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, requests); err == nil {
+		t.Error("expected an error for a component that did not register")
+	}
+}
+
+func TestValidateRefs(t *testing.T) {
+	if err := persist.ValidateRefs(reflect.TypeFor[chainLink]()); err != nil {
+		t.Errorf("chainLink: unexpected error %v", err)
+	}
+	err := persist.ValidateRefs(reflect.TypeFor[badRef]())
+	if err == nil || !strings.Contains(err.Error(), "Target") {
+		t.Errorf("badRef: expected an error naming Target, got %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package persist
 
 import (
+	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
+	"reflect"
+	"slices"
 	"unsafe"
 
 	"github.com/kjkrol/uid"
@@ -16,15 +19,75 @@ import (
 
 // Load reads a snapshot written by Save, registering components via comps
 // (see [CompRequest]) and repopulating catalog/book with its archetypes and
-// entities.
-func Load(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, comps []CompRequest) error {
+// entities. If base is non-nil, it is reset to the loaded snapshot, for a
+// following [LoadDelta] or [SaveDelta]. Chunks are not rebuilt as saved:
+// each chunk the loaded entities fill takes the per-chunk component values
+// of the last saved chunk whose entities it received.
+func Load(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, comps []CompRequest, base *Baseline) error {
+	return readGzip(r, func(gr io.Reader) error {
+		return loadFrom(gr, defIndex, book, catalog, comps, base, nil)
+	})
+}
+
+// LoadOnly is Load restricted to the archetypes the filter built from opts
+// keeps — resolved once comps are registered, so the world's component IDs
+// still follow the file. IDs of the entities skipped are released. Unless
+// opts select everything, the world does not hold the whole file, so base
+// is only reset.
+func LoadOnly(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, comps []CompRequest, base *Baseline, opts []comp.AccessOpt) error {
+	return readGzip(r, func(gr io.Reader) error {
+		return loadFrom(gr, defIndex, book, catalog, comps, base, opts)
+	})
+}
+
+// LoadDelta applies a delta written by SaveDelta on top of the world, which
+// must hold exactly the delta's parent snapshot — base's, as left by the
+// Load or LoadDelta that produced it; base then moves on to the delta.
+// Every component the delta names must already be registered.
+//
+// The delta is read whole and checked, as by [Verify], before anything is
+// applied — it rewrites the world in place, so a corrupt section found
+// halfway would leave it part-updated. So are its components against their
+// registration. Only a value that fails to decode despite an intact
+// checksum can still stop it partway.
+func LoadDelta(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, base *Baseline) error {
+	var payload []byte
+	if err := readGzip(r, func(gr io.Reader) error {
+		var err error
+		payload, err = io.ReadAll(gr)
+		return err
+	}); err != nil {
+		return err
+	}
+	pr := bytes.NewReader(payload)
+	info, err := inspectFrom(pr)
+	if err != nil {
+		return err
+	}
+	if pr.Len() > 0 {
+		return fmt.Errorf("persist: unexpected trailing data after save file payload")
+	}
+	// loadDeltaFrom checks the archetypes before unlinking anything, but
+	// reaches the sparse directory last.
+	for _, sp := range info.Sparse {
+		key := info.Components[sp.Component].Name
+		if def, ok := defIndex.ByKey(key); ok && !def.Sparse {
+			return fmt.Errorf("persist: component %q is sparse in the save file, but not registered with sparse storage", def.Key)
+		}
+	}
+	return loadDeltaFrom(bytes.NewReader(payload), defIndex, book, catalog, base)
+}
+
+// readGzip runs load over r's decompressed stream, then checks that the
+// stream ends there.
+func readGzip(r io.Reader, load func(io.Reader) error) error {
 	gr, err := gzip.NewReader(r)
 	if err != nil {
 		return fmt.Errorf("persist: %w", err)
 	}
 	defer gr.Close()
 
-	if err := loadFrom(gr, defIndex, book, catalog, comps); err != nil {
+	if err := load(gr); err != nil {
 		return err
 	}
 
@@ -42,69 +105,242 @@ func Load(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.C
 	return nil
 }
 
-func loadFrom(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, comps []CompRequest) error {
-	if err := readHeader(r); err != nil {
+// loadFrom loads the snapshot in r — only the archetypes the filter built
+// from only keeps.
+func loadFrom(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, comps []CompRequest, base *Baseline, only []comp.AccessOpt) error {
+	fh, err := readHeader(r)
+	if err != nil {
 		return err
 	}
+	if fh.Kind == kindDelta {
+		return fmt.Errorf("persist: this is a delta save — load its base snapshot first, then apply it as a delta")
+	}
 
-	nextIndex, err := readUint32(r)
+	sr := newSectionReader(r, fh.Version)
+	pool, err := sr.poolState()
 	if err != nil {
 		return err
 	}
-	generations, err := readUint32Slice(r)
+	headers, _, err := sr.componentDirectory()
 	if err != nil {
 		return err
 	}
-	freeIndices, err := readUint32Slice(r)
-	if err != nil {
+
+	if err := registerComponents(headers, comps, fh.Version); err != nil {
 		return err
 	}
+	filter := NewFilter(defIndex, only...)
+
+	book.RestorePoolState(pool.nextIndex, pool.generations, pool.freeIndices)
 
-	compCount, err := readUint32(r)
+	archHeaders, err := sr.archDirectory()
 	if err != nil {
 		return err
 	}
-	headers := make([]compHeader, compCount)
-	for i := range headers {
-		h, err := readComponentHeader(r)
-		if err != nil {
+	for _, ah := range archHeaders {
+		load := loadArchetype
+		if !filter.keeps(maskOf(ah)) {
+			load = skipArchetype
+		}
+		if err := load(sr, defIndex, book, catalog, ah, false); err != nil {
 			return err
 		}
-		headers[i] = h
 	}
+	if fh.Version >= formatVersionSparse {
+		if _, err := loadSparse(sr, defIndex, catalog, nil, alive(book), false); err != nil {
+			return err
+		}
+	}
+	releaseUnloaded(book)
 
-	if err := registerComponents(headers, comps); err != nil {
+	if base != nil {
+		base.Reset()
+		if fh.ID != 0 && filter.keepsAll() {
+			base.capture(fh.ID, book, catalog, nil)
+		}
+	}
+	return nil
+}
+
+func loadDeltaFrom(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, base *Baseline) error {
+	fh, err := readHeader(r)
+	if err != nil {
 		return err
 	}
+	if fh.Kind != kindDelta {
+		return fmt.Errorf("persist: not a delta save — load it as a full snapshot instead")
+	}
+	if base.ID() == 0 || fh.Parent != base.ID() {
+		return fmt.Errorf("persist: delta %016x applies on top of snapshot %016x, but the world holds %016x", fh.ID, fh.Parent, base.ID())
+	}
 
-	book.RestorePoolState(nextIndex, generations, freeIndices)
+	sr := newSectionReader(r, fh.Version)
+	pool, err := sr.poolState()
+	if err != nil {
+		return err
+	}
+	headers, _, err := sr.componentDirectory()
+	if err != nil {
+		return err
+	}
+	fileToLocal := make([]comp.ID, len(headers))
+	for i, h := range headers {
+		def, ok := defIndex.ByKey(h.Name)
+		if !ok {
+			return fmt.Errorf("persist: delta needs component %q, which is not registered", h.Name)
+		}
+		if uint32(def.Size) != h.Size {
+			return fmt.Errorf("persist: component %q size mismatch: delta has %d bytes, current type has %d", h.Name, h.Size, def.Size)
+		}
+		fileToLocal[i] = def.ID
+	}
 
-	archCount, err := readUint32(r)
+	removed, err := sr.removedIDs()
 	if err != nil {
 		return err
 	}
-	archHeaders := make([]archHeader, archCount)
-	for i := range archHeaders {
-		h, err := readArchHeader(r)
-		if err != nil {
+	archHeaders, err := sr.archDirectory()
+	if err != nil {
+		return err
+	}
+	if err := translateCompIDs(archHeaders, fileToLocal); err != nil {
+		return err
+	}
+	for _, ah := range archHeaders {
+		if err := checkComposition(defIndex, ah); err != nil {
 			return err
 		}
-		archHeaders[i] = h
 	}
 
+	for _, id := range removed {
+		unlink(book, catalog, id)
+	}
+
+	book.RestorePoolState(pool.nextIndex, pool.generations, pool.freeIndices)
+
 	for _, ah := range archHeaders {
-		if err := loadArchetype(r, defIndex, book, catalog, ah); err != nil {
+		if err := loadArchetype(sr, defIndex, book, catalog, ah, true); err != nil {
 			return err
 		}
 	}
+	if fh.Version >= formatVersionSparse {
+		if _, err := loadSparse(sr, defIndex, catalog, fileToLocal, alive(book), true); err != nil {
+			return err
+		}
+	}
+
+	base.capture(fh.ID, book, catalog, nil)
+	return nil
+}
+
+// unlink removes id's row from its table and clears its address, leaving
+// the ID pool alone — a delta restores the pool wholesale.
+func unlink(book *addr.Book, catalog *arch.Catalog, id uid.UID64) {
+	entry, ok := book.Get(id)
+	if !ok {
+		return
+	}
+	if swapped, moved := catalog.RemoveEntity(entry.ArchID, entry.ChunkPtr, entry.Slot); moved {
+		book.Move(swapped, entry.ArchID, entry.ChunkPtr, entry.Slot)
+	}
+	book.Index.Clear(id)
+}
+
+// translateCompIDs rewrites each archetype's composition, shared values and
+// per-chunk components from the file's component IDs to the world's, via
+// fileToLocal.
+func translateCompIDs(archHeaders []archHeader, fileToLocal []comp.ID) error {
+	translate := func(id *uint8) error {
+		if int(*id) >= len(fileToLocal) {
+			return fmt.Errorf("persist: corrupt save file: archetype names component %d of %d", *id, len(fileToLocal))
+		}
+		*id = uint8(fileToLocal[*id])
+		return nil
+	}
+	for i := range archHeaders {
+		ah := &archHeaders[i]
+		for j := range ah.CompIDs {
+			if err := translate(&ah.CompIDs[j]); err != nil {
+				return err
+			}
+		}
+		for j := range ah.Shared {
+			if err := translate(&ah.Shared[j].CompID); err != nil {
+				return err
+			}
+		}
+		for j := range ah.Chunked {
+			if err := translate(&ah.Chunked[j]); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-// registerComponents matches comps to headers by Name (not position — the
-// header order, driven by the file, dictates registration order) and calls
+type poolState struct {
+	nextIndex   uint32
+	generations []uint32
+	freeIndices []uint32
+}
+
+func readPoolState(r io.Reader) (poolState, error) {
+	var p poolState
+	var err error
+	if p.nextIndex, err = readUint32(r); err != nil {
+		return poolState{}, err
+	}
+	if p.generations, err = readUint32Slice(r); err != nil {
+		return poolState{}, err
+	}
+	if p.freeIndices, err = readUint32Slice(r); err != nil {
+		return poolState{}, err
+	}
+	return p, nil
+}
+
+func readComponentDirectory(r io.Reader) ([]compHeader, error) {
+	count, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	headers := make([]compHeader, count)
+	for i := range headers {
+		if headers[i], err = readComponentHeader(r); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+func readArchDirectory(r io.Reader, version uint32) ([]archHeader, error) {
+	count, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	headers := make([]archHeader, count)
+	for i := range headers {
+		if headers[i], err = readArchHeader(r, version); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// registerComponents matches comps to headers by Name or one of its Aliases
+// (not position — the header order, driven by the file, dictates
+// registration order) and calls
 // Register for each. Requests matching no header are registered afterward,
 // for forward compatibility with a save made before that type existed.
-func registerComponents(headers []compHeader, comps []CompRequest) error {
+// A version 1 file is matched by LegacyName instead (see [CompRequest]).
+func registerComponents(headers []compHeader, comps []CompRequest, version uint32) error {
+	_, err := registerComponentKeys(headers, comps, version)
+	return err
+}
+
+// registerComponentKeys is registerComponents, also returning the Name of
+// the request each header matched — the key it is registered under.
+func registerComponentKeys(headers []compHeader, comps []CompRequest, version uint32) ([]string, error) {
 	byName := make(map[string]CompRequest, len(comps))
 	for _, c := range comps {
 		if _, dup := byName[c.Name]; dup {
@@ -112,27 +348,67 @@ func registerComponents(headers []compHeader, comps []CompRequest) error {
 		}
 		byName[c.Name] = c
 	}
+	for _, c := range comps {
+		for _, alias := range c.Aliases {
+			if prev, dup := byName[alias]; dup && prev.Name != c.Name {
+				return nil, fmt.Errorf("persist: LoadComp alias %q of %q is already claimed by %q", alias, c.Name, prev.Name)
+			}
+			byName[alias] = c
+		}
+	}
+	if version == formatVersionLegacyNames {
+		var err error
+		if byName, err = byLegacyName(headers, comps); err != nil {
+			return nil, err
+		}
+	}
 
 	matched := make(map[string]bool, len(headers))
-	for _, h := range headers {
+	keys := make([]string, len(headers))
+	for i, h := range headers {
 		req, ok := byName[h.Name]
 		if !ok {
-			return fmt.Errorf("persist: save file needs component %q, but no matching LoadComp was provided", h.Name)
+			return nil, fmt.Errorf("persist: save file needs component %q, but no matching LoadComp was provided", h.Name)
+		}
+		if matched[req.Name] {
+			return nil, fmt.Errorf("persist: save file lists component %q twice (under its name or an alias)", req.Name)
 		}
 		size := h.Size
 		if err := req.Register(&size); err != nil {
-			return err
+			return nil, err
 		}
-		matched[h.Name] = true
+		matched[req.Name] = true
+		keys[i] = req.Name
 	}
 	for _, c := range comps {
 		if !matched[c.Name] {
 			if err := c.Register(nil); err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
-	return nil
+	return keys, nil
+}
+
+// byLegacyName indexes comps by LegacyName, for a version 1 file's
+// directory. Requests sharing a LegacyName are only an error if headers
+// actually needs that name — otherwise both are simply unmatched.
+func byLegacyName(headers []compHeader, comps []CompRequest) (map[string]CompRequest, error) {
+	byLegacy := make(map[string]CompRequest, len(comps))
+	ambiguous := make(map[string]string)
+	for _, c := range comps {
+		if prev, dup := byLegacy[c.LegacyName]; dup {
+			ambiguous[c.LegacyName] = fmt.Sprintf("%q and %q", prev.Name, c.Name)
+			continue
+		}
+		byLegacy[c.LegacyName] = c
+	}
+	for _, h := range headers {
+		if both, ok := ambiguous[h.Name]; ok {
+			return nil, fmt.Errorf("persist: version %d save file names component %q without its import path, which matches both %s", formatVersionLegacyNames, h.Name, both)
+		}
+	}
+	return byLegacy, nil
 }
 
 type slotBatch struct {
@@ -141,21 +417,30 @@ type slotBatch struct {
 	n     int
 }
 
-func loadArchetype(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, ah archHeader) error {
-	var composition comp.Composition
-	for _, id := range ah.CompIDs {
-		composition = composition.With(defIndex.ByID(comp.ID(id)))
+// loadArchetype reads ah's rows into its archetype. With replace set (a
+// delta), each row's entity is first unlinked from wherever the world
+// currently holds it.
+func loadArchetype(sr sectionReader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, ah archHeader, replace bool) error {
+	composition, err := compositionOf(defIndex, catalog, ah)
+	if err != nil {
+		return err
 	}
 	archID := catalog.Upsert(composition)
 	table := &catalog.Archetypes[archID].Table
 	defs := composition.Defs
-
-	batches := reserveBatches(table, int(ah.EntityCount))
+	at := SectionError{Section: sectionArchetypeData, Archetype: archetypeName(componentKeys(defIndex), ah)}
 
 	ids := make([]uid.UID64, ah.EntityCount)
-	if err := readIDsInto(r, ids); err != nil {
+	if err := sr.read(at, func(r io.Reader) error { return readIDsInto(r, ids) }); err != nil {
 		return err
 	}
+	if replace {
+		for _, id := range ids {
+			unlink(book, catalog, id)
+		}
+	}
+
+	batches := reserveBatches(table, int(ah.EntityCount))
 	offset := 0
 	for _, b := range batches {
 		table.SetEntityRange(b.ptr, b.start, ids[offset:offset+b.n])
@@ -169,18 +454,250 @@ func loadArchetype(r io.Reader, defIndex *comp.DefIndex, book *addr.Book, catalo
 	}
 
 	for _, def := range defs {
-		for _, b := range batches {
-			for i := range b.n {
-				ptr := table.ComponentAt(b.ptr, b.start+colstore.Slot(i), def.ID)
+		at.Component = def.Key
+		if err := sr.read(at, func(r io.Reader) error {
+			for _, b := range batches {
+				for i := range b.n {
+					ptr := table.ComponentAt(b.ptr, b.start+colstore.Slot(i), def.ID)
+					if err := DecodeValue(r, def.Type, ptr); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return loadChunkValues(sr, at, table, composition.Chunk, ah.Runs, batches)
+}
+
+// loadChunkValues reads the per-chunk value sections of an archetype whose
+// rows went to batches, one value per saved chunk — a run of rows — into
+// the chunks those rows now fill. A chunk filled from several runs takes
+// the last one's value. defs are in the order the file holds them.
+func loadChunkValues(sr sectionReader, at SectionError, table *colstore.Table, defs []comp.Def, runs []uint32, batches []slotBatch) error {
+	for _, def := range defs {
+		at.Component = def.Key
+		values := reflect.MakeSlice(reflect.SliceOf(def.Type), len(runs), len(runs))
+		if err := sr.read(at, func(r io.Reader) error {
+			for i := range runs {
+				if err := DecodeValue(r, def.Type, values.Index(i).Addr().UnsafePointer()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		spreadRuns(runs, batches, func(run int, b slotBatch) {
+			reflect.NewAt(def.Type, table.ChunkValueAt(b.ptr, def.ID)).Elem().Set(values.Index(run))
+		})
+	}
+	return nil
+}
+
+// spreadRuns calls set, in order, for each batch that each run of rows
+// reaches — runs and batches both cutting the same rows into consecutive
+// spans.
+func spreadRuns(runs []uint32, batches []slotBatch, set func(run int, b slotBatch)) {
+	bi, start := 0, 0 // the first batch not wholly before the run, and its first row
+	var end int       // the run's end row
+	for run, n := range runs {
+		end += int(n)
+		for bi < len(batches) && start < end {
+			set(run, batches[bi])
+			if start+batches[bi].n > end {
+				break // the batch goes on into the next run
+			}
+			start += batches[bi].n
+			bi++
+		}
+	}
+}
+
+// skipArchetype reads past ah's rows, leaving the world untouched; its
+// signature matches loadArchetype's.
+func skipArchetype(sr sectionReader, defIndex *comp.DefIndex, _ *addr.Book, _ *arch.Catalog, ah archHeader, _ bool) error {
+	at := SectionError{Section: sectionArchetypeData, Archetype: archetypeName(componentKeys(defIndex), ah)}
+	ids := make([]uid.UID64, ah.EntityCount)
+	if err := sr.read(at, func(r io.Reader) error { return readIDsInto(r, ids) }); err != nil {
+		return err
+	}
+	skip := func(def comp.Def, count int) error {
+		at.Component = def.Key
+		scratch := reflect.New(def.Type).UnsafePointer()
+		return sr.read(at, func(r io.Reader) error {
+			for range count {
+				if err := DecodeValue(r, def.Type, scratch); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	for _, def := range columnsOf(defIndex, ah) {
+		if err := skip(def, int(ah.EntityCount)); err != nil {
+			return err
+		}
+	}
+	for _, id := range ah.Chunked {
+		if err := skip(defIndex.ByID(comp.ID(id)), len(ah.Runs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadSparse reads the sparse component sections into their sets, each
+// listed entity's value going to the entity target maps it to — read past
+// if there is none. fileToLocal translates the file's component IDs, or
+// is nil if they are the world's. With replace set (a delta, which holds
+// every sparse component whole), each listed set is emptied first. It
+// returns the directory, in the world's component IDs.
+func loadSparse(sr sectionReader, defIndex *comp.DefIndex, catalog *arch.Catalog, fileToLocal []comp.ID, target func(uid.UID64) (uid.UID64, bool), replace bool) ([]sparseHeader, error) {
+	headers, err := sr.sparseDirectory()
+	if err != nil {
+		return nil, err
+	}
+	for i, sh := range headers {
+		count := defIndex.Count()
+		if fileToLocal != nil {
+			count = len(fileToLocal)
+		}
+		if int(sh.CompID) >= count {
+			return nil, fmt.Errorf("persist: corrupt save file: sparse directory names component %d of %d", sh.CompID, count)
+		}
+		id := comp.ID(sh.CompID)
+		if fileToLocal != nil {
+			id = fileToLocal[id]
+		}
+		def := defIndex.ByID(id)
+		if !def.Sparse {
+			return nil, fmt.Errorf("persist: component %q is sparse in the save file, but not registered with sparse storage", def.Key)
+		}
+		headers[i].CompID = uint8(id)
+
+		set := catalog.SparseSet(def)
+		if replace {
+			set.Clear()
+		}
+		at := SectionError{Section: sectionSparseData, Component: def.Key}
+		ids := make([]uid.UID64, sh.EntityCount)
+		if err := sr.read(at, func(r io.Reader) error { return readIDsInto(r, ids) }); err != nil {
+			return nil, err
+		}
+		if def.Size == 0 {
+			for _, fileID := range ids {
+				if id, ok := target(fileID); ok {
+					set.Upsert(id)
+				}
+			}
+			continue
+		}
+		scratch := reflect.New(def.Type).UnsafePointer()
+		if err := sr.read(at, func(r io.Reader) error {
+			for _, fileID := range ids {
+				ptr := scratch
+				if id, ok := target(fileID); ok {
+					ptr = set.Upsert(id)
+				}
 				if err := DecodeValue(r, def.Type, ptr); err != nil {
 					return err
 				}
 			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// alive is loadSparse's target for a load keeping the file's entity IDs:
+// each ID maps to itself if book holds it.
+func alive(book *addr.Book) func(uid.UID64) (uid.UID64, bool) {
+	return func(id uid.UID64) (uid.UID64, bool) {
+		_, ok := book.Get(id)
+		return id, ok
+	}
+}
+
+// compositionOf resolves ah's component IDs against defIndex, decoding its
+// shared values and interning them in catalog; see checkComposition.
+func compositionOf(defIndex *comp.DefIndex, catalog *arch.Catalog, ah archHeader) (comp.Composition, error) {
+	if err := checkComposition(defIndex, ah); err != nil {
+		return comp.Composition{}, err
+	}
+	values := make(map[uint8][]byte, len(ah.Shared))
+	for _, s := range ah.Shared {
+		values[s.CompID] = s.Value
+	}
+	var composition comp.Composition
+	for _, id := range ah.CompIDs {
+		def := defIndex.ByID(comp.ID(id))
+		if !def.Shared {
+			composition = composition.With(def)
+			continue
+		}
+		value := values[id]
+		ptr := reflect.New(def.Type).UnsafePointer()
+		if err := DecodeValue(bytes.NewReader(value), def.Type, ptr); err != nil {
+			return comp.Composition{}, fmt.Errorf("persist: archetype %s, shared component %q: %w", archetypeName(componentKeys(defIndex), ah), def.Key, err)
+		}
+		composition = composition.WithShared(def.ID, catalog.InternShared(def, ptr))
+	}
+	return composition, nil
+}
+
+// checkComposition checks that the file and the world agree on which of
+// ah's components are shared and which per-chunk, and that every shared
+// one has a value — what compositionOf needs, without interning anything.
+func checkComposition(defIndex *comp.DefIndex, ah archHeader) error {
+	for _, s := range ah.Shared {
+		if def := defIndex.ByID(comp.ID(s.CompID)); !def.Shared {
+			return fmt.Errorf("persist: component %q is shared in the save file, but not registered as shared", def.Key)
+		}
+	}
+	for _, id := range ah.Chunked {
+		if def := defIndex.ByID(comp.ID(id)); !def.PerChunk || !slices.Contains(ah.CompIDs, id) {
+			return fmt.Errorf("persist: component %q is per-chunk in the save file, but not registered as per-chunk", def.Key)
+		}
+	}
+	for _, id := range ah.CompIDs {
+		def := defIndex.ByID(comp.ID(id))
+		if def.PerChunk && !slices.Contains(ah.Chunked, id) {
+			return fmt.Errorf("persist: component %q is registered as per-chunk, but has no per-chunk values in the save file", def.Key)
+		}
+		if def.Shared && !slices.ContainsFunc(ah.Shared, func(s sharedEntry) bool { return s.CompID == id }) {
+			return fmt.Errorf("persist: archetype %s has no value for shared component %q", archetypeName(componentKeys(defIndex), ah), def.Key)
 		}
 	}
 	return nil
 }
 
+// maskOf returns the mask of ah's composition.
+func maskOf(ah archHeader) comp.Mask {
+	var mask comp.Mask
+	for _, id := range ah.CompIDs {
+		mask = mask.Set(comp.ID(id))
+	}
+	return mask
+}
+
+// columnsOf lists the components of ah with a column in the file — those
+// a composition keeps per entity — in ID order.
+func columnsOf(defIndex *comp.DefIndex, ah archHeader) []comp.Def {
+	var defs []comp.Def
+	for _, id := range ah.CompIDs {
+		def := defIndex.ByID(comp.ID(id))
+		if def.Size > 0 && !def.Shared && !def.PerChunk {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
 // reserveBatches allocates count slots in table — possibly across several
 // chunks — without seeding entity IDs, mirroring ent.Factory's Create/Next
 // reserve-once, consume-incrementally pattern: ReserveSlots is called once,
@@ -6,10 +6,21 @@ package persist
 // by position, and drives registration itself in the file's recorded order.
 // Produced by the goke package's LoadComp[T]().
 type CompRequest struct {
-	// Name is the real Go type's name (reflect.Type.String()), used to
-	// match this request against a save file's component directory entry.
+	// Name is the type's key ([comp.Def.Key] — a user-assigned stable name,
+	// or its package-qualified [comp.TypeKey]), used to match this request
+	// against a save file's component directory entry.
 	Name string
 
+	// Aliases are further keys this request matches, e.g. the type's key
+	// before a rename or a move to another package.
+	Aliases []string
+
+	// LegacyName is the type's bare reflect.Type.String(), used instead of
+	// Name when reading a version 1 file, which recorded nothing more. Two
+	// requests sharing a LegacyName cannot be told apart in such a file —
+	// Load reports an error if the file needs one of them.
+	LegacyName string
+
 	// Register validates and registers the type. wantSize is the matching
 	// directory entry's recorded size, or nil if this request did not match
 	// any entry (a type not present in the save file — registered anyway,
@@ -38,7 +38,8 @@ func newTestManager() *ent.Manager {
 func req[T any](di *comp.DefIndex) persist.CompRequest {
 	t := reflect.TypeFor[T]()
 	return persist.CompRequest{
-		Name: t.String(),
+		Name:       comp.TypeKey(t),
+		LegacyName: t.String(),
 		Register: func(wantSize *uint32) error {
 			if wantSize != nil && uint32(t.Size()) != *wantSize {
 				return fmt.Errorf("size mismatch for %s: file has %d, current type has %d", t, *wantSize, t.Size())
@@ -65,14 +66,14 @@ func TestSaveLoad_RoundTrip_EmptyWorld(t *testing.T) {
 	m := newTestManager()
 
 	var buf bytes.Buffer
-	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
 
 	var di2 comp.DefIndex
 	di2.Init()
 	m2 := newTestManager()
-	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, nil); err != nil {
+	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, nil, nil); err != nil {
 		t.Fatalf("Load: %v", err)
 	}
 }
@@ -143,7 +144,7 @@ func TestSaveLoad_RoundTrip_MultipleArchetypes(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
 
@@ -153,7 +154,7 @@ func TestSaveLoad_RoundTrip_MultipleArchetypes(t *testing.T) {
 	comps := []persist.CompRequest{
 		req[Position](&di2), req[Velocity](&di2), req[Tag](&di2), req[Name](&di2), req[stamp](&di2),
 	}
-	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, comps); err != nil {
+	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, comps, nil); err != nil {
 		t.Fatalf("Load: %v", err)
 	}
 
@@ -220,7 +221,7 @@ func TestSaveLoad_LoadCompOrderIsIndependent(t *testing.T) {
 	posCol.Slice(&factory.Cursor)[0] = Position{X: 7, Y: 8}
 
 	var buf bytes.Buffer
-	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
 
@@ -229,7 +230,7 @@ func TestSaveLoad_LoadCompOrderIsIndependent(t *testing.T) {
 	m2 := newTestManager()
 	// Deliberately reversed relative to registration order at Save time.
 	comps := []persist.CompRequest{req[Velocity](&di2), req[Position](&di2)}
-	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, comps); err != nil {
+	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, comps, nil); err != nil {
 		t.Fatalf("Load with reordered LoadComp: %v", err)
 	}
 
@@ -246,14 +247,14 @@ func TestSaveLoad_MissingLoadComp_ReturnsError(t *testing.T) {
 	m := newTestManager()
 	di.Intern(reflect.TypeFor[Position]())
 	var buf bytes.Buffer
-	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
 
 	var di2 comp.DefIndex
 	di2.Init()
 	m2 := newTestManager()
-	err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, nil)
+	err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, nil, nil)
 	if err == nil {
 		t.Fatal("expected an error when no LoadComp is provided for a required component")
 	}
@@ -264,7 +265,7 @@ func TestSaveLoad_DuplicateLoadComp_Panics(t *testing.T) {
 	di.Init()
 	m := newTestManager()
 	var buf bytes.Buffer
-	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
 
@@ -277,7 +278,7 @@ func TestSaveLoad_DuplicateLoadComp_Panics(t *testing.T) {
 	di2.Init()
 	m2 := newTestManager()
 	dup := req[Position](&di2)
-	_ = persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{dup, dup})
+	_ = persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{dup, dup}, nil)
 }
 
 func TestSaveLoad_ExtraLoadComp_RegistersWithoutError(t *testing.T) {
@@ -285,7 +286,7 @@ func TestSaveLoad_ExtraLoadComp_RegistersWithoutError(t *testing.T) {
 	di.Init()
 	m := newTestManager()
 	var buf bytes.Buffer
-	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
 
@@ -293,7 +294,7 @@ func TestSaveLoad_ExtraLoadComp_RegistersWithoutError(t *testing.T) {
 	di2.Init()
 	m2 := newTestManager()
 	// Position never appears in this (empty) save — a "new module" type.
-	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{req[Position](&di2)}); err != nil {
+	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{req[Position](&di2)}, nil); err != nil {
 		t.Fatalf("Load with an unmatched LoadComp: %v", err)
 	}
 	if _, ok := di2.ByType(reflect.TypeFor[Position]()); !ok {
@@ -313,7 +314,7 @@ func TestSaveLoad_TruncatedFile_ReturnsError(t *testing.T) {
 	factory.Next()
 
 	var buf bytes.Buffer
-	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
 
@@ -329,7 +330,7 @@ func TestSaveLoad_TruncatedFile_ReturnsError(t *testing.T) {
 				t.Fatalf("expected an error, not a panic, for a truncated file: %v", r)
 			}
 		}()
-		err = persist.Load(bytes.NewReader(truncated), &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{req[Position](&di2)})
+		err = persist.Load(bytes.NewReader(truncated), &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{req[Position](&di2)}, nil)
 	}()
 	if err == nil {
 		t.Fatal("expected an error for a truncated save file")
@@ -353,7 +354,7 @@ func TestSaveLoad_CorruptedByte_ReturnsError(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog); err != nil {
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
 		t.Fatalf("Save: %v", err)
 	}
 
@@ -364,8 +365,58 @@ func TestSaveLoad_CorruptedByte_ReturnsError(t *testing.T) {
 	var di2 comp.DefIndex
 	di2.Init()
 	m2 := newTestManager()
-	err := persist.Load(bytes.NewReader(corrupted), &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{req[Position](&di2)})
+	err := persist.Load(bytes.NewReader(corrupted), &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{req[Position](&di2)}, nil)
 	if err == nil {
 		t.Fatal("expected an error for a save file with a corrupted byte")
 	}
 }
+
+func TestSaveLoad_ColumnsAddedOutOfIDOrder(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	posDef := di.Intern(reflect.TypeFor[Position]())
+	nameDef := di.Intern(reflect.TypeFor[Name]())
+	m := newTestManager()
+
+	// Name first, then Position: the archetype's columns are listed in the
+	// order they were added, not by component ID.
+	var nameCol iter.ArrayRef[Name]
+	var spec comp.AccessSpec
+	spec.Init(&di, comp.Track(&nameCol))
+	factory := m.CreateFactory(spec)
+	factory.Create(3)
+	var ids []uid.UID64
+	for factory.Next() {
+		names := nameCol.Slice(&factory.Cursor)
+		for i := range names {
+			names[i] = Name{Value: fmt.Sprint("e", len(ids)+i)}
+		}
+		ids = append(ids, factory.IDs...)
+	}
+	for i, id := range ids {
+		ptr, err := m.UpsertComp(id, posDef)
+		if err != nil {
+			t.Fatalf("UpsertComp: %v", err)
+		}
+		*(*Position)(ptr) = Position{X: float32(i)}
+	}
+
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &di, &m.AddressBook, &m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var di2 comp.DefIndex
+	di2.Init()
+	m2 := newTestManager()
+	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{req[Position](&di2), req[Name](&di2)}, nil); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for i, id := range ids {
+		if pos, _ := componentAt[Position](m2, id, posDef.ID); pos.X != float32(i) {
+			t.Errorf("entity %v: Position = %+v, want X=%d", id, pos, i)
+		}
+		if name, _ := componentAt[Name](m2, id, nameDef.ID); name.Value != fmt.Sprint("e", i) {
+			t.Errorf("entity %v: Name = %+v, want e%d", id, name, i)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package persist
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+// SchemaKind is what a [Schema] node encodes: one of the shapes
+// [EncodeValue] writes.
+type SchemaKind uint8
+
+const (
+	SchemaOpaque SchemaKind = iota + 1 // an encoding.BinaryMarshaler's length-prefixed bytes
+	SchemaString
+	SchemaBool
+	SchemaInt8
+	SchemaInt16
+	SchemaInt32
+	SchemaInt64 // int too — always widened to 8 bytes
+	SchemaUint8
+	SchemaUint16
+	SchemaUint32
+	SchemaUint64 // uint too
+	SchemaFloat32
+	SchemaFloat64
+	SchemaComplex64
+	SchemaComplex128
+	SchemaArray
+	SchemaStruct
+)
+
+var schemaKindNames = [...]string{
+	SchemaOpaque: "opaque", SchemaString: "string", SchemaBool: "bool",
+	SchemaInt8: "int8", SchemaInt16: "int16", SchemaInt32: "int32", SchemaInt64: "int64",
+	SchemaUint8: "uint8", SchemaUint16: "uint16", SchemaUint32: "uint32", SchemaUint64: "uint64",
+	SchemaFloat32: "float32", SchemaFloat64: "float64",
+	SchemaComplex64: "complex64", SchemaComplex128: "complex128",
+	SchemaArray: "array", SchemaStruct: "struct",
+}
+
+func (k SchemaKind) String() string {
+	if int(k) < len(schemaKindNames) && schemaKindNames[k] != "" {
+		return schemaKindNames[k]
+	}
+	return fmt.Sprintf("SchemaKind(%d)", k)
+}
+
+// Schema describes how a component's values are encoded — enough to walk
+// and print them without the Go type. Save records one per component, from
+// format version 5 on.
+type Schema struct {
+	Kind   SchemaKind
+	Len    int           // SchemaArray: element count
+	Elem   *Schema       // SchemaArray: element schema
+	Fields []SchemaField // SchemaStruct
+}
+
+// SchemaField is one field of a SchemaStruct.
+type SchemaField struct {
+	Name   string
+	Schema Schema
+}
+
+// Field is one decoded field of a struct value, as returned by
+// [Schema.Decode].
+type Field struct {
+	Name  string
+	Value any
+}
+
+var binaryMarshalerType = reflect.TypeFor[encoding.BinaryMarshaler]()
+
+// SchemaOf describes t's encoding, by the rule [EncodeValue] follows. t is
+// assumed already validated by comp.ValidateEncodable.
+func SchemaOf(t reflect.Type) Schema {
+	if reflect.PointerTo(t).Implements(binaryMarshalerType) {
+		return Schema{Kind: SchemaOpaque}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{Kind: SchemaString}
+	case reflect.Bool:
+		return Schema{Kind: SchemaBool}
+	case reflect.Int8:
+		return Schema{Kind: SchemaInt8}
+	case reflect.Int16:
+		return Schema{Kind: SchemaInt16}
+	case reflect.Int32:
+		return Schema{Kind: SchemaInt32}
+	case reflect.Int, reflect.Int64:
+		return Schema{Kind: SchemaInt64}
+	case reflect.Uint8:
+		return Schema{Kind: SchemaUint8}
+	case reflect.Uint16:
+		return Schema{Kind: SchemaUint16}
+	case reflect.Uint32:
+		return Schema{Kind: SchemaUint32}
+	case reflect.Uint, reflect.Uint64:
+		return Schema{Kind: SchemaUint64}
+	case reflect.Float32:
+		return Schema{Kind: SchemaFloat32}
+	case reflect.Float64:
+		return Schema{Kind: SchemaFloat64}
+	case reflect.Complex64:
+		return Schema{Kind: SchemaComplex64}
+	case reflect.Complex128:
+		return Schema{Kind: SchemaComplex128}
+	case reflect.Array:
+		elem := SchemaOf(t.Elem())
+		return Schema{Kind: SchemaArray, Len: t.Len(), Elem: &elem}
+	case reflect.Struct:
+		s := Schema{Kind: SchemaStruct, Fields: make([]SchemaField, t.NumField())}
+		for i := range s.Fields {
+			s.Fields[i] = SchemaField{Name: t.Field(i).Name, Schema: SchemaOf(t.Field(i).Type)}
+		}
+		return s
+	default:
+		panic(fmt.Sprintf("persist: unencodable kind %s reached SchemaOf — comp.ValidateEncodable should have rejected this type at RegComp", t.Kind()))
+	}
+}
+
+// String renders s in Go-like syntax, e.g. "struct{X float32; Y float32}".
+func (s Schema) String() string {
+	switch s.Kind {
+	case SchemaArray:
+		return fmt.Sprintf("[%d]%s", s.Len, s.Elem)
+	case SchemaStruct:
+		fields := make([]string, len(s.Fields))
+		for i, f := range s.Fields {
+			fields[i] = f.Name + " " + f.Schema.String()
+		}
+		return "struct{" + strings.Join(fields, "; ") + "}"
+	default:
+		return s.Kind.String()
+	}
+}
+
+// Decode reads one value encoded by s from r: a []byte for SchemaOpaque; a
+// string, bool, float32, float64, complex64 or complex128 for those kinds;
+// an int64 or uint64 for every signed or unsigned integer kind; a []any for
+// an array; and a []Field for a struct.
+func (s Schema) Decode(r io.Reader) (any, error) {
+	switch s.Kind {
+	case SchemaOpaque:
+		return readBytes(r)
+	case SchemaString:
+		data, err := readBytes(r)
+		return string(data), err
+	case SchemaBool:
+		n, err := readUint8(r)
+		return n != 0, err
+	case SchemaInt8:
+		n, err := readUint8(r)
+		return int64(int8(n)), err
+	case SchemaInt16:
+		n, err := readUint16(r)
+		return int64(int16(n)), err
+	case SchemaInt32:
+		n, err := readUint32(r)
+		return int64(int32(n)), err
+	case SchemaInt64:
+		n, err := readUint64(r)
+		return int64(n), err
+	case SchemaUint8:
+		n, err := readUint8(r)
+		return uint64(n), err
+	case SchemaUint16:
+		n, err := readUint16(r)
+		return uint64(n), err
+	case SchemaUint32:
+		n, err := readUint32(r)
+		return uint64(n), err
+	case SchemaUint64:
+		return readUint64(r)
+	case SchemaFloat32:
+		n, err := readUint32(r)
+		return math.Float32frombits(n), err
+	case SchemaFloat64:
+		n, err := readUint64(r)
+		return math.Float64frombits(n), err
+	case SchemaComplex64:
+		re, err := readUint32(r)
+		if err != nil {
+			return nil, err
+		}
+		im, err := readUint32(r)
+		return complex(math.Float32frombits(re), math.Float32frombits(im)), err
+	case SchemaComplex128:
+		re, err := readUint64(r)
+		if err != nil {
+			return nil, err
+		}
+		im, err := readUint64(r)
+		return complex(math.Float64frombits(re), math.Float64frombits(im)), err
+	case SchemaArray:
+		elems := make([]any, s.Len)
+		for i := range elems {
+			var err error
+			if elems[i], err = s.Elem.Decode(r); err != nil {
+				return nil, err
+			}
+		}
+		return elems, nil
+	case SchemaStruct:
+		fields := make([]Field, len(s.Fields))
+		for i, f := range s.Fields {
+			v, err := f.Schema.Decode(r)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = Field{Name: f.Name, Value: v}
+		}
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("persist: unknown schema kind %d", s.Kind)
+	}
+}
+
+// Split cuts data — a column of n values encoded by s — into one slice per
+// value.
+func (s Schema) Split(data []byte, n int) ([][]byte, error) {
+	r := bytes.NewReader(data)
+	rows := make([][]byte, n)
+	for i := range rows {
+		start := len(data) - r.Len()
+		if _, err := s.Decode(r); err != nil {
+			return nil, fmt.Errorf("value %d of %d: %w", i, n, err)
+		}
+		rows[i] = data[start : len(data)-r.Len()]
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes left over after %d values", r.Len(), n)
+	}
+	return rows, nil
+}
+
+// maxSchemaDepth bounds how deeply a file's schema may nest, so a corrupt
+// one cannot recurse without end.
+const maxSchemaDepth = 64
+
+func writeSchema(w io.Writer, s Schema) error {
+	if err := writeUint8(w, uint8(s.Kind)); err != nil {
+		return err
+	}
+	switch s.Kind {
+	case SchemaArray:
+		if err := writeUint32(w, uint32(s.Len)); err != nil {
+			return err
+		}
+		return writeSchema(w, *s.Elem)
+	case SchemaStruct:
+		if err := writeUint32(w, uint32(len(s.Fields))); err != nil {
+			return err
+		}
+		for _, f := range s.Fields {
+			if err := writeBytes(w, []byte(f.Name)); err != nil {
+				return err
+			}
+			if err := writeSchema(w, f.Schema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readSchema(r io.Reader, depth int) (Schema, error) {
+	if depth > maxSchemaDepth {
+		return Schema{}, fmt.Errorf("schema nested deeper than %d levels", maxSchemaDepth)
+	}
+	kind, err := readUint8(r)
+	if err != nil {
+		return Schema{}, err
+	}
+	s := Schema{Kind: SchemaKind(kind)}
+	switch s.Kind {
+	case SchemaArray:
+		n, err := readUint32(r)
+		if err != nil {
+			return Schema{}, err
+		}
+		elem, err := readSchema(r, depth+1)
+		if err != nil {
+			return Schema{}, err
+		}
+		s.Len, s.Elem = int(n), &elem
+	case SchemaStruct:
+		n, err := readUint32(r)
+		if err != nil {
+			return Schema{}, err
+		}
+		for range n {
+			name, err := readBytes(r)
+			if err != nil {
+				return Schema{}, err
+			}
+			fs, err := readSchema(r, depth+1)
+			if err != nil {
+				return Schema{}, err
+			}
+			s.Fields = append(s.Fields, SchemaField{Name: string(name), Schema: fs})
+		}
+	default:
+		if s.Kind < SchemaOpaque || s.Kind > SchemaStruct {
+			return Schema{}, fmt.Errorf("unknown schema kind %d", kind)
+		}
+	}
+	return s, nil
+}
+
+// componentSchemas describes every registered component, in comp.ID order.
+func componentSchemas(defIndex *comp.DefIndex) []Schema {
+	schemas := make([]Schema, defIndex.Count())
+	for i := range schemas {
+		schemas[i] = SchemaOf(defIndex.ByID(comp.ID(i)).Type)
+	}
+	return schemas
+}
+
+func writeSchemas(w io.Writer, schemas []Schema) error {
+	if err := writeUint32(w, uint32(len(schemas))); err != nil {
+		return err
+	}
+	for _, s := range schemas {
+		if err := writeSchema(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readSchemas(r io.Reader) ([]Schema, error) {
+	n, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	var schemas []Schema
+	for range n {
+		s, err := readSchema(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
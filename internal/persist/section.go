@@ -0,0 +1,224 @@
+package persist
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+// SectionError reports a problem reading one section of a save file —
+// corrupt, truncated, or failing its checksum — naming the section and,
+// within an archetype's data, the archetype's composition and the
+// component column.
+type SectionError struct {
+	Section   string // e.g. "component directory", "data" (of an archetype)
+	Archetype string // the archetype's composition, e.g. "{game.Pos, game.Vel}"; "" outside archetype data
+	Component string // the column's component key; "" for the entity IDs or outside archetype data
+	Err       error
+}
+
+func (e *SectionError) Error() string {
+	var b strings.Builder
+	b.WriteString("persist: ")
+	b.WriteString(e.Section)
+	if e.Archetype != "" {
+		fmt.Fprintf(&b, " of archetype %s", e.Archetype)
+	}
+	switch {
+	case e.Component != "":
+		fmt.Fprintf(&b, ", component %q", e.Component)
+	case e.Archetype != "":
+		b.WriteString(", entity IDs")
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *SectionError) Unwrap() error { return e.Err }
+
+// Section names, as reported by SectionError.
+const (
+	sectionPool          = "ID pool state"
+	sectionComponents    = "component directory"
+	sectionSchemas       = "component schemas"
+	sectionRemoved       = "removed entity list"
+	sectionArchetypes    = "archetype directory"
+	sectionArchetypeData = "data"
+	sectionSparse        = "sparse component directory"
+	sectionSparseData    = "sparse data"
+)
+
+// writeSection frames what write produces as one section: its length
+// (uint64), the bytes, and their CRC32 (IEEE).
+func writeSection(w io.Writer, write func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	if err := writeUint64(w, uint64(buf.Len())); err != nil {
+		return err
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return writeUint32(w, crc32.ChecksumIEEE(buf.Bytes()))
+}
+
+// sectionReader reads a file's sections in order — framed and checked from
+// formatVersionChecksums on, straight off the stream before that.
+type sectionReader struct {
+	r       io.Reader
+	version uint32
+	framed  bool
+}
+
+func newSectionReader(r io.Reader, version uint32) sectionReader {
+	return sectionReader{r: r, version: version, framed: version >= formatVersionChecksums}
+}
+
+// read hands the next section to parse. A framed section is read whole
+// and its checksum verified before parse sees a byte of it, and parse must
+// consume it exactly. Any error comes back as a *SectionError built from
+// at.
+func (s sectionReader) read(at SectionError, parse func(io.Reader) error) error {
+	fail := func(err error) error {
+		at.Err = err
+		return &at
+	}
+	if !s.framed {
+		if err := parse(s.r); err != nil {
+			return fail(err)
+		}
+		return nil
+	}
+
+	n, err := readUint64(s.r)
+	if err != nil {
+		return fail(fmt.Errorf("reading section length: %w", err))
+	}
+	var payload bytes.Buffer
+	// Grows as bytes arrive, so a corrupt length can't force a huge
+	// allocation up front.
+	if _, err := payload.ReadFrom(io.LimitReader(s.r, int64(min(n, 1<<62)))); err != nil {
+		return fail(err)
+	}
+	if uint64(payload.Len()) != n {
+		return fail(fmt.Errorf("truncated: expected %d bytes, file ends after %d", n, payload.Len()))
+	}
+	want, err := readUint32(s.r)
+	if err != nil {
+		return fail(fmt.Errorf("reading checksum: %w", err))
+	}
+	if got := crc32.ChecksumIEEE(payload.Bytes()); got != want {
+		return fail(fmt.Errorf("checksum mismatch (stored %08x, computed %08x)", want, got))
+	}
+
+	br := bytes.NewReader(payload.Bytes())
+	if err := parse(br); err != nil {
+		return fail(err)
+	}
+	if br.Len() != 0 {
+		return fail(fmt.Errorf("%d bytes left unread at the end of the section", br.Len()))
+	}
+	return nil
+}
+
+// poolState reads the ID pool state section.
+func (s sectionReader) poolState() (pool poolState, err error) {
+	err = s.read(SectionError{Section: sectionPool}, func(r io.Reader) error {
+		pool, err = readPoolState(r)
+		return err
+	})
+	return pool, err
+}
+
+// componentDirectory reads the component directory section and, from
+// formatVersionSchemas on, the component schemas that follow it — nil
+// before that.
+func (s sectionReader) componentDirectory() (headers []compHeader, schemas []Schema, err error) {
+	err = s.read(SectionError{Section: sectionComponents}, func(r io.Reader) error {
+		headers, err = readComponentDirectory(r)
+		return err
+	})
+	if err != nil || s.version < formatVersionSchemas {
+		return headers, nil, err
+	}
+	err = s.read(SectionError{Section: sectionSchemas}, func(r io.Reader) error {
+		schemas, err = readSchemas(r)
+		if err == nil && len(schemas) != len(headers) {
+			err = fmt.Errorf("%d schemas for %d components", len(schemas), len(headers))
+		}
+		return err
+	})
+	return headers, schemas, err
+}
+
+// removedIDs reads a delta's removed entity list section.
+func (s sectionReader) removedIDs() (removed []uid.UID64, err error) {
+	err = s.read(SectionError{Section: sectionRemoved}, func(r io.Reader) error {
+		count, err := readUint32(r)
+		if err != nil {
+			return err
+		}
+		removed = make([]uid.UID64, count)
+		return readIDsInto(r, removed)
+	})
+	return removed, err
+}
+
+// archDirectory reads the archetype directory section.
+func (s sectionReader) archDirectory() (headers []archHeader, err error) {
+	err = s.read(SectionError{Section: sectionArchetypes}, func(r io.Reader) error {
+		headers, err = readArchDirectory(r, s.version)
+		return err
+	})
+	return headers, err
+}
+
+// sparseDirectory reads the sparse component directory section.
+func (s sectionReader) sparseDirectory() (headers []sparseHeader, err error) {
+	err = s.read(SectionError{Section: sectionSparse}, func(r io.Reader) error {
+		count, err := readUint32(r)
+		if err != nil {
+			return err
+		}
+		headers = make([]sparseHeader, count)
+		for i := range headers {
+			if headers[i], err = readSparseHeader(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return headers, err
+}
+
+// archetypeName renders a composition for a SectionError: the keys of its
+// components, tags included, in ID order, looked up in keys.
+func archetypeName(keys []string, ah archHeader) string {
+	names := make([]string, len(ah.CompIDs))
+	for i, id := range ah.CompIDs {
+		if int(id) < len(keys) {
+			names[i] = keys[id]
+		} else {
+			names[i] = fmt.Sprintf("#%d", id)
+		}
+	}
+	return "{" + strings.Join(names, ", ") + "}"
+}
+
+// componentKeys lists every component's key registered in defIndex, in
+// comp.ID order.
+func componentKeys(defIndex *comp.DefIndex) []string {
+	keys := make([]string, defIndex.Count())
+	for i := range keys {
+		keys[i] = defIndex.ByID(comp.ID(i)).Key
+	}
+	return keys
+}
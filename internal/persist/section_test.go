@@ -0,0 +1,129 @@
+package persist_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/persist"
+)
+
+// regzip compresses data the way Save does, so a test can damage a save
+// file's payload rather than its gzip stream.
+func regzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// emptySparseDirectory is the size of the section closing a file without
+// sparse components: its length, an entry count of zero, and its CRC32.
+const emptySparseDirectory = 8 + 4 + 4
+
+// damagedFiles saves w and returns its payload damaged in the last
+// archetype section written — the Velocity column of the Position+Velocity
+// archetype, just before the empty sparse directory — once with a flipped
+// byte and once cut short.
+func damagedFiles(t *testing.T, w *filterWorld) map[string][]byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	payload := ungzip(t, buf.Bytes())
+	end := len(payload) - emptySparseDirectory
+	flipped := bytes.Clone(payload)
+	flipped[end-5] ^= 0x40 // the column's last byte, just before its CRC32
+	return map[string][]byte{
+		"checksum mismatch": regzip(t, flipped),
+		"truncated":         regzip(t, payload[:end-9]),
+	}
+}
+
+func assertNamesVelocityColumn(t *testing.T, err error, want string) {
+	t.Helper()
+	var se *persist.SectionError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected a *persist.SectionError, got %v", err)
+	}
+	pos, vel := comp.TypeKey(reflect.TypeFor[Position]()), comp.TypeKey(reflect.TypeFor[Velocity]())
+	if se.Component != vel {
+		t.Errorf("Component = %q, want %q", se.Component, vel)
+	}
+	if se.Archetype != "{"+pos+", "+vel+"}" {
+		t.Errorf("Archetype = %q, want the Position+Velocity composition", se.Archetype)
+	}
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("expected the error to mention %q, got: %v", want, err)
+	}
+}
+
+func TestLoad_DamagedSection_NamesArchetypeAndComponent(t *testing.T) {
+	w := newFilterWorld(t)
+	for want, data := range damagedFiles(t, w) {
+		t.Run(want, func(t *testing.T) {
+			var di comp.DefIndex
+			di.Init()
+			m := newTestManager()
+			err := persist.Load(bytes.NewReader(data), &di, &m.AddressBook, &m.ArchCatalog, w.requests(&di), nil)
+			assertNamesVelocityColumn(t, err, want)
+		})
+	}
+}
+
+func TestVerify_DamagedSection_NamesArchetypeAndComponent(t *testing.T) {
+	w := newFilterWorld(t)
+	for want, data := range damagedFiles(t, w) {
+		t.Run(want, func(t *testing.T) {
+			assertNamesVelocityColumn(t, persist.Verify(bytes.NewReader(data)), want)
+		})
+	}
+}
+
+func TestVerify_AcceptsIntactFiles(t *testing.T) {
+	w := newDeltaWorld(t, 100)
+	w.spawnNames(5)
+	var full bytes.Buffer
+	if err := persist.Save(&full, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &w.base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	w.posAt(w.posIDs[0]).X = -1
+	w.m.Remove(w.posIDs[1])
+	delta := w.saveDelta(t)
+
+	tagged := newFilterWorld(t)
+	var partial bytes.Buffer
+	filter := persist.NewFilter(&tagged.di, comp.Include[Tag]())
+	if err := persist.SaveOnly(&partial, &tagged.di, &tagged.m.AddressBook, &tagged.m.ArchCatalog, filter); err != nil {
+		t.Fatalf("SaveOnly: %v", err)
+	}
+
+	for name, data := range map[string][]byte{"full": full.Bytes(), "delta": delta, "tagged": partial.Bytes()} {
+		if err := persist.Verify(bytes.NewReader(data)); err != nil {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
+
+func TestVerify_RejectsTrailingData(t *testing.T) {
+	w := newFilterWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data := regzip(t, append(ungzip(t, buf.Bytes()), 0))
+	if err := persist.Verify(bytes.NewReader(data)); err == nil {
+		t.Error("expected Verify to reject data after the last section")
+	}
+}
@@ -0,0 +1,223 @@
+package persist_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/ent"
+	"github.com/kjkrol/goke/v3/internal/persist"
+)
+
+// reqShared is req registering T as a shared component.
+func reqShared[T any](di *comp.DefIndex) persist.CompRequest {
+	r := req[T](di)
+	register := r.Register
+	r.Register = func(wantSize *uint32) error {
+		di.InternWith(reflect.TypeFor[T](), comp.RegSpec{Shared: true})
+		return register(wantSize)
+	}
+	return r
+}
+
+func sharedRequests(di *comp.DefIndex) []persist.CompRequest {
+	return []persist.CompRequest{req[Position](di), reqShared[Velocity](di)}
+}
+
+// sharedWorld holds two groups of positioned entities, each sharing its
+// own Velocity.
+type sharedWorld struct {
+	di     comp.DefIndex
+	m      *ent.Manager
+	groups [2][]uid.UID64
+}
+
+func sharedVelocity(group int) Velocity { return Velocity{X: float32(group + 1), Y: -1} }
+
+func newSharedWorld(t *testing.T) *sharedWorld {
+	t.Helper()
+	w := &sharedWorld{m: newTestManager()}
+	w.di.Init()
+	posDef := w.di.Intern(reflect.TypeFor[Position]())
+	velDef := w.di.InternWith(reflect.TypeFor[Velocity](), comp.RegSpec{Shared: true})
+	for g := range w.groups {
+		var spec comp.AccessSpec
+		if err := spec.Comp(posDef); err != nil {
+			t.Fatal(err)
+		}
+		vel := sharedVelocity(g)
+		factory := w.m.CreateFactory(spec, comp.SharedInit{Def: velDef, Value: unsafe.Pointer(&vel)})
+		factory.Create(3)
+		for factory.Next() {
+			w.groups[g] = append(w.groups[g], factory.IDs...)
+		}
+		for i, id := range w.groups[g] {
+			ptr, err := w.m.UpsertComp(id, posDef)
+			if err != nil {
+				t.Fatal(err)
+			}
+			*(*Position)(ptr) = Position{X: float32(i), Y: float32(g)}
+		}
+	}
+	return w
+}
+
+// assertShared checks that each of ids sits in an archetype sharing
+// sharedVelocity(group), with the Position newSharedWorld gave it.
+func assertShared(t *testing.T, di *comp.DefIndex, m *ent.Manager, ids []uid.UID64, group int) {
+	t.Helper()
+	posDef, _ := di.ByType(reflect.TypeFor[Position]())
+	velDef, ok := di.ByType(reflect.TypeFor[Velocity]())
+	if !ok || !velDef.Shared {
+		t.Fatal("Velocity is not registered shared")
+	}
+	for i, id := range ids {
+		entry, ok := m.AddressBook.Get(id)
+		if !ok {
+			t.Errorf("entity %v is missing", id)
+			continue
+		}
+		vel := (*Velocity)(m.ArchCatalog.Archetypes[entry.ArchID].SharedValue(velDef.ID))
+		if want := sharedVelocity(group); vel == nil || *vel != want {
+			t.Errorf("entity %v: shared Velocity = %v, want %+v", id, vel, want)
+		}
+		if pos, _ := componentAt[Position](m, id, posDef.ID); pos != (Position{X: float32(i), Y: float32(group)}) {
+			t.Errorf("entity %v: Position = %+v, want X=%d Y=%d", id, pos, i, group)
+		}
+	}
+}
+
+func TestSaveLoad_RoundTrip_SharedValues(t *testing.T) {
+	w := newSharedWorld(t)
+	var saved, encoded, doc bytes.Buffer
+	if err := persist.Save(&saved, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	s, err := persist.Capture(&w.di, &w.m.AddressBook, &w.m.ArchCatalog)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if err := s.Encode(context.Background(), &encoded); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if err := persist.ExportJSON(&doc, &w.di, &w.m.AddressBook, &w.m.ArchCatalog); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+
+	loads := map[string]func(di *comp.DefIndex, m *ent.Manager) error{
+		"Save": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.Load(&saved, di, &m.AddressBook, &m.ArchCatalog, sharedRequests(di), nil)
+		},
+		"Capture": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.Load(&encoded, di, &m.AddressBook, &m.ArchCatalog, sharedRequests(di), nil)
+		},
+		"ExportJSON": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.ImportJSON(&doc, di, &m.AddressBook, &m.ArchCatalog, sharedRequests(di))
+		},
+	}
+	for name, load := range loads {
+		t.Run(name, func(t *testing.T) {
+			var di comp.DefIndex
+			di.Init()
+			m := newTestManager()
+			if err := load(&di, m); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			for g, ids := range w.groups {
+				assertShared(t, &di, m, ids, g)
+			}
+		})
+	}
+}
+
+// A shared value cannot load into a component registered per entity.
+func TestLoad_SharedValueRegisteredPerEntity_ReturnsError(t *testing.T) {
+	w := newSharedWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	comps := []persist.CompRequest{req[Position](&di), req[Velocity](&di)}
+	if err := persist.Load(&buf, &di, &m.AddressBook, &m.ArchCatalog, comps, nil); err == nil {
+		t.Error("expected an error loading a shared value into a per-entity component")
+	}
+}
+
+// LoadMerge finds the target's archetypes with equal shared values rather
+// than making new ones.
+func TestLoadMerge_SharedValues(t *testing.T) {
+	w := newSharedWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	target := newSharedWorld(t)
+	archetypes := target.m.ArchCatalog.Len()
+	remap, err := persist.LoadMerge(&buf, &target.di, &target.m.AddressBook, &target.m.ArchCatalog, sharedRequests(&target.di))
+	if err != nil {
+		t.Fatalf("LoadMerge: %v", err)
+	}
+	for g, ids := range w.groups {
+		merged := make([]uid.UID64, len(ids))
+		for i, id := range ids {
+			merged[i] = remap[id]
+		}
+		assertShared(t, &target.di, target.m, merged, g)
+		assertShared(t, &target.di, target.m, target.groups[g], g)
+	}
+	if n := target.m.ArchCatalog.Len(); n != archetypes {
+		t.Errorf("expected %d archetypes after the merge, got %d", archetypes, n)
+	}
+}
+
+// A delta's dirty archetypes carry their shared values, so LoadDelta finds
+// the replica's matching archetypes.
+func TestSaveDelta_SharedValues(t *testing.T) {
+	w := newSharedWorld(t)
+	var base, replicaBase persist.Baseline
+	var full bytes.Buffer
+	if err := persist.Save(&full, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	if err := persist.Load(&full, &di, &m.AddressBook, &m.ArchCatalog, sharedRequests(&di), &replicaBase); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	archetypes := m.ArchCatalog.Len()
+
+	posDef, _ := w.di.ByType(reflect.TypeFor[Position]())
+	entry, _ := w.m.AddressBook.Get(w.groups[1][0])
+	pos := (*Position)(w.m.ArchCatalog.Archetypes[entry.ArchID].Table.ComponentAt(entry.ChunkPtr, entry.Slot, posDef.ID))
+	pos.X = 42
+
+	var delta bytes.Buffer
+	if err := persist.SaveDelta(&delta, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &base); err != nil {
+		t.Fatalf("SaveDelta: %v", err)
+	}
+	if err := persist.LoadDelta(&delta, &di, &m.AddressBook, &m.ArchCatalog, &replicaBase); err != nil {
+		t.Fatalf("LoadDelta: %v", err)
+	}
+	entry, _ = m.AddressBook.Get(w.groups[1][0])
+	pos = (*Position)(m.ArchCatalog.Archetypes[entry.ArchID].Table.ComponentAt(entry.ChunkPtr, entry.Slot, posDef.ID))
+	if pos.X != 42 {
+		t.Errorf("changed Position: got %+v, want X=42", *pos)
+	}
+	pos.X = 0
+	for g, ids := range w.groups {
+		assertShared(t, &di, m, ids, g)
+	}
+	if n := m.ArchCatalog.Len(); n != archetypes {
+		t.Errorf("expected %d archetypes after the delta, got %d", archetypes, n)
+	}
+}
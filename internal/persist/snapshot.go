@@ -0,0 +1,269 @@
+package persist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"reflect"
+	"slices"
+	"unsafe"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/addr"
+	"github.com/kjkrol/goke/v3/internal/arch"
+	"github.com/kjkrol/goke/v3/internal/colstore"
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// Snapshot is a raw copy of the world, taken by [Capture] and written out
+// later — possibly on another goroutine — by [Snapshot.Encode], as a full
+// save identical in format to one written by [Save].
+//
+// Capture copies chunk memory column by column, without reflection on
+// individual values. Components whose codec hides a pointer, slice, or map
+// (see [comp.HasOpaqueIndirection]) are the exception: a shallow copy
+// would still share their backing memory with the live world, so they are
+// encoded during Capture instead.
+type Snapshot struct {
+	id          uint64
+	nextIndex   uint32
+	generations []uint32
+	free        []uint32
+	comps       []compHeader
+	schemas     []Schema
+	archs       []archCopy
+	sparse      []sparseCopy
+}
+
+// archCopy is one live archetype's share of a Snapshot. chunks holds a
+// colCopy per per-chunk component, with one value per chunk.
+type archCopy struct {
+	header archHeader
+	ids    []uid.UID64
+	cols   []colCopy
+	chunks []colCopy
+}
+
+// sparseCopy is one sparse component's share of a Snapshot: its entities
+// and, unless it is a tag, their values.
+type sparseCopy struct {
+	compID uint8
+	ids    []uid.UID64
+	col    colCopy
+}
+
+// colCopy is one component column of an archCopy: either its values,
+// copied into a slice of the component's own type (so the GC still sees
+// any string it holds), or — for an opaque codec — already encoded.
+type colCopy struct {
+	typ     reflect.Type
+	values  reflect.Value
+	encoded []byte
+}
+
+// Capture copies the world's ID pool, component directory, every live
+// archetype's entities and every sparse component's values into a
+// Snapshot, which no longer depends on the world once Capture returns.
+func Capture(defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog) (*Snapshot, error) {
+	s := &Snapshot{id: newSnapshotID(), comps: componentDirectory(defIndex), schemas: componentSchemas(defIndex)}
+	s.nextIndex, s.generations, s.free = book.PoolState()
+
+	for _, archID := range liveArchetypes(catalog) {
+		a := &catalog.Archetypes[archID]
+		ac, err := captureArchetype(defIndex, a)
+		if err != nil {
+			return nil, err
+		}
+		s.archs = append(s.archs, ac)
+	}
+	for _, rows := range savedSparse(defIndex, catalog, nil) {
+		sc, err := captureSparse(rows)
+		if err != nil {
+			return nil, err
+		}
+		s.sparse = append(s.sparse, sc)
+	}
+	return s, nil
+}
+
+func captureArchetype(defIndex *comp.DefIndex, a *arch.Archetype) (archCopy, error) {
+	n := int(a.Len())
+	header, err := archHeaderOf(defIndex, a, nil)
+	if err != nil {
+		return archCopy{}, err
+	}
+	ac := archCopy{header: header, ids: make([]uid.UID64, 0, n)}
+	_ = walkTableChunks(&a.Table, func(_ int, cur *iter.Cursor) error {
+		ac.ids = append(ac.ids, cur.IDs...)
+		return nil
+	})
+
+	for _, def := range columnDefs(a) {
+		col := colCopy{typ: def.Type}
+		if comp.HasOpaqueIndirection(def.Type) {
+			var buf bytes.Buffer
+			if err := walkTableChunks(&a.Table, func(_ int, cur *iter.Cursor) error {
+				for slot := range len(cur.IDs) {
+					if err := EncodeValue(&buf, def.Type, a.Table.ComponentAt(cur.Base, colstore.Slot(slot), def.ID)); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				return archCopy{}, err
+			}
+			col.encoded = buf.Bytes()
+		} else {
+			col.values = reflect.MakeSlice(reflect.SliceOf(def.Type), n, n)
+			from := 0
+			_ = walkTableChunks(&a.Table, func(_ int, cur *iter.Cursor) error {
+				k := len(cur.IDs)
+				src := reflect.SliceAt(def.Type, a.Table.ComponentAt(cur.Base, 0, def.ID), k)
+				reflect.Copy(col.values.Slice(from, from+k), src)
+				from += k
+				return nil
+			})
+		}
+		ac.cols = append(ac.cols, col)
+	}
+
+	// Per-chunk types hide no pointers, so every value is copied.
+	for _, def := range chunkDefs(a) {
+		col := colCopy{typ: def.Type, values: reflect.MakeSlice(reflect.SliceOf(def.Type), len(header.Runs), len(header.Runs))}
+		i := 0
+		_ = walkTableChunks(&a.Table, func(_ int, cur *iter.Cursor) error {
+			col.values.Index(i).Set(reflect.NewAt(def.Type, a.Table.ChunkValueAt(cur.Base, def.ID)).Elem())
+			i++
+			return nil
+		})
+		ac.chunks = append(ac.chunks, col)
+	}
+	return ac, nil
+}
+
+// captureSparse copies rows, which hold their whole set in dense order.
+func captureSparse(rows sparseRows) (sparseCopy, error) {
+	def := rows.def
+	sc := sparseCopy{compID: uint8(def.ID), ids: rows.ids, col: colCopy{typ: def.Type}}
+	n := len(rows.ids)
+	switch {
+	case def.Size == 0:
+	case comp.HasOpaqueIndirection(def.Type):
+		var buf bytes.Buffer
+		for _, p := range rows.pos {
+			if err := EncodeValue(&buf, def.Type, rows.set.At(p)); err != nil {
+				return sparseCopy{}, err
+			}
+		}
+		sc.col.encoded = buf.Bytes()
+	default:
+		sc.col.values = reflect.MakeSlice(reflect.SliceOf(def.Type), n, n)
+		if n > 0 {
+			reflect.Copy(sc.col.values, reflect.SliceAt(def.Type, rows.set.At(0), n))
+		}
+	}
+	return sc, nil
+}
+
+// ID returns the snapshot ID the encoded file will carry.
+func (s *Snapshot) ID() uint64 { return s.id }
+
+// Encode writes s to w, gzip-compressed. It checks ctx between archetypes
+// and components, returning ctx's error — and leaving w holding a
+// truncated stream — once it is done.
+func (s *Snapshot) Encode(ctx context.Context, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	if err := s.encodeTo(ctx, gw); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func (s *Snapshot) encodeTo(ctx context.Context, w io.Writer) error {
+	if err := writeHeader(w, fileHeader{Kind: kindFull, ID: s.id}); err != nil {
+		return err
+	}
+	if err := writeSection(w, func(w io.Writer) error {
+		if err := writeUint32(w, s.nextIndex); err != nil {
+			return err
+		}
+		if err := writeUint32Slice(w, s.generations); err != nil {
+			return err
+		}
+		return writeUint32Slice(w, s.free)
+	}); err != nil {
+		return err
+	}
+	if err := writeSection(w, func(w io.Writer) error { return writeCompHeaders(w, s.comps) }); err != nil {
+		return err
+	}
+	if err := writeSection(w, func(w io.Writer) error { return writeSchemas(w, s.schemas) }); err != nil {
+		return err
+	}
+
+	archHeaders := make([]archHeader, len(s.archs))
+	for i, ac := range s.archs {
+		archHeaders[i] = ac.header
+	}
+	if err := writeSection(w, func(w io.Writer) error { return writeArchDirectory(w, archHeaders) }); err != nil {
+		return err
+	}
+
+	for _, ac := range s.archs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := writeSection(w, func(w io.Writer) error { return writeIDs(w, ac.ids) }); err != nil {
+			return err
+		}
+		for _, col := range slices.Concat(ac.cols, ac.chunks) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := writeSection(w, col.encode); err != nil {
+				return err
+			}
+		}
+	}
+
+	headers := make([]sparseHeader, len(s.sparse))
+	for i, sc := range s.sparse {
+		headers[i] = sparseHeader{CompID: sc.compID, EntityCount: uint32(len(sc.ids))}
+	}
+	if err := writeSection(w, func(w io.Writer) error { return writeSparseDirectory(w, headers) }); err != nil {
+		return err
+	}
+	for _, sc := range s.sparse {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := writeSection(w, func(w io.Writer) error { return writeIDs(w, sc.ids) }); err != nil {
+			return err
+		}
+		if sc.col.typ.Size() == 0 {
+			continue
+		}
+		if err := writeSection(w, sc.col.encode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *colCopy) encode(w io.Writer) error {
+	if !c.values.IsValid() {
+		_, err := w.Write(c.encoded)
+		return err
+	}
+	base := c.values.UnsafePointer()
+	size := c.typ.Size()
+	for i := range c.values.Len() {
+		if err := EncodeValue(w, c.typ, unsafe.Add(base, uintptr(i)*size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
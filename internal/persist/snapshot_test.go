@@ -0,0 +1,145 @@
+package persist_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/persist"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+func TestSnapshot_EncodesTheWorldAsCaptured(t *testing.T) {
+	w := newDeltaWorld(t, 5000)
+	w.spawnNames(10)
+	want := dumpWorld(w.m)
+
+	s, err := persist.Capture(&w.di, &w.m.AddressBook, &w.m.ArchCatalog)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+
+	// Changes after Capture must not leak into the encoded snapshot.
+	w.posAt(w.posIDs[0]).X = -1
+	w.m.Remove(w.posIDs[1])
+	w.spawnNames(3)
+
+	var buf bytes.Buffer
+	if err := s.Encode(context.Background(), &buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	r := &replica{m: newTestManager()}
+	r.di.Init()
+	if err := persist.Load(&buf, &r.di, &r.m.AddressBook, &r.m.ArchCatalog, w.requests(&r.di), &r.base); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if r.base.ID() != s.ID() {
+		t.Errorf("expected the loaded baseline at snapshot %x, got %x", s.ID(), r.base.ID())
+	}
+
+	got := dumpWorld(r.m)
+	if len(got) != len(want) {
+		t.Errorf("expected %d entities, got %d", len(want), len(got))
+	}
+	for id, row := range want {
+		if got[id] != row {
+			t.Errorf("entity %v: got %q, want %q", id, got[id], row)
+		}
+	}
+}
+
+func TestSnapshot_EncodesOpaqueIndirectionAtCapture(t *testing.T) {
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	var invCol iter.ArrayRef[inventory]
+	var spec comp.AccessSpec
+	spec.Init(&di, comp.Track(&invCol))
+	f := m.CreateFactory(spec)
+	f.Create(1)
+	var inv *inventory
+	var id uid.UID64
+	for f.Next() {
+		inv = &invCol.Slice(&f.Cursor)[0]
+		inv.items = []int32{1, 2}
+		id = f.IDs[0]
+	}
+
+	s, err := persist.Capture(&di, &m.AddressBook, &m.ArchCatalog)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	inv.items = append(inv.items, 3) // shares no memory with the snapshot
+
+	var buf bytes.Buffer
+	if err := s.Encode(context.Background(), &buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var di2 comp.DefIndex
+	di2.Init()
+	m2 := newTestManager()
+	if err := persist.Load(&buf, &di2, &m2.AddressBook, &m2.ArchCatalog, []persist.CompRequest{req[inventory](&di2)}, nil); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	entry, _ := m2.AddressBook.Get(id)
+	got := (*inventory)(m2.ArchCatalog.Archetypes[entry.ArchID].Table.ComponentAt(entry.ChunkPtr, entry.Slot, 0))
+	if len(got.items) != 2 {
+		t.Errorf("expected the inventory as captured (2 items), got %d", len(got.items))
+	}
+}
+
+func TestSnapshot_Encode_StopsWhenCanceled(t *testing.T) {
+	w := newDeltaWorld(t, 100)
+	s, err := persist.Capture(&w.di, &w.m.AddressBook, &w.m.ArchCatalog)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	if err := s.Encode(ctx, &buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSnapshot_MatchesSave(t *testing.T) {
+	w := newDeltaWorld(t, 300)
+	s, err := persist.Capture(&w.di, &w.m.AddressBook, &w.m.ArchCatalog)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	var async, sync bytes.Buffer
+	if err := s.Encode(context.Background(), &async); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if err := persist.Save(&sync, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if got, want := ungzip(t, async.Bytes()), ungzip(t, sync.Bytes()); !bytes.Equal(got[headerLen:], want[headerLen:]) {
+		t.Error("expected the snapshot's encoding to match Save's, past the snapshot header")
+	}
+}
+
+// headerLen is the size of a version 3 file header: magic, version, kind,
+// snapshot ID, and parent ID.
+const headerLen = len(persist.Magic) + 4 + 1 + 8 + 8
+
+func ungzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -0,0 +1,272 @@
+package persist_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/colstore"
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/internal/ent"
+	"github.com/kjkrol/goke/v3/internal/persist"
+)
+
+// Stun is a sparse component naming the entity that caused it.
+type Stun struct {
+	Ticks  int32
+	Source uid.UID64 `goke:"ref"`
+}
+
+// Frozen is a sparse tag.
+type Frozen struct{}
+
+// reqSparse is req registering T with sparse storage.
+func reqSparse[T any](di *comp.DefIndex) persist.CompRequest {
+	r := req[T](di)
+	register := r.Register
+	r.Register = func(wantSize *uint32) error {
+		di.InternWith(reflect.TypeFor[T](), comp.RegSpec{Sparse: true})
+		return register(wantSize)
+	}
+	return r
+}
+
+func sparseRequests(di *comp.DefIndex) []persist.CompRequest {
+	return []persist.CompRequest{req[Position](di), req[Tag](di), req[Velocity](di), reqSparse[Stun](di), reqSparse[Frozen](di)}
+}
+
+// newSparseWorld is a filterWorld whose plain and moving entities are
+// stunned — Ticks their index, Source the next one — and whose first
+// moving entity is also frozen.
+func newSparseWorld(t *testing.T) *filterWorld {
+	t.Helper()
+	w := newFilterWorld(t)
+	stunDef := w.di.InternWith(reflect.TypeFor[Stun](), comp.RegSpec{Sparse: true})
+	frozenDef := w.di.InternWith(reflect.TypeFor[Frozen](), comp.RegSpec{Sparse: true})
+	for _, ids := range [][]uid.UID64{w.plain, w.moving} {
+		for i, id := range ids {
+			ptr, err := w.m.UpsertComp(id, stunDef)
+			if err != nil {
+				t.Fatal(err)
+			}
+			*(*Stun)(ptr) = Stun{Ticks: int32(i), Source: ids[(i+1)%len(ids)]}
+		}
+	}
+	if _, err := w.m.UpsertComp(w.moving[0], frozenDef); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// setOf returns the sparse set of T in m.
+func setOf[T any](t *testing.T, di *comp.DefIndex, m *ent.Manager) *colstore.SparseSet {
+	t.Helper()
+	def, ok := di.ByType(reflect.TypeFor[T]())
+	if !ok || !def.Sparse {
+		t.Fatalf("%s is not registered sparse", reflect.TypeFor[T]())
+	}
+	return m.ArchCatalog.SparseSet(def)
+}
+
+// assertStunned checks that m's stunned entities are exactly ids, each
+// holding the value newSparseWorld gave it, with Source mapped by remap
+// (if non-nil).
+func assertStunned(t *testing.T, di *comp.DefIndex, m *ent.Manager, ids []uid.UID64, remap map[uid.UID64]uid.UID64) {
+	t.Helper()
+	stuns := setOf[Stun](t, di, m)
+	for i, id := range ids {
+		want := Stun{Ticks: int32(i), Source: ids[(i+1)%len(ids)]}
+		if remap != nil {
+			id, want.Source = remap[id], remap[want.Source]
+		}
+		if got := (*Stun)(stuns.Get(id)); got == nil || *got != want {
+			t.Errorf("entity %v: Stun = %v, want %+v", id, got, want)
+		}
+	}
+}
+
+func TestSaveLoad_RoundTrip_SparseComponents(t *testing.T) {
+	w := newSparseWorld(t)
+	var saved, encoded, doc bytes.Buffer
+	if err := persist.Save(&saved, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	s, err := persist.Capture(&w.di, &w.m.AddressBook, &w.m.ArchCatalog)
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+	if err := s.Encode(context.Background(), &encoded); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if err := persist.ExportJSON(&doc, &w.di, &w.m.AddressBook, &w.m.ArchCatalog); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+
+	loads := map[string]func(di *comp.DefIndex, m *ent.Manager) error{
+		"Save": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.Load(&saved, di, &m.AddressBook, &m.ArchCatalog, sparseRequests(di), nil)
+		},
+		"Capture": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.Load(&encoded, di, &m.AddressBook, &m.ArchCatalog, sparseRequests(di), nil)
+		},
+		"ExportJSON": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.ImportJSON(&doc, di, &m.AddressBook, &m.ArchCatalog, sparseRequests(di))
+		},
+	}
+	for name, load := range loads {
+		t.Run(name, func(t *testing.T) {
+			var di comp.DefIndex
+			di.Init()
+			m := newTestManager()
+			if err := load(&di, m); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			assertStunned(t, &di, m, w.plain, nil)
+			assertStunned(t, &di, m, w.moving, nil)
+			if n := setOf[Stun](t, &di, m).Len(); n != len(w.plain)+len(w.moving) {
+				t.Errorf("expected %d stunned entities, got %d", len(w.plain)+len(w.moving), n)
+			}
+			if frozen := setOf[Frozen](t, &di, m); frozen.Len() != 1 || !frozen.Has(w.moving[0]) {
+				t.Errorf("expected only %v frozen, got %v", w.moving[0], frozen.IDs())
+			}
+		})
+	}
+}
+
+// A sparse component registered with archetype storage cannot take the
+// file's sparse values.
+func TestLoad_SparseComponentRegisteredDense_ReturnsError(t *testing.T) {
+	w := newSparseWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	comps := []persist.CompRequest{req[Position](&di), req[Tag](&di), req[Velocity](&di), req[Stun](&di), reqSparse[Frozen](&di)}
+	if err := persist.Load(&buf, &di, &m.AddressBook, &m.ArchCatalog, comps, nil); err == nil {
+		t.Error("expected an error loading sparse values into an archetype component")
+	}
+}
+
+// SaveOnly writes the sparse values of the entities it keeps, and
+// LoadOnly loads those of the entities it keeps.
+func TestSaveOnly_LoadOnly_SparseFollowsEntities(t *testing.T) {
+	w := newSparseWorld(t)
+	var partial, full bytes.Buffer
+	filter := persist.NewFilter(&w.di, comp.Exclude[Velocity]())
+	if err := persist.SaveOnly(&partial, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, filter); err != nil {
+		t.Fatalf("SaveOnly: %v", err)
+	}
+	if err := persist.Save(&full, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loads := map[string]func(di *comp.DefIndex, m *ent.Manager) error{
+		"SaveOnly": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.Load(&partial, di, &m.AddressBook, &m.ArchCatalog, sparseRequests(di), nil)
+		},
+		"LoadOnly": func(di *comp.DefIndex, m *ent.Manager) error {
+			return persist.LoadOnly(&full, di, &m.AddressBook, &m.ArchCatalog, sparseRequests(di), nil, []comp.AccessOpt{comp.Exclude[Velocity]()})
+		},
+	}
+	for name, load := range loads {
+		t.Run(name, func(t *testing.T) {
+			var di comp.DefIndex
+			di.Init()
+			m := newTestManager()
+			if err := load(&di, m); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			assertStunned(t, &di, m, w.plain, nil)
+			if n := setOf[Stun](t, &di, m).Len(); n != len(w.plain) {
+				t.Errorf("expected only the %d plain entities stunned, got %d", len(w.plain), n)
+			}
+			if n := setOf[Frozen](t, &di, m).Len(); n != 0 {
+				t.Errorf("expected no frozen entity, got %d", n)
+			}
+		})
+	}
+}
+
+// A delta carries every sparse component whole: values added, changed and
+// removed since the base all reach the replica.
+func TestSaveDelta_SparseComponents(t *testing.T) {
+	w := newSparseWorld(t)
+	var base persist.Baseline
+	var full bytes.Buffer
+	if err := persist.Save(&full, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &base); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var di comp.DefIndex
+	di.Init()
+	m := newTestManager()
+	var replicaBase persist.Baseline
+	if err := persist.Load(&full, &di, &m.AddressBook, &m.ArchCatalog, sparseRequests(&di), &replicaBase); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	stunDef, _ := w.di.ByType(reflect.TypeFor[Stun]())
+	frozenDef, _ := w.di.ByType(reflect.TypeFor[Frozen]())
+	ptr, err := w.m.UpsertComp(w.tagged[0], stunDef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	*(*Stun)(ptr) = Stun{Ticks: 99}
+	(*Stun)(w.m.ArchCatalog.SparseSet(stunDef).Get(w.plain[1])).Ticks = -1
+	if err := w.m.RemoveComp(w.moving[0], frozenDef); err != nil {
+		t.Fatal(err)
+	}
+	w.m.Remove(w.plain[0])
+
+	var delta bytes.Buffer
+	if err := persist.SaveDelta(&delta, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, &base); err != nil {
+		t.Fatalf("SaveDelta: %v", err)
+	}
+	if err := persist.LoadDelta(&delta, &di, &m.AddressBook, &m.ArchCatalog, &replicaBase); err != nil {
+		t.Fatalf("LoadDelta: %v", err)
+	}
+
+	stuns := setOf[Stun](t, &di, m)
+	if got := (*Stun)(stuns.Get(w.tagged[0])); got == nil || got.Ticks != 99 {
+		t.Errorf("added value: got %v, want Ticks 99", got)
+	}
+	if got := (*Stun)(stuns.Get(w.plain[1])); got == nil || got.Ticks != -1 {
+		t.Errorf("changed value: got %v, want Ticks -1", got)
+	}
+	if stuns.Has(w.plain[0]) {
+		t.Error("expected the removed entity's value to be gone")
+	}
+	if n := stuns.Len(); n != len(w.plain)+len(w.moving) {
+		t.Errorf("expected %d stunned entities, got %d", len(w.plain)+len(w.moving), n)
+	}
+	if n := setOf[Frozen](t, &di, m).Len(); n != 0 {
+		t.Errorf("expected the frozen tag removed, got %d frozen", n)
+	}
+}
+
+// LoadMerge moves sparse values to the merged entities' fresh IDs and
+// rewrites their references.
+func TestLoadMerge_SparseComponents(t *testing.T) {
+	w := newSparseWorld(t)
+	var buf bytes.Buffer
+	if err := persist.Save(&buf, &w.di, &w.m.AddressBook, &w.m.ArchCatalog, nil); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	target := newSparseWorld(t)
+	remap, err := persist.LoadMerge(&buf, &target.di, &target.m.AddressBook, &target.m.ArchCatalog, sparseRequests(&target.di))
+	if err != nil {
+		t.Fatalf("LoadMerge: %v", err)
+	}
+	assertStunned(t, &target.di, target.m, w.plain, remap)
+	assertStunned(t, &target.di, target.m, w.moving, remap)
+	assertStunned(t, &target.di, target.m, target.plain, nil)
+	if frozen := setOf[Frozen](t, &target.di, target.m); frozen.Len() != 2 || !frozen.Has(remap[w.moving[0]]) {
+		t.Errorf("expected %v and %v frozen, got %v", target.moving[0], remap[w.moving[0]], frozen.IDs())
+	}
+}
@@ -1,8 +1,12 @@
 package persist
 
 import (
+	"bytes"
+	"cmp"
 	"compress/gzip"
+	"fmt"
 	"io"
+	"slices"
 
 	"github.com/kjkrol/uid"
 
@@ -14,69 +18,286 @@ import (
 )
 
 // Save writes a full snapshot of the world — entity ID pool bookkeeping,
-// component definitions, archetype compositions, and per-entity component
-// data — to w, gzip-compressed.
-func Save(w io.Writer, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog) error {
+// component definitions, archetype compositions with their shared values,
+// and per-entity component data, sparse components included — to w,
+// gzip-compressed. If base is non-nil, it is reset to the written
+// snapshot, for a following [SaveDelta]. Per-chunk component values are
+// written once per chunk; see [Load] for how they come back.
+func Save(w io.Writer, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, base *Baseline) error {
+	id := newSnapshotID()
 	gw := gzip.NewWriter(w)
-	if err := saveTo(gw, defIndex, book, catalog); err != nil {
+	if err := saveTo(gw, fileHeader{Kind: kindFull, ID: id}, defIndex, book, catalog, Filter{}); err != nil {
 		return err
 	}
-	return gw.Close()
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	if base != nil {
+		base.capture(id, book, catalog, nil)
+	}
+	return nil
 }
 
-func saveTo(w io.Writer, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog) error {
-	if err := writeHeader(w); err != nil {
+// SaveOnly is Save restricted to the archetypes filter keeps, and to the
+// sparse component values of their entities. The ID pool bookkeeping is
+// still written whole; Load releases the IDs of entities left out. The
+// file does not hold the whole world, so no baseline moves on to it.
+func SaveOnly(w io.Writer, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, filter Filter) error {
+	gw := gzip.NewWriter(w)
+	if err := saveTo(gw, fileHeader{Kind: kindFull, ID: newSnapshotID()}, defIndex, book, catalog, filter); err != nil {
 		return err
 	}
+	return gw.Close()
+}
 
-	nextIndex, generations, freeIndices := book.PoolState()
-	if err := writeUint32(w, nextIndex); err != nil {
+func saveTo(w io.Writer, h fileHeader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, filter Filter) error {
+	var lives []arch.ID
+	for _, archID := range liveArchetypes(catalog) {
+		if filter.keeps(catalog.Archetypes[archID].Mask()) {
+			lives = append(lives, archID)
+		}
+	}
+	headers := make([]archHeader, len(lives))
+	for i, archID := range lives {
+		var err error
+		if headers[i], err = archHeaderOf(defIndex, &catalog.Archetypes[archID], nil); err != nil {
+			return err
+		}
+	}
+
+	if err := writeHeader(w, h); err != nil {
 		return err
 	}
-	if err := writeUint32Slice(w, generations); err != nil {
+	if err := writeSection(w, func(w io.Writer) error { return writePoolState(w, book) }); err != nil {
 		return err
 	}
-	if err := writeUint32Slice(w, freeIndices); err != nil {
+	if err := writeSection(w, func(w io.Writer) error { return writeComponentDirectory(w, defIndex) }); err != nil {
 		return err
 	}
-
-	if err := writeComponentDirectory(w, defIndex); err != nil {
+	if err := writeSection(w, func(w io.Writer) error { return writeSchemas(w, componentSchemas(defIndex)) }); err != nil {
 		return err
 	}
-
-	lives := liveArchetypes(catalog)
-	if err := writeUint32(w, uint32(len(lives))); err != nil {
+	if err := writeSection(w, func(w io.Writer) error { return writeArchDirectory(w, headers) }); err != nil {
 		return err
 	}
+
 	for _, archID := range lives {
+		if err := saveArchetypeData(w, &catalog.Archetypes[archID]); err != nil {
+			return err
+		}
+	}
+
+	var keep func(uid.UID64) bool
+	if !filter.keepsAll() {
+		keep = func(id uid.UID64) bool {
+			entry, _ := book.Get(id)
+			return filter.keeps(catalog.Archetypes[entry.ArchID].Mask())
+		}
+	}
+	return writeSparse(w, savedSparse(defIndex, catalog, keep))
+}
+
+// SaveDelta writes, gzip-compressed, only what changed since base: the ID
+// pool bookkeeping and component directory in full, the IDs removed since
+// base, and every entity of every chunk whose content changed. Sparse
+// components are not tracked per entity, so each is written whole. base must
+// hold a snapshot (from [Save], [Load], or a previous SaveDelta); on
+// success it moves on to the written one, so successive deltas chain.
+func SaveDelta(w io.Writer, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, base *Baseline) error {
+	if base.ID() == 0 {
+		return fmt.Errorf("persist: SaveDelta needs a base snapshot — Save or Load first")
+	}
+
+	id := newSnapshotID()
+	chunks := make(map[chunkKey]uint64, len(base.chunks))
+	gw := gzip.NewWriter(w)
+	if err := saveDeltaTo(gw, fileHeader{Kind: kindDelta, ID: id, Parent: base.ID()}, defIndex, book, catalog, base, chunks); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	base.capture(id, book, catalog, chunks)
+	return nil
+}
+
+// dirtyArchetype is one archetype's share of a delta: the chunks whose
+// rows are written, and their total entity count.
+type dirtyArchetype struct {
+	archID arch.ID
+	chunks map[int]bool
+	count  uint32
+}
+
+// saveDeltaTo writes a delta against base, filling chunks with the current
+// content hash of every non-empty chunk.
+func saveDeltaTo(w io.Writer, h fileHeader, defIndex *comp.DefIndex, book *addr.Book, catalog *arch.Catalog, base *Baseline, chunks map[chunkKey]uint64) error {
+	var dirty []dirtyArchetype
+	for archID := arch.RootID; archID < catalog.Len(); archID++ {
 		a := &catalog.Archetypes[archID]
-		var ids []uint8
-		for id := range a.Mask().AllSet() {
-			ids = append(ids, uint8(id))
+		always := alwaysDirty(a)
+		d := dirtyArchetype{archID: archID, chunks: make(map[int]bool)}
+		_ = walkTableChunks(&a.Table, func(idx int, cur *iter.Cursor) error {
+			key := chunkKey{archID, cur.Base}
+			sum := base.chunkHash(a, cur)
+			chunks[key] = sum
+			if prev, ok := base.chunks[key]; always || !ok || prev != sum {
+				d.chunks[idx] = true
+				d.count += uint32(len(cur.IDs))
+			}
+			return nil
+		})
+		if d.count > 0 {
+			dirty = append(dirty, d)
 		}
-		h := archHeader{CompIDs: ids, EntityCount: uint32(a.Len())}
-		if err := writeArchHeader(w, h); err != nil {
+	}
+	headers := make([]archHeader, len(dirty))
+	for i, d := range dirty {
+		var err error
+		if headers[i], err = archHeaderOf(defIndex, &catalog.Archetypes[d.archID], d.chunks); err != nil {
 			return err
 		}
 	}
 
-	for _, archID := range lives {
-		if err := saveArchetypeData(w, &catalog.Archetypes[archID]); err != nil {
+	if err := writeHeader(w, h); err != nil {
+		return err
+	}
+	if err := writeSection(w, func(w io.Writer) error { return writePoolState(w, book) }); err != nil {
+		return err
+	}
+	if err := writeSection(w, func(w io.Writer) error { return writeComponentDirectory(w, defIndex) }); err != nil {
+		return err
+	}
+	if err := writeSection(w, func(w io.Writer) error { return writeSchemas(w, componentSchemas(defIndex)) }); err != nil {
+		return err
+	}
+
+	removed := base.removedSince(book)
+	if err := writeSection(w, func(w io.Writer) error {
+		if err := writeUint32(w, uint32(len(removed))); err != nil {
+			return err
+		}
+		return writeIDs(w, removed)
+	}); err != nil {
+		return err
+	}
+
+	if err := writeSection(w, func(w io.Writer) error { return writeArchDirectory(w, headers) }); err != nil {
+		return err
+	}
+	for _, d := range dirty {
+		if err := saveArchetypeRows(w, &catalog.Archetypes[d.archID], d.chunks, writeSection); err != nil {
 			return err
 		}
 	}
+	return writeSparse(w, savedSparse(defIndex, catalog, nil))
+}
 
+func writePoolState(w io.Writer, book *addr.Book) error {
+	nextIndex, generations, freeIndices := book.PoolState()
+	if err := writeUint32(w, nextIndex); err != nil {
+		return err
+	}
+	if err := writeUint32Slice(w, generations); err != nil {
+		return err
+	}
+	return writeUint32Slice(w, freeIndices)
+}
+
+// compIDs lists a's composition, tags included, in ascending ID order.
+func compIDs(a *arch.Archetype) []uint8 {
+	var ids []uint8
+	for id := range a.Mask().AllSet() {
+		ids = append(ids, uint8(id))
+	}
+	return ids
+}
+
+// archHeaderOf builds a's archetype directory entry for the chunks (by
+// index) in only, or every chunk if only is nil.
+func archHeaderOf(defIndex *comp.DefIndex, a *arch.Archetype, only map[int]bool) (archHeader, error) {
+	shared, err := sharedEntries(defIndex, a)
+	if err != nil {
+		return archHeader{}, err
+	}
+	h := archHeader{CompIDs: compIDs(a), Shared: shared}
+	for _, def := range chunkDefs(a) {
+		h.Chunked = append(h.Chunked, uint8(def.ID))
+	}
+	_ = walkTableChunks(&a.Table, func(idx int, cur *iter.Cursor) error {
+		if only != nil && !only[idx] {
+			return nil
+		}
+		h.EntityCount += uint32(len(cur.IDs))
+		if len(h.Chunked) > 0 {
+			h.Runs = append(h.Runs, uint32(len(cur.IDs)))
+		}
+		return nil
+	})
+	return h, nil
+}
+
+// sharedEntries encodes the values of a's shared components, in ID order.
+func sharedEntries(defIndex *comp.DefIndex, a *arch.Archetype) ([]sharedEntry, error) {
+	var entries []sharedEntry
+	for _, v := range a.Composition().Shared {
+		var buf bytes.Buffer
+		if err := EncodeValue(&buf, defIndex.ByID(v.ID).Type, a.SharedValue(v.ID)); err != nil {
+			return nil, err
+		}
+		entries = append(entries, sharedEntry{CompID: uint8(v.ID), Value: buf.Bytes()})
+	}
+	return entries, nil
+}
+
+func writeArchDirectory(w io.Writer, headers []archHeader) error {
+	if err := writeUint32(w, uint32(len(headers))); err != nil {
+		return err
+	}
+	for _, h := range headers {
+		if err := writeArchHeader(w, h); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// columnDefs lists a's data-bearing components in ascending ID order — the
+// order a file holds their columns in, whatever order a's composition
+// gained them in.
+func columnDefs(a *arch.Archetype) []comp.Def {
+	defs := slices.Clone(a.Composition().Defs)
+	slices.SortFunc(defs, func(x, y comp.Def) int { return cmp.Compare(x.ID, y.ID) })
+	return defs
+}
+
+// chunkDefs lists a's per-chunk components in ascending ID order.
+func chunkDefs(a *arch.Archetype) []comp.Def {
+	defs := slices.Clone(a.Composition().Chunk)
+	slices.SortFunc(defs, func(x, y comp.Def) int { return cmp.Compare(x.ID, y.ID) })
+	return defs
+}
+
 func writeComponentDirectory(w io.Writer, defIndex *comp.DefIndex) error {
-	count := defIndex.Count()
-	if err := writeUint32(w, uint32(count)); err != nil {
+	return writeCompHeaders(w, componentDirectory(defIndex))
+}
+
+// componentDirectory lists every registered component's header, in comp.ID
+// order.
+func componentDirectory(defIndex *comp.DefIndex) []compHeader {
+	headers := make([]compHeader, defIndex.Count())
+	for i := range headers {
+		def := defIndex.ByID(comp.ID(i))
+		headers[i] = compHeader{Name: def.Key, Size: uint32(def.Size), Align: uint32(def.Align)}
+	}
+	return headers
+}
+
+func writeCompHeaders(w io.Writer, headers []compHeader) error {
+	if err := writeUint32(w, uint32(len(headers))); err != nil {
 		return err
 	}
-	for i := range count {
-		def := defIndex.ByID(comp.ID(i))
-		h := compHeader{Name: def.Type.String(), Size: uint32(def.Size), Align: uint32(def.Align)}
+	for _, h := range headers {
 		if err := writeComponentHeader(w, h); err != nil {
 			return err
 		}
@@ -96,26 +317,120 @@ func liveArchetypes(catalog *arch.Catalog) []arch.ID {
 	return lives
 }
 
-// saveArchetypeData writes a's entities as two flat, entity-count-long
-// passes over its table — first every id, then every data-bearing
-// component's values, one component at a time — decoupled from a's own
-// chunk boundaries so a freshly-created table on Load need not replicate
-// them.
+// saveArchetypeData writes a's entities as flat, entity-count-long passes
+// over its table — first every id, then every data-bearing component's
+// values, one component at a time, each pass its own section — decoupled
+// from a's own chunk boundaries so a freshly-created table on Load need not
+// replicate them. Each per-chunk component follows as one more pass, with a
+// value per chunk.
 func saveArchetypeData(w io.Writer, a *arch.Archetype) error {
+	return saveArchetypeRows(w, a, nil, writeSection)
+}
+
+// saveArchetypeRows is saveArchetypeData restricted to the chunks (by
+// index) in only, or every chunk if only is nil, with each pass written
+// through section.
+func saveArchetypeRows(w io.Writer, a *arch.Archetype, only map[int]bool, section func(io.Writer, func(io.Writer) error) error) error {
 	table := &a.Table
-	defs := a.Composition().Defs
+	defs := columnDefs(a)
+	walk := func(fn func(cur *iter.Cursor) error) error {
+		return walkTableChunks(table, func(idx int, cur *iter.Cursor) error {
+			if only != nil && !only[idx] {
+				return nil
+			}
+			return fn(cur)
+		})
+	}
 
-	if err := walkTableChunks(table, func(cur *iter.Cursor) error {
-		return writeIDs(w, cur.IDs)
+	if err := section(w, func(w io.Writer) error {
+		return walk(func(cur *iter.Cursor) error {
+			return writeIDs(w, cur.IDs)
+		})
 	}); err != nil {
 		return err
 	}
 
 	for _, def := range defs {
-		if err := walkTableChunks(table, func(cur *iter.Cursor) error {
-			for slot := range len(cur.IDs) {
-				ptr := table.ComponentAt(cur.Base, colstore.Slot(slot), def.ID)
-				if err := EncodeValue(w, def.Type, ptr); err != nil {
+		if err := section(w, func(w io.Writer) error {
+			return walk(func(cur *iter.Cursor) error {
+				for slot := range len(cur.IDs) {
+					ptr := table.ComponentAt(cur.Base, colstore.Slot(slot), def.ID)
+					if err := EncodeValue(w, def.Type, ptr); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, def := range chunkDefs(a) {
+		if err := section(w, func(w io.Writer) error {
+			return walk(func(cur *iter.Cursor) error {
+				return EncodeValue(w, def.Type, table.ChunkValueAt(cur.Base, def.ID))
+			})
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sparseRows is one sparse component's share of a save: the entities
+// written, and the positions of their values in the component's set.
+type sparseRows struct {
+	def comp.Def
+	set *colstore.SparseSet // nil if the component was never used
+	ids []uid.UID64
+	pos []int
+}
+
+// savedSparse lists every registered sparse component, in comp.ID order,
+// with the entities of its set that keep reports — all of them if keep is
+// nil.
+func savedSparse(defIndex *comp.DefIndex, catalog *arch.Catalog, keep func(uid.UID64) bool) []sparseRows {
+	var out []sparseRows
+	for i := range defIndex.Count() {
+		def := defIndex.ByID(comp.ID(i))
+		if !def.Sparse {
+			continue
+		}
+		rows := sparseRows{def: def, set: catalog.LookupSparse(def.ID)}
+		if rows.set != nil {
+			for p, id := range rows.set.IDs() {
+				if keep == nil || keep(id) {
+					rows.ids = append(rows.ids, id)
+					rows.pos = append(rows.pos, p)
+				}
+			}
+		}
+		out = append(out, rows)
+	}
+	return out
+}
+
+// writeSparse writes the sparse component directory, then each component's
+// entity IDs and, unless it is a tag, their values — each its own section.
+func writeSparse(w io.Writer, sparse []sparseRows) error {
+	headers := make([]sparseHeader, len(sparse))
+	for i, s := range sparse {
+		headers[i] = sparseHeader{CompID: uint8(s.def.ID), EntityCount: uint32(len(s.ids))}
+	}
+	if err := writeSection(w, func(w io.Writer) error { return writeSparseDirectory(w, headers) }); err != nil {
+		return err
+	}
+	for _, s := range sparse {
+		if err := writeSection(w, func(w io.Writer) error { return writeIDs(w, s.ids) }); err != nil {
+			return err
+		}
+		if s.def.Size == 0 {
+			continue
+		}
+		if err := writeSection(w, func(w io.Writer) error {
+			for _, p := range s.pos {
+				if err := EncodeValue(w, s.def.Type, s.set.At(p)); err != nil {
 					return err
 				}
 			}
@@ -127,15 +442,28 @@ func saveArchetypeData(w io.Writer, a *arch.Archetype) error {
 	return nil
 }
 
-// walkTableChunks calls fn once per non-empty chunk of table, in order.
-func walkTableChunks(table *colstore.Table, fn func(cur *iter.Cursor) error) error {
+func writeSparseDirectory(w io.Writer, headers []sparseHeader) error {
+	if err := writeUint32(w, uint32(len(headers))); err != nil {
+		return err
+	}
+	for _, h := range headers {
+		if err := writeSparseHeader(w, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkTableChunks calls fn once per non-empty chunk of table, in order,
+// with the chunk's index.
+func walkTableChunks(table *colstore.Table, fn func(idx int, cur *iter.Cursor) error) error {
 	var cur iter.Cursor
 	for from := 0; ; {
 		idx, ok := table.FillCursorNext(&cur, from, nil)
 		if !ok {
 			return nil
 		}
-		if err := fn(&cur); err != nil {
+		if err := fn(idx, &cur); err != nil {
 			return err
 		}
 		from = idx + 1
@@ -1,6 +1,8 @@
 package query
 
 import (
+	"unsafe"
+
 	"github.com/kjkrol/goke/v3/internal/arch"
 	"github.com/kjkrol/goke/v3/internal/colstore"
 	"github.com/kjkrol/goke/v3/iter"
@@ -10,8 +12,13 @@ type BakedTable struct {
 	ArchID      arch.ID
 	Table       *colstore.Table
 	CompOffsets []uintptr
+	Shared      []unsafe.Pointer // the archetype's tracked shared values
+	// ChunkOffsets locates the tracked per-chunk values within each chunk.
+	ChunkOffsets []uintptr
 }
 
 func (bt *BakedTable) FillCursorNext(cur *iter.Cursor, from int) (int, bool) {
+	cur.Shared = bt.Shared
+	cur.ChunkOffsets = bt.ChunkOffsets
 	return bt.Table.FillCursorNext(cur, from, bt.CompOffsets)
 }
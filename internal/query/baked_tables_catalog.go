@@ -1,6 +1,8 @@
 package query
 
 import (
+	"unsafe"
+
 	"github.com/kjkrol/goke/v3/internal/arch"
 	"github.com/kjkrol/goke/v3/internal/comp"
 )
@@ -13,10 +15,18 @@ type BakedTablesCatalog struct {
 // Add bakes the archetype into a BakedTable and registers it in the catalog.
 // compIDs defines which component columns are precomputed for iteration.
 func (c *BakedTablesCatalog) Add(archetype *arch.Archetype, compIDs []comp.ID) {
+	c.addOffsets(archetype, archetype.Table.BakeOffsets(compIDs), nil, nil)
+}
+
+// addOffsets registers the archetype with already-baked column offsets,
+// shared values and per-chunk value offsets.
+func (c *BakedTablesCatalog) addOffsets(archetype *arch.Archetype, offsets []uintptr, shared []unsafe.Pointer, chunkOffsets []uintptr) {
 	c.BakedTables = append(c.BakedTables, BakedTable{
-		ArchID:      archetype.Id,
-		Table:       &archetype.Table,
-		CompOffsets: archetype.Table.BakeOffsets(compIDs),
+		ArchID:       archetype.Id,
+		Table:        &archetype.Table,
+		CompOffsets:  offsets,
+		Shared:       shared,
+		ChunkOffsets: chunkOffsets,
 	})
 
 	if int(archetype.Id) >= len(c.archTableIndex) {
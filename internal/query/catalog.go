@@ -2,34 +2,65 @@ package query
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/kjkrol/goke/v3/internal/addr"
 	"github.com/kjkrol/goke/v3/internal/arch"
 	"github.com/kjkrol/goke/v3/internal/comp"
 )
 
+// Catalog owns every Matcher of a world. Matchers live in fixed-size
+// blocks that are never reallocated, so the pointers Add returns stay valid
+// as the catalog grows; a full block is followed by a new one as large as
+// all the previous ones together.
 type Catalog struct {
-	matchers    []Matcher
+	blocks      [][]Matcher
+	blockCap    int // capacity of the first block
 	cc          *comp.DefIndex
 	entityIndex *addr.Index
 	archCatalog *arch.Catalog
+	epoch       atomic.Uint64 // advanced by Touch; Range summaries from older epochs are stale
+	watched     comp.Mask     // components whose writes tables count; see Watch
+	indexes     []Index
+	free        []*Matcher // released slots, reused by Add
 }
 
 func (c *Catalog) Init(cc *comp.DefIndex, entityIndex *addr.Index, archCatalog *arch.Catalog, cfg Config) {
-	c.matchers = make([]Matcher, 0, cfg.Cap)
+	c.blockCap = max(cfg.Cap, 1)
+	c.blocks = [][]Matcher{make([]Matcher, 0, c.blockCap)}
 	c.cc = cc
 	c.entityIndex = entityIndex
 	c.archCatalog = archCatalog
 }
 
-// Add allocates the next free Matcher slot and returns a stable pointer to it.
-// Panics if MaxMatchers is exceeded — increase MaxMatchers in const.go if needed.
+// Add allocates the next free Matcher slot — reusing a released one first —
+// and returns a stable pointer to it, growing the catalog by a new block
+// when the last one is full.
 func (c *Catalog) Add() *Matcher {
-	if len(c.matchers) == cap(c.matchers) {
-		panic("query: matcher catalog capacity exceeded — increase MaxMatchers")
+	if n := len(c.free); n > 0 {
+		m := c.free[n-1]
+		c.free = c.free[:n-1]
+		return m
 	}
-	c.matchers = append(c.matchers, Matcher{})
-	return &c.matchers[len(c.matchers)-1]
+	last := &c.blocks[len(c.blocks)-1]
+	if len(*last) == cap(*last) {
+		c.blocks = append(c.blocks, make([]Matcher, 0, c.Len()))
+		last = &c.blocks[len(c.blocks)-1]
+	}
+	// A slot reused after Reset keeps its generation; see Matcher.gen.
+	*last = (*last)[:len(*last)+1]
+	m := &(*last)[len(*last)-1]
+	*m = Matcher{gen: m.gen}
+	return m
+}
+
+// Len returns the number of Matcher slots allocated, released ones included.
+func (c *Catalog) Len() int {
+	n := 0
+	for _, b := range c.blocks {
+		n += len(b)
+	}
+	return n
 }
 
 // NewMatcher creates a Matcher using Track/Include/Exclude opts.
@@ -48,21 +79,81 @@ func NewMatcher(c *Catalog, opts ...comp.AccessOpt) *Matcher {
 func (c *Catalog) AddMatcher(accessSpec *comp.AccessSpec) *Matcher {
 	matcher := c.Add()
 	matcher.Init(c.entityIndex, c.archCatalog, accessSpec)
+	matcher.catalog = c
 	for archID := arch.RootID; archID < c.archCatalog.Len(); archID++ {
 		matcher.BakeIfMatch(&c.archCatalog.Archetypes[archID])
 	}
 	return matcher
 }
 
+// Release clears m and returns its slot to Add, so it no longer receives
+// OnArchetypeCreated. Panics if m is not a live Matcher of c.
+func (c *Catalog) Release(m *Matcher) {
+	// Only live matchers of c point at it; Clear resets the pointer.
+	if m.catalog != c {
+		panic("query: Release of a Matcher that is not live in this catalog")
+	}
+	m.Clear()
+	c.free = append(c.free, m)
+}
+
 func (c *Catalog) OnArchetypeCreated(archetype *arch.Archetype) {
-	for i := range c.matchers {
-		c.matchers[i].BakeIfMatch(archetype)
+	for id := range c.watched.AllSet() {
+		archetype.Table.Watch(id)
+	}
+	for _, b := range c.blocks {
+		for i := range b {
+			if m := &b[i]; !m.released() {
+				m.BakeIfMatch(archetype)
+			}
+		}
 	}
 }
 
+// Touch marks every Range summary stale, so the next scan of each chunk
+// recomputes it — for values written other than through a Matcher, which
+// marks what it yields written itself.
+func (c *Catalog) Touch() { c.epoch.Add(1) }
+
+// Watch has every table holding one of ids, now or later, count the writes
+// marked to its column — done for the key of a Range and the components of
+// an Index, so that writers skip marking the columns nobody summarizes.
+func (c *Catalog) Watch(ids ...comp.ID) {
+	for _, id := range ids {
+		if c.watched.IsSet(id) {
+			continue
+		}
+		c.watched = c.watched.Set(id)
+		for archID := arch.RootID; archID < c.archCatalog.Len(); archID++ {
+			c.archCatalog.Archetypes[archID].Table.Watch(id)
+		}
+	}
+}
+
+// AddIndex registers ix to be refreshed by every Refresh.
+func (c *Catalog) AddIndex(ix Index) { c.indexes = append(c.indexes, ix) }
+
+// Refresh rebuilds every registered Index — called once all of a Sync's
+// mutations are applied, so lookups between Syncs see one consistent state.
+func (c *Catalog) Refresh() {
+	for _, ix := range c.indexes {
+		ix.Refresh()
+	}
+}
+
+// Reset releases every Matcher and detaches every Index, whose next use
+// panics: the components it reads are gone with the Matchers.
 func (c *Catalog) Reset() {
-	for i := range c.matchers {
-		c.matchers[i].Clear()
+	for _, ix := range c.indexes {
+		ix.detach()
+	}
+	c.indexes = nil
+	c.watched = comp.Mask{}
+	c.free = c.free[:0]
+	for _, b := range c.blocks {
+		for i := range b {
+			b[i].Clear()
+		}
 	}
-	c.matchers = c.matchers[:0]
+	c.blocks = [][]Matcher{c.blocks[0][:0]}
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/kjkrol/goke/v3/internal/comp"
 	"github.com/kjkrol/goke/v3/internal/ent"
+	"github.com/kjkrol/goke/v3/iter"
 )
 
 func newQueryCatalog() (*Catalog, *comp.DefIndex, *ent.Manager) {
@@ -70,3 +71,45 @@ func TestMatcherReactivity(t *testing.T) {
 		t.Errorf("Matcher incorrectly added an archetype that does not satisfy the TagA requirement")
 	}
 }
+
+func TestCatalog_ReleaseReusesSlot(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	var pos iter.ArrayRef[iterPos]
+
+	m := NewMatcher(cat, comp.Track(&pos))
+	cat.Release(m)
+	if len(m.BakedTables) != 0 || !m.released() {
+		t.Fatal("expected Release to clear the matcher")
+	}
+
+	// A released matcher is not baked into new archetypes.
+	var spec comp.AccessSpec
+	spec.Init(cc, comp.Track(&pos))
+	em.CreateFactory(spec)
+	if len(m.BakedTables) != 0 {
+		t.Error("expected a released matcher to ignore new archetypes")
+	}
+
+	if again := NewMatcher(cat, comp.Track(&pos)); again != m || len(again.BakedTables) != 1 {
+		t.Error("expected the released slot to be reused and baked afresh")
+	}
+	if cat.Len() != 1 {
+		t.Errorf("expected 1 matcher slot in use, got %d", cat.Len())
+	}
+}
+
+func TestCatalog_ReleasePanics(t *testing.T) {
+	cat, _, _ := newQueryCatalog()
+	m := NewMatcher(cat)
+	cat.Release(m)
+	for name, m := range map[string]*Matcher{"twice": m, "foreign": new(Matcher)} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			cat.Release(m)
+		})
+	}
+}
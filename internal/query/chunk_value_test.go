@@ -0,0 +1,75 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+type chunkLOD struct{ Level int32 }
+
+func TestMatcher_TrackChunk(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	cc.InternWith(reflect.TypeFor[chunkLOD](), comp.RegSpec{PerChunk: true})
+	pos := new(iter.ArrayRef[iterPos])
+	var lod iter.ChunkRef[chunkLOD]
+
+	var spec comp.AccessSpec
+	spec.Init(cc, comp.Track(pos), comp.TrackChunk(&lod))
+	f := em.CreateFactory(spec)
+	want := map[uid.UID64]int32{}
+	var level int32
+	chunkCap := 0
+	for f.Create(3 * 1024); f.Next(); {
+		level++
+		lod.Get(&f.Cursor).Level = level
+		for _, id := range f.IDs {
+			want[id] = level
+		}
+		chunkCap = max(chunkCap, len(f.IDs))
+	}
+	if level < 2 {
+		t.Fatalf("expected the entities to span several chunks, got %d", level)
+	}
+
+	m := NewMatcher(cat, comp.Track(pos), comp.TrackChunk(&lod))
+	seen := 0
+	for m.All(); m.Next(); {
+		got := lod.Get(&m.Cursor).Level
+		for _, id := range m.Cursor.IDs {
+			if want[id] != got {
+				t.Fatalf("All: entity %v read level %d, want %d", id, got, want[id])
+			}
+			seen++
+		}
+	}
+	if seen != len(want) {
+		t.Errorf("All: expected %d entities, got %d", len(want), seen)
+	}
+
+	skip := ChunkFunc(func(cur *iter.Cursor) bool { return lod.Get(cur).Level != 1 })
+	seen = 0
+	for m.Where(skip).All(); m.Next(); {
+		if lod.Get(&m.Cursor).Level == 1 {
+			t.Fatal("Where: expected the level-1 chunk to be skipped")
+		}
+		seen += len(m.Slots)
+	}
+	if seen != len(want)-chunkCap {
+		t.Errorf("Where: expected %d entities, got %d", len(want)-chunkCap, seen)
+	}
+	m.Where()
+
+	for id, level := range want {
+		if !m.SeekMatching(id) || lod.Get(&m.Cursor).Level != level {
+			t.Fatalf("SeekMatching: expected level %d for %v", level, id)
+		}
+		if !m.Seek(id) || lod.Get(&m.Cursor).Level != level {
+			t.Fatalf("Seek: expected level %d for %v", level, id)
+		}
+	}
+}
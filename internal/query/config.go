@@ -1,11 +1,11 @@
 package query
 
 type Config struct {
-	Cap int
+	Cap int // capacity of the Catalog's first block of Matchers
 }
 
 func DefaultConfig() Config {
 	return Config{
-		Cap: MaxMatchers,
+		Cap: MatcherBlockCap,
 	}
 }
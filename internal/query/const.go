@@ -1,8 +1,9 @@
 package query
 
 const (
-	// MaxMatchers is the maximum number of Matchers that can be registered in a Catalog.
-	// The Catalog pre-allocates this capacity once at Init time so that pointers
-	// to individual Matcher slots remain stable for the lifetime of the ECS world.
-	MaxMatchers = 64
+	// MatcherBlockCap is the default capacity of the Catalog's first block of
+	// Matchers. The Catalog grows past it by adding blocks, never moving a
+	// Matcher, so pointers to individual Matcher slots stay stable for the
+	// lifetime of the ECS world.
+	MatcherBlockCap = 64
 )
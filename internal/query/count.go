@@ -0,0 +1,93 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+var (
+	// ErrNoMatch is returned by Single when no entity matches.
+	ErrNoMatch = errors.New("no entity matches the query")
+	// ErrMultipleMatches is returned by Single when more than one entity matches.
+	ErrMultipleMatches = errors.New("more than one entity matches the query")
+)
+
+// Count returns the number of entities the Matcher matches, summed from its
+// baked tables' lengths — O(matched archetypes), no iteration. Sparse
+// components make it count entities: those of the smallest required
+// sparse set, or every matched entity if they are only excluded.
+func (m *Matcher) Count() int {
+	n := 0
+	if m.hasSparse() {
+		m.eachMatch(func(uid.UID64) bool { n++; return true })
+		return n
+	}
+	for i := range m.BakedTables {
+		n += int(m.BakedTables[i].Table.Len())
+	}
+	return n
+}
+
+// IsEmpty reports whether no entity matches, stopping at the first
+// non-empty baked table — or, under sparse components, the first match.
+func (m *Matcher) IsEmpty() bool {
+	if m.hasSparse() {
+		empty := true
+		m.eachMatch(func(uid.UID64) bool { empty = false; return false })
+		return empty
+	}
+	for i := range m.BakedTables {
+		if m.BakedTables[i].Table.Len() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Single returns the one entity the Matcher matches, with the Cursor
+// positioned on it as by Seek. It returns ErrNoMatch or ErrMultipleMatches
+// (wrapped with the count) otherwise, leaving the Cursor untouched.
+func (m *Matcher) Single() (uid.UID64, error) {
+	if m.hasSparse() {
+		return m.singleSparse()
+	}
+	var bt *BakedTable
+	for i := range m.BakedTables {
+		switch n := m.BakedTables[i].Table.Len(); {
+		case n == 0:
+			continue
+		case n > 1 || bt != nil:
+			return 0, fmt.Errorf("%w (%d)", ErrMultipleMatches, m.Count())
+		}
+		bt = &m.BakedTables[i]
+	}
+	if bt == nil {
+		return 0, ErrNoMatch
+	}
+	var cur iter.Cursor
+	bt.FillCursorNext(&cur, 0)
+	id := cur.IDs[0]
+	m.Seek(id)
+	return id, nil
+}
+
+// singleSparse is Single under sparse components, which needs a scan.
+func (m *Matcher) singleSparse() (uid.UID64, error) {
+	var found []uid.UID64
+	m.eachMatch(func(id uid.UID64) bool {
+		found = append(found, id)
+		return len(found) < 2
+	})
+	switch len(found) {
+	case 0:
+		return 0, ErrNoMatch
+	case 1:
+		m.Seek(found[0])
+		return found[0], nil
+	}
+	return 0, fmt.Errorf("%w (%d)", ErrMultipleMatches, m.Count())
+}
@@ -0,0 +1,53 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+func TestMatcher_CountIsEmptySingle(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	var pos iter.ArrayRef[iterPos]
+	m := NewMatcher(cat, comp.Track(&pos))
+
+	if m.Count() != 0 || !m.IsEmpty() {
+		t.Fatalf("expected an empty match, got Count %d", m.Count())
+	}
+	if _, err := m.Single(); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("Single on no match: got %v, want ErrNoMatch", err)
+	}
+
+	spawn := func(n int, opts ...comp.AccessOpt) *iter.Cursor {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(n)
+		f.Next()
+		return &f.Cursor
+	}
+	cur := spawn(1, comp.Track(&pos), comp.Track(new(iter.ArrayRef[iterVel])))
+	want := cur.IDs[0]
+	*pos.At(cur) = iterPos{X: 3}
+	spawn(4, comp.Track(new(iter.ArrayRef[iterVel]))) // not matched
+
+	id, err := m.Single()
+	if err != nil || id != want {
+		t.Fatalf("Single: got %v, %v; want %v", id, err, want)
+	}
+	if got := pos.At(&m.Cursor); got.X != 3 {
+		t.Errorf("expected the Cursor on the single entity, read %+v", *got)
+	}
+
+	// A second match in another archetype, then a second in the same one.
+	spawn(1, comp.Track(&pos))
+	if _, err := m.Single(); !errors.Is(err, ErrMultipleMatches) {
+		t.Errorf("Single on two archetypes: got %v, want ErrMultipleMatches", err)
+	}
+	spawn(5, comp.Track(&pos))
+	if m.Count() != 7 || m.IsEmpty() {
+		t.Errorf("expected Count 7, got %d", m.Count())
+	}
+}
@@ -5,11 +5,46 @@
 // # Matcher
 //
 // A [Matcher] filters archetypes by component mask (include and exclude
-// sets) and exposes three access patterns: All (chunk-by-chunk iteration),
-// Pick (per-entity iteration over a given entity subset), and Seek (direct
-// positioning on a single known entity, independent of the mask). Matchers
-// are built once at initialization and updated automatically as new
-// archetypes are created.
+// sets, plus any AnyOf/OneOf/AllOf groups — all evaluated once per
+// archetype, when it is baked) and exposes three access patterns: All
+// (chunk-by-chunk iteration), Pick (per-entity iteration over a given
+// entity subset), and Seek (direct positioning on a single known entity,
+// independent of the mask). Matchers are built once at initialization and
+// updated automatically as new archetypes are created.
+//
+// Contains tests one entity against the mask through the same per-archetype
+// table, and SeekMatching is Seek guarded by it.
+//
+// PickSet is Pick for large unsorted batches: an [EntitySet] buckets its IDs
+// by archetype and chunk once, so iteration fills the Cursor per chunk, as
+// All does, and lists the selected slots in Slots. The grouping is kept
+// until an archetype it saw changes version (a removal or relocation).
+//
+// [Sorted] visits a Matcher's entities in the order of a key read at the
+// Cursor. Sort refreshes the keys in the previous order and repairs it with
+// a bounded insertion sort, rebuilding only when the matched set grows.
+//
+// Where adds value [Predicate]s to All-mode iteration: Next lists the
+// matching slots of each chunk in Slots. A [Range] predicate keeps each
+// chunk's min and max key, recomputed lazily once the chunk changes or a
+// write to the key column is marked for it, and skips chunks it cannot
+// match. Only the columns a Range or an Index reads are watched for
+// writes, so Matchers over other columns mark nothing.
+// A [ChunkFunc] judges a chunk as a whole, usually by its per-chunk
+// components, and skips it without testing any entity.
+//
+// Sparse components, which belong to no archetype, are tested per entity:
+// All-mode Next lists the passing slots in Slots, as under Where, and
+// Pick, PickSet, Contains and SeekMatching skip entities that fail. A
+// required sparse component makes Count walk the smallest such set.
+//
+// Tracked shared components — one value per archetype — are baked as
+// pointers next to the column offsets and set in Cursor.Shared with them.
+// Tracked per-chunk components are baked the same way, as offsets into
+// each chunk set in Cursor.ChunkOffsets.
+//
+// Count, IsEmpty and Single answer from the baked tables' lengths, without
+// iterating.
 //
 // SeekH is Seek's per-entity fast path: it skips the archetype-change and
 // alive checks, trusting the caller to have already established the target
@@ -20,10 +55,22 @@
 // For each matching archetype, a [BakedTable] stores a pointer to the
 // archetype's column table alongside precomputed per-column byte offsets.
 // At iteration time the hot path is pure pointer arithmetic — no column
-// lookup, no hash map.
+// lookup, no hash map. An optional column the archetype lacks is baked as
+// iter.Absent, so the check for it happens once per chunk (or per Seek into
+// a new archetype), never per entity.
+//
+// # Indexes
+//
+// [HashIndex] and [OrderedIndex] map a key read from each of a Matcher's
+// entities to their IDs, for exact-match and range lookups without a scan.
+// Registered with Catalog.AddIndex, they are rebuilt by Catalog.Refresh at
+// the end of every Sync; OrderedIndex re-sorts incrementally, like Sorted.
 //
 // # Catalog
 //
 // [Catalog] holds all registered Matchers and fans out to each matching
-// matcher whenever a new archetype is created.
+// matcher whenever a new archetype is created. Matchers are allocated in
+// blocks that never move, so the catalog grows without invalidating
+// Matcher pointers; Release clears a Matcher and returns its slot for reuse
+// by the next Add.
 package query
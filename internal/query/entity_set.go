@@ -0,0 +1,180 @@
+package query
+
+import (
+	"cmp"
+	"slices"
+	"unsafe"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/arch"
+	"github.com/kjkrol/goke/v3/internal/colstore"
+)
+
+// EntitySet is a reusable batch of entity IDs for Matcher.PickSet, grouped
+// by archetype and chunk so iteration visits each chunk once with the list
+// of selected slots in it — instead of resolving every ID and re-pointing
+// the Cursor at each archetype change, as Pick does. The grouping is built
+// on first use and rebuilt only when an archetype it saw has since removed
+// or relocated entities; appends don't invalidate it.
+type EntitySet struct {
+	ids []uid.UID64
+
+	owner  *Matcher // the matcher the grouping was built for; nil if none
+	gen    uint32   // owner's generation at grouping time
+	slots  []uint32
+	groups []setGroup
+	stamps []tableStamp
+}
+
+// setGroup is one chunk's run of slots within EntitySet.slots.
+type setGroup struct {
+	tableIdx   int // into the owner's BakedTables
+	chunkIdx   int
+	start, end int
+}
+
+// tableStamp records a table's structural version at grouping time.
+type tableStamp struct {
+	table   *colstore.Table
+	version uint32
+}
+
+// NewEntitySet returns a set holding ids.
+func NewEntitySet(ids []uid.UID64) *EntitySet {
+	s := &EntitySet{}
+	s.Reset(ids)
+	return s
+}
+
+// Reset replaces the set's IDs, dropping its grouping.
+func (s *EntitySet) Reset(ids []uid.UID64) {
+	s.ids = append(s.ids[:0], ids...)
+	s.owner = nil
+}
+
+// IDs returns the set's IDs, in the order given.
+func (s *EntitySet) IDs() []uid.UID64 { return s.ids }
+
+// stale reports whether s must be regrouped before m iterates it.
+func (s *EntitySet) stale(m *Matcher) bool {
+	if s.owner != m || s.gen != m.gen {
+		return true
+	}
+	for _, st := range s.stamps {
+		if st.table.Version() != st.version {
+			return true
+		}
+	}
+	return false
+}
+
+// setEntry is one resolved ID, sorted into place during grouping.
+type setEntry struct {
+	tableIdx int
+	chunkPtr unsafe.Pointer
+	slot     uint32
+}
+
+// group resolves s's IDs against m, dropping dead and non-matching ones,
+// and sorts the rest by table, chunk and slot. Duplicates collapse.
+func (s *EntitySet) group(m *Matcher) {
+	s.owner, s.gen = m, m.gen
+	s.slots, s.groups, s.stamps = s.slots[:0], s.groups[:0], s.stamps[:0]
+
+	entries := make([]setEntry, 0, len(s.ids))
+	stamped := make(map[arch.ID]bool)
+	for _, id := range s.ids {
+		entry, ok := m.EntityIndex.Get(id)
+		if !ok {
+			continue
+		}
+		// Every archetype holding one of the IDs is stamped, matching or
+		// not: an entity leaving it — perhaps into a matching one — bumps
+		// its version.
+		if !stamped[entry.ArchID] {
+			stamped[entry.ArchID] = true
+			table := &m.archCatalog.Archetypes[entry.ArchID].Table
+			s.stamps = append(s.stamps, tableStamp{table: table, version: table.Version()})
+		}
+		if uint(entry.ArchID) >= uint(len(m.archTableIndex)) || m.archTableIndex[entry.ArchID] < 0 {
+			continue
+		}
+		entries = append(entries, setEntry{
+			tableIdx: int(m.archTableIndex[entry.ArchID]),
+			chunkPtr: entry.ChunkPtr,
+			slot:     uint32(entry.Slot),
+		})
+	}
+	slices.SortFunc(entries, func(a, b setEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.tableIdx, b.tableIdx),
+			cmp.Compare(uintptr(a.chunkPtr), uintptr(b.chunkPtr)),
+			cmp.Compare(a.slot, b.slot),
+		)
+	})
+	entries = slices.CompactFunc(entries, func(a, b setEntry) bool { return a == b })
+
+	for i, e := range entries {
+		if i == 0 || e.tableIdx != entries[i-1].tableIdx || e.chunkPtr != entries[i-1].chunkPtr {
+			if len(s.groups) > 0 {
+				s.groups[len(s.groups)-1].end = len(s.slots)
+			}
+			table := m.BakedTables[e.tableIdx].Table
+			s.groups = append(s.groups, setGroup{
+				tableIdx: e.tableIdx,
+				chunkIdx: int(table.ChunkIdxByPtr(e.chunkPtr)),
+				start:    len(s.slots),
+			})
+		}
+		s.slots = append(s.slots, e.slot)
+	}
+	if len(s.groups) > 0 {
+		s.groups[len(s.groups)-1].end = len(s.slots)
+	}
+}
+
+type setIter struct {
+	set      *EntitySet
+	groupPos int
+	// Slots lists the selected entities' slots in the current PickSet
+	// chunk, ascending: index Comp[T].Slice and Cursor.IDs with them.
+	Slots []uint32
+}
+
+// PickSet starts iteration over set's entities that match, one chunk at a
+// time; advance with Next. Each step fills the Cursor for the whole chunk,
+// as All does, and Slots with the selected slots in it — so ChunkSnapshot
+// and bulk migration work on the picked subset too.
+func (m *Matcher) PickSet(set *EntitySet) *Matcher {
+	if set.stale(m) {
+		set.group(m)
+	}
+	m.mode = modePickSet
+	m.setIter = setIter{set: set, groupPos: -1}
+	m.seekLastArchID = arch.NullID // iteration repoints Cursor.Offsets
+	return m
+}
+
+// nextPickSet advances to the set's next chunk — skipping, under a sparse
+// filter, those where no selected entity passes it.
+func (m *Matcher) nextPickSet() bool {
+	for {
+		m.groupPos++
+		if m.groupPos >= len(m.set.groups) {
+			m.Cursor.IDs, m.Slots = nil, nil
+			return false
+		}
+		g := &m.set.groups[m.groupPos]
+		m.tableIdx, m.chunkIdx = g.tableIdx, g.chunkIdx
+		m.BakedTables[g.tableIdx].FillCursorNext(&m.Cursor, g.chunkIdx)
+		m.Slots = m.set.slots[g.start:g.end]
+		if m.hasSparse() {
+			if m.Slots = m.filterSlots(m.Slots); len(m.Slots) == 0 {
+				continue
+			}
+		}
+		m.markChunk()
+		return true
+	}
+}
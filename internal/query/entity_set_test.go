@@ -0,0 +1,147 @@
+package query
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// collectSet drains a PickSet iteration, returning the picked IDs and their
+// X positions in visiting order.
+func collectSet(m *Matcher, set *EntitySet, pos *iter.ArrayRef[iterPos]) (ids []uid.UID64, xs []float64) {
+	for m.PickSet(set); m.Next(); {
+		positions := pos.Slice(&m.Cursor)
+		for _, s := range m.Slots {
+			ids = append(ids, m.Cursor.IDs[s])
+			xs = append(xs, float64(positions[s].X))
+		}
+	}
+	return ids, xs
+}
+
+// IDs interleaved across archetypes are visited grouped by chunk, with
+// non-matching, dead and duplicate IDs dropped.
+func TestEntitySet_GroupsByChunk(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	var pos iter.ArrayRef[iterPos]
+	spawn := func(n int, x float32, opts ...comp.AccessOpt) []uid.UID64 {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(n)
+		f.Next()
+		for i := range f.IDs {
+			f.Cursor.Slot = uintptr(i)
+			pos.At(&f.Cursor).X = x
+		}
+		return slices.Clone(f.IDs)
+	}
+	a := spawn(3, 1, comp.Track(&pos))
+	b := spawn(3, 2, comp.Track(&pos), comp.Track(new(iter.ArrayRef[iterVel])))
+	other := spawn(1, 3, comp.Track(new(iter.ArrayRef[iterVel])))
+
+	m := NewMatcher(cat, comp.Track(&pos))
+	set := NewEntitySet([]uid.UID64{b[2], a[1], other[0], b[0], a[1], uid.UID64(999), a[2]})
+
+	var chunks int
+	for m.PickSet(set); m.Next(); {
+		chunks++
+		if snap := m.ChunkSnapshot(); snap.ChunkPtr != m.Cursor.Base {
+			t.Errorf("chunk %d: ChunkSnapshot does not describe the current chunk", chunks)
+		}
+	}
+	if chunks != 2 {
+		t.Errorf("expected 2 chunks, got %d", chunks)
+	}
+
+	ids, xs := collectSet(m, set, &pos)
+	if want := []uid.UID64{a[1], a[2], b[0], b[2]}; !slices.Equal(ids, want) {
+		t.Errorf("expected ids %v, got %v", want, ids)
+	}
+	if want := []float64{1, 1, 2, 2}; !slices.Equal(xs, want) {
+		t.Errorf("expected xs %v, got %v", want, xs)
+	}
+}
+
+// Removing an entity from an archetype the set saw relocates another one;
+// the set must regroup instead of visiting stale slots.
+func TestEntitySet_RegroupsAfterRemoval(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	var pos iter.ArrayRef[iterPos]
+	var spec comp.AccessSpec
+	spec.Init(cc, comp.Track(&pos))
+	f := em.CreateFactory(spec)
+	f.Create(4)
+	f.Next()
+	ids := slices.Clone(f.IDs)
+	for i := range ids {
+		f.Cursor.Slot = uintptr(i)
+		pos.At(&f.Cursor).X = float32(i)
+	}
+
+	m := NewMatcher(cat, comp.Track(&pos))
+	set := NewEntitySet([]uid.UID64{ids[3], ids[0]})
+	if got, _ := collectSet(m, set, &pos); len(got) != 2 {
+		t.Fatalf("expected 2 picked entities, got %d", len(got))
+	}
+
+	em.Remove(ids[0])
+	got, xs := collectSet(m, set, &pos)
+	if !slices.Equal(got, []uid.UID64{ids[3]}) || !slices.Equal(xs, []float64{3}) {
+		t.Errorf("expected only ids[3] with X=3 after removal, got %v %v", got, xs)
+	}
+}
+
+func TestEntitySet_Reset(t *testing.T) {
+	set := NewEntitySet([]uid.UID64{1, 2})
+	set.Reset([]uid.UID64{3})
+	if !slices.Equal(set.IDs(), []uid.UID64{3}) {
+		t.Errorf("expected IDs [3], got %v", set.IDs())
+	}
+}
+
+// A Matcher slot released and reused — by Add or after Reset — is a new
+// Matcher to a set grouped for its previous one.
+func TestEntitySet_RegroupsForReusedMatcher(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	var pos iter.ArrayRef[iterPos]
+	vel := new(iter.ArrayRef[iterVel])
+	spawn := func(x float32, opts ...comp.AccessOpt) uid.UID64 {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(1)
+		f.Next()
+		pos.At(&f.Cursor).X = x
+		return f.IDs[0]
+	}
+	a := spawn(1, comp.Track(&pos))
+	b := spawn(2, comp.Track(&pos), comp.Track(vel))
+	set := NewEntitySet([]uid.UID64{a, b})
+
+	m := NewMatcher(cat, comp.Track(&pos))
+	if got, _ := collectSet(m, set, &pos); len(got) != 2 {
+		t.Fatalf("expected 2 picked entities, got %d", len(got))
+	}
+	cat.Release(m)
+	reused := NewMatcher(cat, comp.Track(&pos), comp.Track(vel))
+	if reused != m {
+		t.Fatal("expected Add to reuse the released slot")
+	}
+	if got, xs := collectSet(reused, set, &pos); !slices.Equal(got, []uid.UID64{b}) || !slices.Equal(xs, []float64{2}) {
+		t.Errorf("after Release and Add: expected only b with X=2, got %v %v", got, xs)
+	}
+
+	cat.Reset()
+	first := NewMatcher(cat, comp.Track(&pos))
+	if first != m {
+		t.Fatal("expected Add after Reset to reuse the first slot")
+	}
+	if got, xs := collectSet(first, set, &pos); !slices.Equal(got, []uid.UID64{a, b}) || !slices.Equal(xs, []float64{1, 2}) {
+		t.Errorf("after Reset: expected a and b, got %v %v", got, xs)
+	}
+}
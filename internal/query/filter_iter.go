@@ -16,13 +16,16 @@ func (m *Matcher) nextPick() bool {
 			m.lastArchID = link.ArchID
 			if m.bt != nil {
 				m.Cursor.Offsets = m.bt.CompOffsets // set once per archetype change
+				m.Cursor.Shared = m.bt.Shared
+				m.Cursor.ChunkOffsets = m.bt.ChunkOffsets
 			}
 		}
-		if m.bt == nil {
+		if m.bt == nil || !m.sparseOK(e) {
 			continue
 		}
 		m.Entity = e
 		m.Cursor.Set(link.ChunkPtr, uintptr(link.Slot)) // per entity: chunk base + slot only
+		m.markEntity(m.bt.Table)
 		return true
 	}
 	return false
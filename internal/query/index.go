@@ -0,0 +1,189 @@
+package query
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// Index is a secondary index over a Matcher's entities, kept by the Catalog
+// and refreshed at every Sync — see Catalog.Refresh.
+type Index interface {
+	// Refresh rebuilds the index from the current component values, unless
+	// none of its entities were added, removed or written since the last.
+	Refresh()
+	detach()
+}
+
+// indexBase is what both indexes keep to tell whether a rebuild is due:
+// the state of the Matcher's tables as of the last one.
+type indexBase struct {
+	m        *Matcher
+	seen     []tableState // by baked table
+	epoch    uint64
+	detached bool
+}
+
+type tableState struct {
+	version, len, writes uint32
+}
+
+// newIndexBase makes m read-only — an index only reads its keys — and has
+// the Catalog count writes to m's components, which stale compares.
+func newIndexBase(m *Matcher) indexBase {
+	m.watch(m.compIDs...)
+	return indexBase{m: m.ReadOnly()}
+}
+
+// stale reports whether any of the Matcher's tables changed version or
+// length, had a tracked column marked written, or was joined by a new one,
+// or the Catalog's epoch advanced since the last call. The write counts
+// are atomic, so systems run in parallel may have marked them since.
+func (b *indexBase) stale() bool {
+	b.mustAttached()
+	changed := len(b.seen) != len(b.m.BakedTables) || b.epoch != b.m.Epoch()
+	b.epoch = b.m.Epoch()
+	for i := range b.m.BakedTables {
+		table := b.m.BakedTables[i].Table
+		st := tableState{version: table.Version(), len: table.Len()}
+		for _, id := range b.m.compIDs {
+			st.writes += table.Writes(id)
+		}
+		if i == len(b.seen) {
+			b.seen = append(b.seen, st)
+			continue
+		}
+		if b.seen[i] != st {
+			b.seen[i] = st
+			changed = true
+		}
+	}
+	return changed
+}
+
+// detach marks the index dead: its Catalog was Reset, releasing its Matcher.
+func (b *indexBase) detach() { b.detached = true }
+
+func (b *indexBase) mustAttached() {
+	if b.detached {
+		panic("query: index used after its Catalog was Reset")
+	}
+}
+
+// HashIndex maps a key read from each matched entity to the IDs holding it,
+// for exact-match lookups such as "the entity whose PlayerID is 42".
+type HashIndex[K comparable] struct {
+	indexBase
+	key func(cur *iter.Cursor) K
+	ids map[K][]uid.UID64
+}
+
+// NewHashIndex returns a HashIndex over m's entities, keyed by key read at
+// the Cursor. It is built at once; call Refresh (or register it with
+// Catalog.AddIndex) to keep it current.
+func NewHashIndex[K comparable](m *Matcher, key func(cur *iter.Cursor) K) *HashIndex[K] {
+	ix := &HashIndex[K]{indexBase: newIndexBase(m), key: key, ids: make(map[K][]uid.UID64)}
+	ix.Refresh()
+	return ix
+}
+
+// Refresh rebuilds the buckets in one pass over the matched chunks,
+// reusing their storage — unless the index is not stale.
+func (ix *HashIndex[K]) Refresh() {
+	if !ix.stale() {
+		return
+	}
+	for k, ids := range ix.ids {
+		ix.ids[k] = ids[:0]
+	}
+	cur := &ix.m.Cursor
+	for ix.m.All(); ix.m.Next(); {
+		for i, id := range cur.IDs {
+			cur.Slot = uintptr(i)
+			k := ix.key(cur)
+			ix.ids[k] = append(ix.ids[k], id)
+		}
+	}
+	for k, ids := range ix.ids {
+		if len(ids) == 0 {
+			delete(ix.ids, k)
+		}
+	}
+}
+
+// Get returns the IDs of the entities whose key is k, in storage order. The
+// slice is owned by the index and valid until the next Refresh.
+func (ix *HashIndex[K]) Get(k K) []uid.UID64 {
+	ix.mustAttached()
+	return ix.ids[k]
+}
+
+// Len returns the number of distinct keys.
+func (ix *HashIndex[K]) Len() int {
+	ix.mustAttached()
+	return len(ix.ids)
+}
+
+// OrderedIndex keeps a Matcher's entities sorted by a key read from their
+// components, for range scans such as "every entity with X in [a, b]".
+// Refresh re-sorts incrementally, as Sorted does.
+type OrderedIndex[K cmp.Ordered] struct {
+	indexBase
+	s   Sorted[K]
+	ids []uid.UID64 // entity IDs in s.entries order
+}
+
+// NewOrderedIndex returns an OrderedIndex over m's entities, keyed by key
+// read at the Cursor. It is built at once, like NewHashIndex.
+func NewOrderedIndex[K cmp.Ordered](m *Matcher, key func(cur *iter.Cursor) K) *OrderedIndex[K] {
+	ix := &OrderedIndex[K]{indexBase: newIndexBase(m), s: Sorted[K]{m: m, key: key}}
+	ix.Refresh()
+	return ix
+}
+
+// Refresh re-sorts the entities by their current keys, unless the index
+// is not stale.
+func (ix *OrderedIndex[K]) Refresh() {
+	if !ix.stale() {
+		return
+	}
+	ix.s.Sort()
+	ix.ids = ix.ids[:0]
+	for _, e := range ix.s.entries {
+		ix.ids = append(ix.ids, e.id)
+	}
+}
+
+// Range returns the IDs of the entities whose key lies in [lo, hi], in key
+// order. The slice is owned by the index and valid until the next Refresh.
+func (ix *OrderedIndex[K]) Range(lo, hi K) []uid.UID64 {
+	ix.mustAttached()
+	entries := ix.s.entries
+	if len(entries) == 0 || hi < lo {
+		return nil
+	}
+	// Both searches compare keys only, so i is the first entry with key >=
+	// lo and j the first with key > hi.
+	i, _ := slices.BinarySearchFunc(entries, lo, func(e sortedEntry[K], k K) int {
+		return cmp.Compare(e.key, k)
+	})
+	j, _ := slices.BinarySearchFunc(entries, hi, func(e sortedEntry[K], k K) int {
+		if e.key > k {
+			return 1
+		}
+		return -1
+	})
+	return ix.ids[i:j:j]
+}
+
+// Get returns the IDs of the entities whose key is k.
+func (ix *OrderedIndex[K]) Get(k K) []uid.UID64 { return ix.Range(k, k) }
+
+// Len returns the number of indexed entities.
+func (ix *OrderedIndex[K]) Len() int {
+	ix.mustAttached()
+	return len(ix.ids)
+}
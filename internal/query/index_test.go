@@ -0,0 +1,109 @@
+package query
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+func TestHashIndex(t *testing.T) {
+	cat, pos, ids := whereFixture(t) // X = 0..3, 100..103
+	m := NewMatcher(cat, comp.Track(pos))
+	ix := NewHashIndex(m, func(cur *iter.Cursor) int { return int(pos.At(cur).X) % 2 })
+
+	if got, want := ix.Get(1), []uid.UID64{ids[1], ids[3], ids[5], ids[7]}; !slices.Equal(got, want) {
+		t.Errorf("expected odd Xs %v, got %v", want, got)
+	}
+	if ix.Len() != 2 {
+		t.Errorf("expected 2 keys, got %d", ix.Len())
+	}
+
+	writer := NewMatcher(cat, comp.Track(pos))
+	writer.Seek(ids[0])
+	pos.At(&writer.Cursor).X = 7
+	cat.AddIndex(ix)
+	cat.Refresh()
+	if got := ix.Get(0); len(got) != 3 || slices.Contains(got, ids[0]) {
+		t.Errorf("after Refresh: expected ids[0] moved out of key 0, got %v", got)
+	}
+	if ix.Get(5) != nil {
+		t.Error("expected nil for a missing key")
+	}
+}
+
+func TestOrderedIndex_Range(t *testing.T) {
+	cat, pos, ids := whereFixture(t)
+	ix := NewOrderedIndex(NewMatcher(cat, comp.Track(pos)), func(cur *iter.Cursor) float32 { return pos.At(cur).X })
+
+	tests := []struct {
+		name   string
+		lo, hi float32
+		want   []uid.UID64
+	}{
+		{"spanning archetypes", 2, 101, []uid.UID64{ids[2], ids[3], ids[4], ids[5]}},
+		{"exact", 100, 100, []uid.UID64{ids[4]}},
+		{"gap", 4, 99, nil},
+		{"inverted", 3, 1, nil},
+		{"all", -1, 1000, ids},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ix.Range(tt.lo, tt.hi); !slices.Equal(got, tt.want) {
+				t.Errorf("Range(%v, %v) = %v, want %v", tt.lo, tt.hi, got, tt.want)
+			}
+		})
+	}
+	if ix.Len() != len(ids) {
+		t.Errorf("expected Len %d, got %d", len(ids), ix.Len())
+	}
+}
+
+// Refresh skips the rebuild while no indexed entity was added, removed or
+// written, and rebuilds once one was.
+func TestIndex_RefreshSkipsUnchanged(t *testing.T) {
+	cat, pos, ids := whereFixture(t)
+	var reads int
+	ix := NewOrderedIndex(NewMatcher(cat, comp.Track(pos)), func(cur *iter.Cursor) float32 { reads++; return pos.At(cur).X })
+	cat.AddIndex(ix)
+
+	reads = 0
+	cat.Refresh()
+	if reads != 0 {
+		t.Errorf("expected no key reads refreshing an unchanged index, got %d", reads)
+	}
+
+	NewMatcher(cat, comp.Track(pos)).ReadOnly().Seek(ids[0])
+	cat.Refresh()
+	if reads != 0 {
+		t.Errorf("expected a read-only Seek to leave the index current, got %d key reads", reads)
+	}
+
+	writer := NewMatcher(cat, comp.Track(pos))
+	writer.Seek(ids[0])
+	pos.At(&writer.Cursor).X = 1000
+	cat.Refresh()
+	if reads == 0 {
+		t.Fatal("expected a rebuild after a write")
+	}
+	if got := ix.Range(1000, 1000); !slices.Equal(got, ids[:1]) {
+		t.Errorf("expected %v, got %v", ids[:1], got)
+	}
+}
+
+func TestIndex_UseAfterReset_Panics(t *testing.T) {
+	cat, pos, _ := whereFixture(t)
+	ix := NewHashIndex(NewMatcher(cat, comp.Track(pos)), func(cur *iter.Cursor) float32 { return pos.At(cur).X })
+	cat.AddIndex(ix)
+	cat.Reset()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic using an index after Reset")
+		}
+	}()
+	ix.Get(0)
+}
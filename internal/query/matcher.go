@@ -1,6 +1,9 @@
 package query
 
 import (
+	"slices"
+	"unsafe"
+
 	"github.com/kjkrol/uid"
 
 	"github.com/kjkrol/goke/v3/internal/addr"
@@ -17,6 +20,8 @@ const (
 	modeNone iterMode = iota
 	modeAll
 	modePick
+	modePickSet
+	modeWhere
 )
 
 type allIter struct {
@@ -43,24 +48,36 @@ type Matcher struct {
 	archCatalog *arch.Catalog
 	includeMask comp.Mask
 	compIDs     []comp.ID
+	sharedIDs   []comp.ID // tracked shared components, in Cursor.Shared order
+	chunkIDs    []comp.ID // tracked per-chunk components, in Cursor.ChunkOffsets order
+	optional    []int     // positions in compIDs of optional columns
 	excludeMask comp.Mask
-	mode        iterMode
-	Cursor      iter.Cursor
+	groups      []comp.Group
+	preds       []Predicate
+	sparseFilter
+	slotBuf  []uint32
+	catalog  *Catalog // set while live in a Catalog
+	readOnly bool     // see ReadOnly
+	gen      uint32   // bumped by Clear, so state keyed by m can tell a reuse
+	mode     iterMode
+	Cursor   iter.Cursor
 	allIter
 	filterIter
+	setIter
 	seekTable      *colstore.Table
 	seekOffsets    [arch.MaxID][]uintptr
 	seekLastArchID arch.ID
+	seekShared     []unsafe.Pointer
 }
 
 func (m *Matcher) Init(entityIndex *addr.Index, archCatalog *arch.Catalog, accessSpec *comp.AccessSpec) {
 	includeMask := comp.NewMask(accessSpec)
 
+	if err := accessSpec.Conflicts(); err != nil {
+		panic("ECS Matcher Error: " + err.Error())
+	}
 	var excludeMask comp.Mask
 	for _, id := range accessSpec.ExCompIDs {
-		if includeMask.IsSet(id) {
-			panic("ECS Matcher Error: component cannot be both REQUIRED and EXCLUDED")
-		}
 		excludeMask = excludeMask.Set(id)
 	}
 
@@ -68,7 +85,29 @@ func (m *Matcher) Init(entityIndex *addr.Index, archCatalog *arch.Catalog, acces
 	m.archCatalog = archCatalog
 	m.includeMask = includeMask
 	m.compIDs = accessSpec.CompIDs()
+	m.sharedIDs = accessSpec.SharedIDs
+	m.chunkIDs = accessSpec.ChunkIDs()
+	m.optional = nil
+	for i, id := range m.compIDs {
+		if slices.Contains(accessSpec.OptIDs, id) {
+			m.optional = append(m.optional, i)
+		}
+	}
 	m.excludeMask = excludeMask
+	m.groups = accessSpec.Groups
+	m.sparseIn, m.sparseEx = nil, nil
+	for _, u := range accessSpec.Sparse {
+		set := archCatalog.SparseSet(u.Def)
+		if u.Bind != nil {
+			u.Bind(set)
+		}
+		switch {
+		case u.Exclude:
+			m.sparseEx = append(m.sparseEx, set)
+		case !u.Optional:
+			m.sparseIn = append(m.sparseIn, set)
+		}
+	}
 	m.seekLastArchID = arch.NullID
 }
 
@@ -77,23 +116,103 @@ func (m *Matcher) Clear() {
 	m.archCatalog = nil
 	m.includeMask = comp.Mask{}
 	m.compIDs = nil
+	m.sharedIDs = nil
+	m.chunkIDs = nil
+	m.optional = nil
 	m.excludeMask = comp.Mask{}
+	m.groups = nil
+	m.Where()
+	m.sparseFilter = sparseFilter{}
+	m.slotBuf = nil
+	m.catalog = nil
+	m.readOnly = false
 	m.BakedTablesCatalog.Clear()
+	m.setIter = setIter{}
 	m.seekTable = nil
 	m.seekOffsets = [arch.MaxID][]uintptr{}
 	m.seekLastArchID = arch.NullID
+	m.seekShared = nil
+	m.gen++
 }
 
+// released reports whether m is cleared — released to its Catalog, or not
+// yet initialized.
+func (m *Matcher) released() bool { return m.archCatalog == nil }
+
 func (m *Matcher) BakeIfMatch(archetype *arch.Archetype) {
-	if !archetype.Mask().IsEmpty() && archetype.Mask().Matches(m.includeMask, m.excludeMask) {
-		m.BakedTablesCatalog.Add(archetype, m.compIDs)
+	mask := archetype.Mask()
+	if !mask.IsEmpty() && mask.Matches(m.includeMask, m.excludeMask) && mask.Satisfies(m.groups) {
+		m.BakedTablesCatalog.addOffsets(archetype, m.bakeOffsets(archetype), m.bakeShared(archetype, nil),
+			archetype.Table.BakeChunkOffsets(m.chunkIDs))
+	}
+}
+
+// bakeShared appends archetype's values of the tracked shared components
+// to dst — nil for one it lacks, which only Seek can reach.
+func (m *Matcher) bakeShared(archetype *arch.Archetype, dst []unsafe.Pointer) []unsafe.Pointer {
+	for _, id := range m.sharedIDs {
+		dst = append(dst, archetype.SharedValue(id))
+	}
+	return dst
+}
+
+// bakeOffsets resolves the tracked columns' offsets in archetype, marking
+// each optional column it lacks iter.Absent.
+func (m *Matcher) bakeOffsets(archetype *arch.Archetype) []uintptr {
+	offsets := archetype.Table.BakeOffsets(m.compIDs)
+	for _, i := range m.optional {
+		if !archetype.Mask().IsSet(m.compIDs[i]) {
+			offsets[i] = iter.Absent
+		}
+	}
+	return offsets
+}
+
+// ReadOnly declares that nothing writes through m's Cursor, so iterating or
+// seeking it leaves the value summaries of Range predicates valid; see
+// markChunk. Returns m.
+func (m *Matcher) ReadOnly() *Matcher {
+	m.readOnly = true
+	return m
+}
+
+// markChunk records that the tracked columns of the chunk the Cursor is
+// filled for — by All or PickSet — may be written through it. Only watched
+// columns count the mark (see Catalog.Watch); a table without any costs
+// one check.
+func (m *Matcher) markChunk() {
+	if table := m.BakedTables[m.tableIdx].Table; !m.readOnly && table.Watching() {
+		table.MarkChunkWritten(colstore.Idx(m.chunkIdx), m.compIDs...)
+	}
+}
+
+// markEntity is markChunk for a Cursor set on one entity of table, by Pick
+// or Seek: its chunk index is not at hand, so the mark covers the table.
+func (m *Matcher) markEntity(table *colstore.Table) {
+	if !m.readOnly && table.Watching() {
+		table.MarkWritten(m.compIDs...)
+	}
+}
+
+// watch has the Catalog count writes to ids; see Catalog.Watch. A Matcher
+// outside a Catalog has no writers to notice.
+func (m *Matcher) watch(ids ...comp.ID) {
+	if m.catalog != nil {
+		m.catalog.Watch(ids...)
 	}
 }
 
 // All starts full chunk iteration over matched archetypes; advance with Next.
+// With Where predicates or sparse components set, Next skips chunks without
+// a match and lists the matching slots in Slots.
 func (m *Matcher) All() *Matcher {
 	m.mode = modeAll
+	if len(m.preds) > 0 || m.hasSparse() {
+		m.mode = modeWhere
+	}
 	m.allIter = allIter{chunkIdx: -1}
+	m.Slots = nil
+	m.seekLastArchID = arch.NullID // iteration repoints Cursor.Offsets
 	return m
 }
 
@@ -102,6 +221,7 @@ func (m *Matcher) All() *Matcher {
 func (m *Matcher) Pick(selected []uid.UID64) *Matcher {
 	m.mode = modePick
 	m.filterIter = filterIter{selected: selected, lastArchID: arch.NullID}
+	m.seekLastArchID = arch.NullID // iteration repoints Cursor.Offsets
 	return m
 }
 
@@ -109,9 +229,17 @@ func (m *Matcher) Pick(selected []uid.UID64) *Matcher {
 func (m *Matcher) Next() bool {
 	switch m.mode {
 	case modeAll:
-		return m.nextAll()
+		if !m.nextAll() {
+			return false
+		}
+		m.markChunk()
+		return true
 	case modePick:
 		return m.nextPick()
+	case modePickSet:
+		return m.nextPickSet()
+	case modeWhere:
+		return m.nextWhere()
 	}
 	return false
 }
@@ -125,21 +253,62 @@ func (m *Matcher) Seek(entID uid.UID64) bool {
 		return false
 	}
 	if entry.ArchID != m.seekLastArchID {
-		m.seekTable = &m.archCatalog.Archetypes[entry.ArchID].Table
+		archetype := &m.archCatalog.Archetypes[entry.ArchID]
+		m.seekTable = &archetype.Table
 		offs := m.seekOffsets[entry.ArchID]
 		if offs == nil {
-			offs = m.seekTable.BakeOffsets(m.compIDs)
+			offs = m.bakeOffsets(archetype)
 			m.seekOffsets[entry.ArchID] = offs
 		}
 		m.Cursor.Offsets = offs
+		if len(m.sharedIDs) > 0 {
+			m.seekShared = m.bakeShared(archetype, m.seekShared[:0])
+			m.Cursor.Shared = m.seekShared
+		}
+		if len(m.chunkIDs) > 0 {
+			m.Cursor.ChunkOffsets = archetype.Table.BakeChunkOffsets(m.chunkIDs)
+		}
+		m.seekLastArchID = entry.ArchID
+	}
+	m.Cursor.Set(entry.ChunkPtr, uintptr(entry.Slot))
+	m.markEntity(m.seekTable)
+	return true
+}
+
+// Contains reports whether entID is alive and its archetype matches the
+// Matcher's include/exclude masks and groups — an O(1) lookup in the
+// per-archetype table BakeIfMatch already keeps — and it passes the
+// sparse components' filter.
+func (m *Matcher) Contains(entID uid.UID64) bool {
+	entry, ok := m.EntityIndex.Get(entID)
+	return ok && m.Get(entry.ArchID) != nil && m.sparseOK(entID)
+}
+
+// SeekMatching is Seek restricted to matching entities: it positions the
+// Cursor only if Contains(entID), and reports whether it did.
+func (m *Matcher) SeekMatching(entID uid.UID64) bool {
+	entry, ok := m.EntityIndex.Get(entID)
+	if !ok {
+		return false
+	}
+	bt := m.Get(entry.ArchID)
+	if bt == nil || !m.sparseOK(entID) {
+		return false
+	}
+	if entry.ArchID != m.seekLastArchID {
+		m.seekTable = bt.Table
+		m.Cursor.Offsets = bt.CompOffsets
+		m.Cursor.Shared = bt.Shared
+		m.Cursor.ChunkOffsets = bt.ChunkOffsets
 		m.seekLastArchID = entry.ArchID
 	}
 	m.Cursor.Set(entry.ChunkPtr, uintptr(entry.Slot))
+	m.markEntity(m.seekTable)
 	return true
 }
 
 // ChunkSnapshot captures the chunk most recently advanced to by Next in All
-// mode, for use with CmdBuf.MassMigrate.
+// or PickSet mode, for use with CmdBuf.MassMigrate.
 func (m *Matcher) ChunkSnapshot() bulk.ChunkSnapshot {
 	bt := &m.BakedTables[m.tableIdx]
 	return bulk.ChunkSnapshot{
@@ -157,5 +326,9 @@ func (m *Matcher) ChunkSnapshot() bulk.ChunkSnapshot {
 func (m *Matcher) SeekH(entID uid.UID64) bool {
 	entry := m.EntityIndex.GetUnchecked(entID)
 	m.Cursor.Set(entry.ChunkPtr, uintptr(entry.Slot))
-	return entry.ArchID == m.seekLastArchID
+	if entry.ArchID != m.seekLastArchID {
+		return false
+	}
+	m.markEntity(m.seekTable)
+	return true
 }
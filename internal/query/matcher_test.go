@@ -1,8 +1,11 @@
 package query
 
 import (
+	"slices"
 	"testing"
 
+	"github.com/kjkrol/uid"
+
 	"github.com/kjkrol/goke/v3/internal/comp"
 	"github.com/kjkrol/goke/v3/internal/ent"
 	"github.com/kjkrol/goke/v3/iter"
@@ -185,7 +188,9 @@ func TestCatalog_Reset(t *testing.T) {
 	}
 }
 
-func TestCatalog_AddPanicsWhenFull(t *testing.T) {
+// The catalog grows past its configured capacity by adding blocks; earlier
+// Matchers must not move, and all of them keep receiving new archetypes.
+func TestCatalog_GrowsPastCap(t *testing.T) {
 	var cc comp.DefIndex
 	cc.Init()
 	var em ent.Manager
@@ -193,14 +198,112 @@ func TestCatalog_AddPanicsWhenFull(t *testing.T) {
 	cat.Init(&cc, &em.AddressBook.Index, &em.ArchCatalog, Config{Cap: 2})
 	em.Init(ent.DefaultConfig(), cat.OnArchetypeCreated)
 
-	cat.Add()
-	cat.Add()
+	var pos iter.ArrayRef[iterPos]
+	first := NewMatcher(cat, comp.Track(&pos))
+	var all []*Matcher
+	for range 9 {
+		all = append(all, NewMatcher(cat, comp.Track(&pos)))
+	}
+	if cat.Len() != 10 {
+		t.Fatalf("expected 10 matchers, got %d", cat.Len())
+	}
+
+	var spec comp.AccessSpec
+	spec.Init(&cc, comp.Track(&pos))
+	em.CreateFactory(spec)
+	// Had a block moved, the archetype would be baked into the copies, not
+	// into the Matchers behind these pointers.
+	for i, m := range append(all, first) {
+		if len(m.BakedTables) != 1 {
+			t.Errorf("matcher %d: expected the new archetype baked, got %d tables", i, len(m.BakedTables))
+		}
+	}
+}
+
+func TestMatcher_Optional(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+
+	spawn := func(opts ...comp.AccessOpt) []uid.UID64 {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(2)
+		f.Next()
+		return f.IDs
+	}
+	still := spawn(comp.Track(new(iter.ArrayRef[iterPos])))
+	moving := spawn(comp.Track(new(iter.ArrayRef[iterPos])), comp.Track(new(iter.ArrayRef[iterVel])))
+	spawn(comp.Track(new(iter.ArrayRef[iterVel]))) // no position: must not match
+
+	var pos iter.ArrayRef[iterPos]
+	var vel iter.ArrayRef[iterVel]
+	m := NewMatcher(cat, comp.Track(&pos), comp.Optional(&vel))
+
+	withVel, withoutVel := 0, 0
+	for m.All(); m.Next(); {
+		if len(pos.Slice(&m.Cursor)) != len(m.Cursor.IDs) {
+			t.Fatal("expected the required column in every chunk")
+		}
+		if v := vel.SliceOrNil(&m.Cursor); v != nil {
+			withVel += len(v)
+		} else {
+			withoutVel += len(m.Cursor.IDs)
+		}
+	}
+	if withVel != 2 || withoutVel != 2 {
+		t.Errorf("All: expected 2 entities with velocity and 2 without, got %d and %d", withVel, withoutVel)
+	}
+
+	for m.Pick(append(slices.Clone(still), moving...)); m.Next(); {
+		hasVel := slices.Contains(moving, m.Entity)
+		if got := vel.AtOrNil(&m.Cursor) != nil; got != hasVel {
+			t.Errorf("Pick %v: velocity present = %v, want %v", m.Entity, got, hasVel)
+		}
+	}
+
+	if !m.Seek(still[0]) || vel.AtOrNil(&m.Cursor) != nil || pos.At(&m.Cursor) == nil {
+		t.Error("Seek: expected a position and no velocity")
+	}
+	if !m.Seek(moving[0]) || vel.AtOrNil(&m.Cursor) == nil {
+		t.Error("Seek: expected a velocity")
+	}
+}
+
+func TestMatcher_InitPanicsOnOptionalExcluded(t *testing.T) {
+	cat, _, _ := newQueryCatalog()
 
 	defer func() {
 		if r := recover(); r == nil {
-			t.Error("expected panic when catalog capacity is exceeded")
+			t.Error("expected panic when same component is both optional and excluded")
 		}
 	}()
 
-	cat.Add()
+	NewMatcher(cat, comp.Optional(new(iter.ArrayRef[iterPos])), comp.Exclude[iterPos]())
+}
+
+func TestMatcher_AnyOf_BakesEachMatchingArchetype(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	type circle struct{ R float32 }
+
+	spawn := func(opts ...comp.AccessOpt) {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(1)
+		f.Next()
+	}
+	spawn(comp.Track(new(iter.ArrayRef[iterPos])))
+	spawn(comp.Track(new(iter.ArrayRef[iterVel])))
+
+	m := NewMatcher(cat, comp.AnyOf(comp.Include[iterPos](), comp.Include[iterVel]()))
+	if len(m.BakedTables) != 2 {
+		t.Fatalf("expected both archetypes baked, got %d", len(m.BakedTables))
+	}
+
+	// Archetypes created later are tested the same way.
+	spawn(comp.Track(new(iter.ArrayRef[circle])))
+	spawn(comp.Track(new(iter.ArrayRef[iterPos])), comp.Track(new(iter.ArrayRef[circle])))
+	if len(m.BakedTables) != 3 {
+		t.Errorf("expected the position+circle archetype baked too, got %d tables", len(m.BakedTables))
+	}
 }
@@ -176,3 +176,67 @@ func TestSeekH_DifferentArchetypeReportsMismatch(t *testing.T) {
 		t.Error("expected the suggested fallback Seek(eB) to succeed")
 	}
 }
+
+func TestSeek_AfterAll_RestoresOffsets(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	var pos iter.ArrayRef[iterPos]
+	spawn := func(opts ...comp.AccessOpt) *iter.Cursor {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(1)
+		f.Next()
+		return &f.Cursor
+	}
+	a := spawn(comp.Track(&pos))
+	e := a.IDs[0]
+	*pos.At(a) = iterPos{X: 7}
+	*pos.At(spawn(comp.Track(new(iter.ArrayRef[iterVel])), comp.Track(&pos))) = iterPos{X: 9}
+
+	m := NewMatcher(cat, comp.Track(&pos))
+	m.Seek(e)
+	for m.All(); m.Next(); {
+	}
+	if !m.Seek(e) || pos.At(&m.Cursor).X != 7 {
+		t.Errorf("Seek after All: expected X=7, got %+v", *pos.At(&m.Cursor))
+	}
+}
+
+func TestContains_SeekMatching(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	var pos iter.ArrayRef[iterPos]
+	spawn := func(opts ...comp.AccessOpt) *iter.Cursor {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(1)
+		f.Next()
+		return &f.Cursor
+	}
+	cur := spawn(comp.Track(&pos))
+	still := cur.IDs[0]
+	*pos.At(cur) = iterPos{X: 1}
+	moving := spawn(comp.Track(&pos), comp.Track(new(iter.ArrayRef[iterVel]))).IDs[0]
+
+	m := NewMatcher(cat, comp.Track(&pos), comp.Exclude[iterVel]())
+	if !m.Contains(still) || m.Contains(moving) || m.Contains(uid.UID64(1<<40)) {
+		t.Error("Contains: expected only the entity without velocity to match")
+	}
+
+	// A plain Seek into the excluded archetype must not make SeekMatching
+	// trust its cache.
+	if !m.Seek(moving) || m.SeekMatching(moving) {
+		t.Error("SeekMatching: expected the excluded entity to be refused")
+	}
+	if !m.SeekMatching(still) || pos.At(&m.Cursor).X != 1 {
+		t.Error("SeekMatching: expected the Cursor on the matching entity")
+	}
+	if !m.SeekH(still) {
+		t.Error("SeekH: expected the archetype cached by SeekMatching")
+	}
+
+	em.Remove(still)
+	if m.Contains(still) || m.SeekMatching(still) {
+		t.Error("expected a removed entity not to match")
+	}
+}
@@ -0,0 +1,84 @@
+package query
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+	"unsafe"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+type sharedLOD struct{ Level int32 }
+
+func TestMatcher_TrackShared(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	def := cc.InternWith(reflect.TypeFor[sharedLOD](), comp.RegSpec{Shared: true})
+	pos := new(iter.ArrayRef[iterPos])
+	want := map[uid.UID64]int32{}
+	var plain []uid.UID64
+	spawn := func(level int32, shared bool) {
+		var spec comp.AccessSpec
+		spec.Init(cc, comp.Track(pos))
+		var inits []comp.SharedInit
+		if shared {
+			inits = append(inits, comp.SharedInit{Def: def, Value: unsafe.Pointer(&sharedLOD{Level: level})})
+		}
+		f := em.CreateFactory(spec, inits...)
+		f.Create(3)
+		f.Next()
+		for _, id := range f.IDs {
+			if shared {
+				want[id] = level
+			} else {
+				plain = append(plain, id)
+			}
+		}
+	}
+	spawn(1, true)
+	spawn(2, true)
+	spawn(0, false)
+
+	var lod iter.SharedRef[sharedLOD]
+	m := NewMatcher(cat, comp.Track(pos), comp.TrackShared(&lod))
+	seen := 0
+	for m.All(); m.Next(); {
+		level := lod.Get(&m.Cursor).Level
+		for _, id := range m.Cursor.IDs {
+			if want[id] != level {
+				t.Errorf("All: entity %v read level %d, want %d", id, level, want[id])
+			}
+			seen++
+		}
+	}
+	if seen != len(want) {
+		t.Errorf("All: expected %d entities, got %d", len(want), seen)
+	}
+
+	picked := 0
+	for m.Pick(append(plain, slices.Collect(maps.Keys(want))...)); m.Next(); {
+		if got := lod.Get(&m.Cursor).Level; got != want[m.Entity] {
+			t.Errorf("Pick: entity %v read level %d, want %d", m.Entity, got, want[m.Entity])
+		}
+		picked++
+	}
+	if picked != len(want) {
+		t.Errorf("Pick: expected the entities without the component skipped, got %d", picked)
+	}
+
+	for id, level := range want {
+		if !m.SeekMatching(id) || lod.Get(&m.Cursor).Level != level {
+			t.Errorf("SeekMatching: expected level %d for %v", level, id)
+		}
+		if !m.Seek(id) || lod.Get(&m.Cursor).Level != level {
+			t.Errorf("Seek: expected level %d for %v", level, id)
+		}
+	}
+	if !m.Seek(plain[0]) || m.Cursor.Shared[lod.Idx] != nil {
+		t.Error("Seek: expected a nil value for an archetype without the component")
+	}
+}
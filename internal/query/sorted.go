@@ -0,0 +1,130 @@
+package query
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// Sorted orders a Matcher's entities by a key read from their components —
+// e.g. draw order by ZIndex. Sort keeps the previous order and refreshes the
+// keys in place, so when few keys changed re-sorting is a short insertion
+// sort rather than a full one; a change in the matched entity set rebuilds.
+// Ties are broken by entity ID, so the order is deterministic.
+type Sorted[K cmp.Ordered] struct {
+	m       *Matcher
+	key     func(cur *iter.Cursor) K
+	entries []sortedEntry[K]
+	pos     int
+	// Entity is the entity Next last positioned the Cursor on.
+	Entity uid.UID64
+}
+
+type sortedEntry[K cmp.Ordered] struct {
+	key K
+	id  uid.UID64
+}
+
+func compareEntries[K cmp.Ordered](a, b sortedEntry[K]) int {
+	return cmp.Or(cmp.Compare(a.key, b.key), cmp.Compare(a.id, b.id))
+}
+
+// NewSorted returns a Sorted over m's entities. key reads the sort key at
+// the Cursor's current entity, e.g. via ArrayRef.At on a column m tracks.
+func NewSorted[K cmp.Ordered](m *Matcher, key func(cur *iter.Cursor) K) *Sorted[K] {
+	return &Sorted[K]{m: m, key: key}
+}
+
+// Sort brings the order up to date and rewinds Next to the first entity.
+func (s *Sorted[K]) Sort() {
+	s.pos = 0
+	readOnly := s.m.readOnly
+	s.m.readOnly = true // reading the keys writes nothing
+	defer func() { s.m.readOnly = readOnly }()
+	if !s.refresh() {
+		s.rebuild()
+		slices.SortFunc(s.entries, compareEntries[K])
+		return
+	}
+	if !insertionSortLimited(s.entries, len(s.entries)/8+8) {
+		slices.SortFunc(s.entries, compareEntries[K])
+	}
+}
+
+// refresh rereads every known entity's key, dropping those that no longer
+// match. It reports false if the matched set gained entities, which only a
+// rebuild can find — or may have, under Where predicates, whose matches
+// cannot be counted without a scan.
+func (s *Sorted[K]) refresh() bool {
+	if len(s.entries) == 0 || len(s.m.preds) > 0 {
+		return false
+	}
+	kept := s.entries[:0]
+	for _, e := range s.entries {
+		if s.m.SeekMatching(e.id) {
+			kept = append(kept, sortedEntry[K]{key: s.key(&s.m.Cursor), id: e.id})
+		}
+	}
+	s.entries = kept
+	return len(kept) == s.m.Count()
+}
+
+// rebuild collects every matched entity and its key, chunk by chunk.
+func (s *Sorted[K]) rebuild() {
+	s.entries = s.entries[:0]
+	cur := &s.m.Cursor
+	for s.m.All(); s.m.Next(); {
+		if s.m.Slots != nil {
+			for _, slot := range s.m.Slots {
+				cur.Slot = uintptr(slot)
+				s.entries = append(s.entries, sortedEntry[K]{key: s.key(cur), id: cur.IDs[slot]})
+			}
+			continue
+		}
+		for i, id := range cur.IDs {
+			cur.Slot = uintptr(i)
+			s.entries = append(s.entries, sortedEntry[K]{key: s.key(cur), id: id})
+		}
+	}
+}
+
+// insertionSortLimited sorts entries by insertion, giving up once it has
+// shifted more than limit elements; it reports whether entries is sorted.
+// Nearly sorted input — a few changed keys — finishes well within limit.
+func insertionSortLimited[K cmp.Ordered](entries []sortedEntry[K], limit int) bool {
+	for i := 1; i < len(entries); i++ {
+		e := entries[i]
+		j := i
+		for ; j > 0 && compareEntries(e, entries[j-1]) < 0; j-- {
+			entries[j] = entries[j-1]
+			limit--
+		}
+		entries[j] = e
+		if limit < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of entities in the order as of the last Sort.
+func (s *Sorted[K]) Len() int { return len(s.entries) }
+
+// Next positions the Matcher's Cursor on the next entity in key order, as
+// by SeekMatching; entities that stopped matching since Sort — including
+// their Where predicates — are skipped.
+// Returns false when exhausted.
+func (s *Sorted[K]) Next() bool {
+	for s.pos < len(s.entries) {
+		id := s.entries[s.pos].id
+		s.pos++
+		if s.m.SeekMatching(id) && s.m.satisfies(&s.m.Cursor) {
+			s.Entity = id
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package query
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+func sortedXs(s *Sorted[float32], pos *iter.ArrayRef[iterPos]) []float32 {
+	var xs []float32
+	for s.Sort(); s.Next(); {
+		xs = append(xs, pos.At(&s.m.Cursor).X)
+	}
+	return xs
+}
+
+func TestSorted_OrdersByKeyAcrossArchetypes(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	var pos iter.ArrayRef[iterPos]
+	var ids []uid.UID64
+	spawn := func(xs []float32, opts ...comp.AccessOpt) {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(len(xs))
+		f.Next()
+		for i, x := range xs {
+			f.Cursor.Slot = uintptr(i)
+			pos.At(&f.Cursor).X = x
+		}
+		ids = append(ids, f.IDs...)
+	}
+	spawn([]float32{5, 1, 3}, comp.Track(&pos))
+	spawn([]float32{4, 2}, comp.Track(&pos), comp.Track(new(iter.ArrayRef[iterVel])))
+
+	m := NewMatcher(cat, comp.Track(&pos))
+	s := NewSorted(m, func(cur *iter.Cursor) float32 { return pos.At(cur).X })
+	if got := sortedXs(s, &pos); !slices.Equal(got, []float32{1, 2, 3, 4, 5}) {
+		t.Fatalf("expected ascending X, got %v", got)
+	}
+
+	// A changed key is picked up by the incremental path.
+	m.Seek(ids[0])
+	pos.At(&m.Cursor).X = 0
+	if got := sortedXs(s, &pos); !slices.Equal(got, []float32{0, 1, 2, 3, 4}) {
+		t.Errorf("after a key change: got %v", got)
+	}
+
+	// Removed entities drop out; new ones force a rebuild.
+	em.Remove(ids[1])
+	spawn([]float32{2.5}, comp.Track(&pos))
+	if got := sortedXs(s, &pos); !slices.Equal(got, []float32{0, 2, 2.5, 3, 4}) {
+		t.Errorf("after remove and spawn: got %v", got)
+	}
+	if s.Len() != 5 {
+		t.Errorf("expected Len 5, got %d", s.Len())
+	}
+}
+
+func TestInsertionSortLimited(t *testing.T) {
+	entries := func(keys ...int) []sortedEntry[int] {
+		out := make([]sortedEntry[int], len(keys))
+		for i, k := range keys {
+			out[i] = sortedEntry[int]{key: k, id: uid.UID64(i)}
+		}
+		return out
+	}
+	nearly := entries(1, 2, 4, 3, 5)
+	if !insertionSortLimited(nearly, 2) || !slices.IsSortedFunc(nearly, compareEntries[int]) {
+		t.Errorf("expected a nearly sorted slice to sort within the limit, got %v", nearly)
+	}
+	if insertionSortLimited(entries(5, 4, 3, 2, 1), 2) {
+		t.Error("expected a reversed slice to exceed the limit")
+	}
+}
@@ -0,0 +1,77 @@
+package query
+
+import (
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/colstore"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// sparseFilter holds a Matcher's sparse components: sets an entity must be
+// in and sets it must not be in. Sparse components belong to no archetype,
+// so, unlike the mask, they are tested per entity.
+type sparseFilter struct {
+	sparseIn []*colstore.SparseSet
+	sparseEx []*colstore.SparseSet
+}
+
+// hasSparse reports whether any sparse requirement or exclusion is set.
+func (f *sparseFilter) hasSparse() bool { return len(f.sparseIn)+len(f.sparseEx) > 0 }
+
+// sparseOK reports whether id passes every sparse requirement and exclusion.
+func (f *sparseFilter) sparseOK(id uid.UID64) bool {
+	for _, s := range f.sparseIn {
+		if !s.Has(id) {
+			return false
+		}
+	}
+	for _, s := range f.sparseEx {
+		if s.Has(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// eachMatch calls yield for the Matcher's matching entities, in no
+// particular order, until it returns false. With a required sparse
+// component it walks the smallest such set rather than every chunk.
+func (m *Matcher) eachMatch(yield func(uid.UID64) bool) {
+	if len(m.sparseIn) > 0 {
+		smallest := m.sparseIn[0]
+		for _, s := range m.sparseIn[1:] {
+			if s.Len() < smallest.Len() {
+				smallest = s
+			}
+		}
+		for _, id := range smallest.IDs() {
+			if m.Contains(id) && !yield(id) {
+				return
+			}
+		}
+		return
+	}
+	var cur iter.Cursor
+	for i := range m.BakedTables {
+		bt := &m.BakedTables[i]
+		for idx, ok := bt.FillCursorNext(&cur, 0); ok; idx, ok = bt.FillCursorNext(&cur, idx+1) {
+			for _, id := range cur.IDs {
+				if m.sparseOK(id) && !yield(id) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// filterSlots narrows slots of the chunk at the Cursor to the entities
+// passing the sparse filter, into slotBuf.
+func (m *Matcher) filterSlots(slots []uint32) []uint32 {
+	m.slotBuf = m.slotBuf[:0]
+	for _, slot := range slots {
+		if m.sparseOK(m.Cursor.IDs[slot]) {
+			m.slotBuf = append(m.slotBuf, slot)
+		}
+	}
+	return m.slotBuf
+}
@@ -0,0 +1,95 @@
+package query
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/colstore"
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+type sparseMark struct{ N int }
+
+// sparseFixture is whereFixture with sparseMark, a sparse component, added
+// to every entity at an odd position.
+func sparseFixture(t *testing.T) (*Catalog, comp.Def, []uid.UID64) {
+	t.Helper()
+	cat, _, ids := whereFixture(t)
+	def := cat.cc.InternWith(reflect.TypeFor[sparseMark](), comp.RegSpec{Sparse: true})
+	set := cat.archCatalog.SparseSet(def)
+	for i, id := range ids {
+		if i%2 == 1 {
+			(*sparseMark)(set.Upsert(id)).N = i
+		}
+	}
+	return cat, def, ids
+}
+
+func TestMatcher_SparseRequire(t *testing.T) {
+	cat, def, ids := sparseFixture(t)
+	var spec comp.AccessSpec
+	var bound *colstore.SparseSet
+	if err := spec.AddSparse(comp.SparseUse{Def: def, Bind: func(s any) { bound = s.(*colstore.SparseSet) }}); err != nil {
+		t.Fatal(err)
+	}
+	m := cat.AddMatcher(&spec)
+	if bound != cat.archCatalog.SparseSet(def) {
+		t.Fatal("expected Bind to receive the component's sparse set")
+	}
+
+	odd := []uid.UID64{ids[1], ids[3], ids[5], ids[7]}
+	if got := whereIDs(m); !slices.Equal(got, odd) {
+		t.Errorf("All: expected %v, got %v", odd, got)
+	}
+	var picked []uid.UID64
+	for m.Pick(ids); m.Next(); {
+		picked = append(picked, m.Entity)
+	}
+	if !slices.Equal(picked, odd) {
+		t.Errorf("Pick: expected %v, got %v", odd, picked)
+	}
+	if m.Contains(ids[0]) || !m.Contains(ids[1]) || m.SeekMatching(ids[2]) {
+		t.Error("expected Contains and SeekMatching to test the sparse set")
+	}
+	if m.Count() != 4 || m.IsEmpty() {
+		t.Errorf("expected Count 4, got %d", m.Count())
+	}
+	if _, err := m.Single(); err == nil {
+		t.Error("expected Single to report multiple matches")
+	}
+
+	var grouped []uid.UID64
+	for m.PickSet(NewEntitySet(ids[:4])); m.Next(); {
+		for _, s := range m.Slots {
+			grouped = append(grouped, m.Cursor.IDs[s])
+		}
+	}
+	if !slices.Equal(grouped, odd[:2]) {
+		t.Errorf("PickSet: expected %v, got %v", odd[:2], grouped)
+	}
+}
+
+func TestMatcher_SparseExclude(t *testing.T) {
+	cat, def, ids := sparseFixture(t)
+	var spec comp.AccessSpec
+	if err := spec.Forbid(def); err != nil {
+		t.Fatal(err)
+	}
+	m := cat.AddMatcher(&spec)
+
+	even := []uid.UID64{ids[0], ids[2], ids[4], ids[6]}
+	if got := whereIDs(m); !slices.Equal(got, even) {
+		t.Errorf("expected %v, got %v", even, got)
+	}
+	if m.Count() != 4 {
+		t.Errorf("expected Count 4, got %d", m.Count())
+	}
+
+	cat.archCatalog.SparseSet(def).Clear()
+	if m.Count() != len(ids) {
+		t.Errorf("expected every entity once the set is empty, got %d", m.Count())
+	}
+}
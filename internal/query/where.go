@@ -0,0 +1,203 @@
+package query
+
+import (
+	"cmp"
+
+	"github.com/kjkrol/goke/v3/internal/colstore"
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// Predicate filters a Matcher's entities by component value during
+// All-mode iteration. Before testing a chunk's entities one by one, the
+// Matcher asks every predicate whether the chunk can hold a match at all,
+// so a predicate keeping a per-chunk summary can skip it untested.
+type Predicate interface {
+	// MayMatch reports whether the chunk the Matcher's Cursor is filled
+	// for can hold a matching entity.
+	MayMatch(m *Matcher) bool
+	// Test reports whether the entity at cur matches.
+	Test(cur *iter.Cursor) bool
+}
+
+// PredicateFunc is a Predicate without a summary: it tests every entity.
+type PredicateFunc func(cur *iter.Cursor) bool
+
+func (f PredicateFunc) MayMatch(*Matcher) bool     { return true }
+func (f PredicateFunc) Test(cur *iter.Cursor) bool { return f(cur) }
+
+// ChunkFunc is a Predicate on a chunk as a whole, typically its per-chunk
+// components: chunks it rejects are skipped without testing any entity,
+// and every entity of a chunk it accepts matches.
+type ChunkFunc func(cur *iter.Cursor) bool
+
+func (f ChunkFunc) MayMatch(m *Matcher) bool { return f(&m.Cursor) }
+func (f ChunkFunc) Test(*iter.Cursor) bool   { return true }
+
+// Range matches entities whose key lies in [Lo, Hi]. It keeps the min and
+// max key of every chunk it has scanned, recomputed lazily when the chunk's
+// table changes version, the chunk's length changes, or a write to the key
+// column is marked for the chunk — which a writable Matcher does for every
+// chunk and entity it yields, and Catalog.Touch for all of them. Where has
+// the Catalog watch the key column, so tables count those marks. Chunks
+// whose range misses [Lo, Hi] are skipped. A Range belongs to the one
+// Matcher it is passed to with Where.
+type Range[K cmp.Ordered] struct {
+	Key    func(cur *iter.Cursor) K
+	Lo, Hi K
+	col    *int     // the key column's position in the Matcher's tracked components
+	owner  *Matcher // set by Where
+	keyID  comp.ID
+	sums   [][]rangeSummary[K] // by baked table, then chunk
+}
+
+type rangeSummary[K cmp.Ordered] struct {
+	min, max K
+	version  uint32
+	stamp    uint32
+	n        int
+	epoch    uint64
+	valid    bool
+}
+
+// NewRange returns a Range over key with the inclusive bounds lo and hi.
+// col points at the position of the column key reads among the tracked
+// components of the Matcher — an iter.ArrayRef's Idx, bound by the time
+// the Range is passed to Where.
+func NewRange[K cmp.Ordered](col *int, key func(cur *iter.Cursor) K, lo, hi K) *Range[K] {
+	return &Range[K]{Key: key, Lo: lo, Hi: hi, col: col}
+}
+
+// own binds r to m, or releases it when m is nil. It panics if r already
+// belongs to another Matcher: the summaries are kept by m's baked tables.
+func (r *Range[K]) own(m *Matcher) {
+	switch {
+	case m == nil || r.owner == nil:
+		r.owner, r.sums = m, nil
+		if m != nil {
+			r.keyID = m.compIDs[*r.col]
+			m.watch(r.keyID)
+		}
+	case r.owner != m:
+		panic("query: Range passed to Where of two Matchers")
+	}
+}
+
+func (r *Range[K]) Test(cur *iter.Cursor) bool {
+	k := r.Key(cur)
+	return k >= r.Lo && k <= r.Hi
+}
+
+func (r *Range[K]) MayMatch(m *Matcher) bool {
+	for len(r.sums) <= m.tableIdx {
+		r.sums = append(r.sums, nil)
+	}
+	chunks := r.sums[m.tableIdx]
+	for len(chunks) <= m.chunkIdx {
+		chunks = append(chunks, rangeSummary[K]{})
+	}
+	r.sums[m.tableIdx] = chunks
+
+	s := &chunks[m.chunkIdx]
+	table := m.BakedTables[m.tableIdx].Table
+	version, n, epoch := table.Version(), len(m.Cursor.IDs), m.Epoch()
+	stamp := table.WriteStamp(colstore.Idx(m.chunkIdx), r.keyID)
+	if !s.valid || s.version != version || s.n != n || s.stamp != stamp || s.epoch != epoch {
+		*s = rangeSummary[K]{version: version, stamp: stamp, n: n, epoch: epoch, valid: true}
+		r.summarize(s, &m.Cursor)
+	}
+	return n > 0 && s.max >= r.Lo && s.min <= r.Hi
+}
+
+// summarize records the min and max key of the chunk cur is filled for.
+func (r *Range[K]) summarize(s *rangeSummary[K], cur *iter.Cursor) {
+	for i := range cur.IDs {
+		cur.Slot = uintptr(i)
+		k := r.Key(cur)
+		if i == 0 || k < s.min {
+			s.min = k
+		}
+		if i == 0 || k > s.max {
+			s.max = k
+		}
+	}
+}
+
+// Where sets the predicates All-mode iteration filters by: Next then
+// advances only to chunks holding at least one entity that satisfies every
+// predicate, and lists those entities' slots in Slots. Pick, Seek, Contains
+// and Count stay mask-only. Where with no predicates clears them. A
+// predicate keeping per-Matcher state, such as a Range, panics when passed
+// to a second Matcher.
+func (m *Matcher) Where(preds ...Predicate) *Matcher {
+	for _, p := range m.preds {
+		if o, ok := p.(owned); ok {
+			o.own(nil)
+		}
+	}
+	for _, p := range preds {
+		if o, ok := p.(owned); ok {
+			o.own(m)
+		}
+	}
+	m.preds = preds
+	return m
+}
+
+// owned is a Predicate keeping per-Matcher state, bound by Where.
+type owned interface {
+	own(m *Matcher)
+}
+
+// Touch advances the Catalog's summary epoch; see Catalog.Touch.
+func (m *Matcher) Touch() {
+	if m.catalog != nil {
+		m.catalog.Touch()
+	}
+}
+
+// Epoch returns the Catalog's summary epoch; see Range.
+func (m *Matcher) Epoch() uint64 {
+	if m.catalog == nil {
+		return 0
+	}
+	return m.catalog.epoch.Load()
+}
+
+// satisfies reports whether the entity at cur passes every predicate.
+func (m *Matcher) satisfies(cur *iter.Cursor) bool {
+	for _, p := range m.preds {
+		if !p.Test(cur) {
+			return false
+		}
+	}
+	return true
+}
+
+// nextWhere advances to the next chunk holding a match for every predicate
+// and the sparse filter, filling Slots with the matching slots.
+func (m *Matcher) nextWhere() bool {
+	cur := &m.Cursor
+next:
+	for m.nextAll() {
+		for _, p := range m.preds {
+			if !p.MayMatch(m) {
+				continue next
+			}
+		}
+		m.slotBuf = m.slotBuf[:0]
+		for i, id := range cur.IDs {
+			cur.Slot = uintptr(i)
+			if m.sparseOK(id) && m.satisfies(cur) {
+				m.slotBuf = append(m.slotBuf, uint32(i))
+			}
+		}
+		if len(m.slotBuf) > 0 {
+			m.Slots = m.slotBuf
+			m.markChunk()
+			return true
+		}
+	}
+	m.Slots = nil
+	return false
+}
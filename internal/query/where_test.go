@@ -0,0 +1,188 @@
+package query
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// whereFixture spawns two archetypes holding X values 0..3 and 100..103.
+func whereFixture(t *testing.T) (*Catalog, *iter.ArrayRef[iterPos], []uid.UID64) {
+	t.Helper()
+	cat, cc, em := newQueryCatalog()
+	pos := new(iter.ArrayRef[iterPos])
+	var ids []uid.UID64
+	spawn := func(base float32, opts ...comp.AccessOpt) {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(4)
+		f.Next()
+		for i := range f.IDs {
+			f.Cursor.Slot = uintptr(i)
+			pos.At(&f.Cursor).X = base + float32(i)
+		}
+		ids = append(ids, f.IDs...)
+	}
+	spawn(0, comp.Track(pos))
+	spawn(100, comp.Track(pos), comp.Track(new(iter.ArrayRef[iterVel])))
+	return cat, pos, ids
+}
+
+func whereIDs(m *Matcher) []uid.UID64 {
+	var ids []uid.UID64
+	for m.All(); m.Next(); {
+		for _, s := range m.Slots {
+			ids = append(ids, m.Cursor.IDs[s])
+		}
+	}
+	return ids
+}
+
+func TestWhere_PredicateFunc(t *testing.T) {
+	cat, pos, ids := whereFixture(t)
+	m := NewMatcher(cat, comp.Track(pos)).Where(PredicateFunc(func(cur *iter.Cursor) bool {
+		return int(pos.At(cur).X)%2 == 1
+	}))
+	if got, want := whereIDs(m), []uid.UID64{ids[1], ids[3], ids[5], ids[7]}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	m.Where()
+	for m.All(); m.Next(); {
+		if m.Slots != nil {
+			t.Fatal("expected nil Slots without predicates")
+		}
+	}
+}
+
+// A Range skips chunks whose summary misses its bounds, reading no key in
+// them, until the epoch advances.
+func TestWhere_RangePrunesChunks(t *testing.T) {
+	cat, pos, ids := whereFixture(t)
+	var reads int
+	r := NewRange(&pos.Idx, func(cur *iter.Cursor) float32 { reads++; return pos.At(cur).X }, 101, 200)
+	m := NewMatcher(cat, comp.Track(pos)).ReadOnly().Where(r)
+
+	if got, want := whereIDs(m), ids[5:]; !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if reads != 8+4 {
+		t.Errorf("first scan: expected 12 key reads (summaries plus tests), got %d", reads)
+	}
+
+	reads = 0
+	whereIDs(m)
+	if reads != 4 {
+		t.Errorf("second scan: expected 4 key reads (low chunk pruned), got %d", reads)
+	}
+
+	m.Seek(ids[0])
+	pos.At(&m.Cursor).X = 150
+	m.Touch()
+	if got, want := whereIDs(m), append([]uid.UID64{ids[0]}, ids[5:]...); !slices.Equal(got, want) {
+		t.Errorf("after Touch: expected %v, got %v", want, got)
+	}
+}
+
+// A writable Matcher marks the chunks it yields written, so their summaries
+// are recomputed on the next scan while pruned chunks keep theirs — across
+// a Catalog.Refresh too.
+func TestWhere_RangeResummarizesYieldedChunks(t *testing.T) {
+	cat, pos, _ := whereFixture(t)
+	var reads int
+	r := NewRange(&pos.Idx, func(cur *iter.Cursor) float32 { reads++; return pos.At(cur).X }, 101, 200)
+	m := NewMatcher(cat, comp.Track(pos)).Where(r)
+
+	whereIDs(m)
+	cat.Refresh()
+	reads = 0
+	whereIDs(m)
+	if reads != 4+4 {
+		t.Errorf("second scan: expected 8 key reads (yielded chunk resummarized, low chunk pruned), got %d", reads)
+	}
+}
+
+// A write through another Matcher's Seek invalidates the written table's
+// summaries without a Touch.
+func TestWhere_RangeSeesWritesThroughOtherMatchers(t *testing.T) {
+	cat, pos, ids := whereFixture(t)
+	r := NewRange(&pos.Idx, func(cur *iter.Cursor) float32 { return pos.At(cur).X }, 101, 200)
+	m := NewMatcher(cat, comp.Track(pos)).Where(r)
+	whereIDs(m)
+
+	writer := NewMatcher(cat, comp.Track(pos))
+	writer.Seek(ids[0])
+	pos.At(&writer.Cursor).X = 150
+	if got, want := whereIDs(m), append([]uid.UID64{ids[0]}, ids[5:]...); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestWhere_RangeSharedBetweenMatchers_Panics(t *testing.T) {
+	cat, pos, _ := whereFixture(t)
+	r := NewRange(&pos.Idx, func(cur *iter.Cursor) float32 { return pos.At(cur).X }, 0, 1)
+	m := NewMatcher(cat, comp.Track(pos)).Where(r)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic passing a Range to a second Matcher")
+		}
+	}()
+	NewMatcher(cat, comp.Track(pos)).Where(r)
+	_ = m
+}
+
+// Clearing a Matcher releases its Range for another one.
+func TestWhere_RangeReleasedByClear(t *testing.T) {
+	cat, pos, ids := whereFixture(t)
+	r := NewRange(&pos.Idx, func(cur *iter.Cursor) float32 { return pos.At(cur).X }, 0, 1)
+	m := NewMatcher(cat, comp.Track(pos)).Where(r)
+	whereIDs(m)
+	cat.Release(m)
+
+	m2 := NewMatcher(cat, comp.Track(pos)).Where(r)
+	if got, want := whereIDs(m2), ids[:2]; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// Where watches the Range's key column, in archetypes created later too;
+// writes to other columns stay unrecorded.
+func TestWhere_RangeWatchesItsKeyColumn(t *testing.T) {
+	cat, cc, em := newQueryCatalog()
+	pos, vel := new(iter.ArrayRef[iterPos]), new(iter.ArrayRef[iterVel])
+	spawn := func(opts ...comp.AccessOpt) {
+		var spec comp.AccessSpec
+		spec.Init(cc, opts...)
+		f := em.CreateFactory(spec)
+		f.Create(4)
+		f.Next()
+	}
+	spawn(comp.Track(pos), comp.Track(vel))
+
+	m := NewMatcher(cat, comp.Track(pos))
+	m.Where(NewRange(&pos.Idx, func(cur *iter.Cursor) float32 { return pos.At(cur).X }, 0, 1))
+	spawn(comp.Track(pos))
+	posID, velID := m.compIDs[0], NewMatcher(cat, comp.Track(vel)).compIDs[0]
+
+	for _, bt := range m.BakedTables {
+		if !bt.Table.Watching() {
+			t.Fatal("expected every table holding the key column to watch it")
+		}
+	}
+	writer := NewMatcher(cat, comp.Track(pos), comp.Track(vel))
+	for writer.All(); writer.Next(); {
+	}
+	table := writer.BakedTables[0].Table
+	if table.Writes(posID) == 0 {
+		t.Error("expected the write to the key column to be counted")
+	}
+	if table.Writes(velID) != 0 {
+		t.Error("expected the write to an unwatched column to go unrecorded")
+	}
+}
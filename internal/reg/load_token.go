@@ -3,16 +3,27 @@ package reg
 import (
 	"fmt"
 	"reflect"
+
+	"github.com/kjkrol/goke/v3/internal/comp"
 )
 
 // CompToken is a component-type token for Load, produced by LoadComp[T]().
 // Load matches tokens against the save file's component directory by name,
 // not by position — pass them to Load in any order.
 type CompToken struct {
-	// Name is the real Go type's name (reflect.Type.String()), used to
-	// match this token against a save file's component directory entry.
+	// Name is the type's key — its WithName name, or its package-qualified
+	// comp.TypeKey — used to match this token against a save file's
+	// component directory entry.
 	Name string
 
+	// Aliases are further keys this token matches: its WithAliases names,
+	// plus its TypeKey when Name replaces it.
+	Aliases []string
+
+	// LegacyName is the type's bare reflect.Type.String(), matched instead
+	// of Name when loading a save file written before Name was qualified.
+	LegacyName string
+
 	// Register validates and registers the type. wantSize is the matching
 	// directory entry's recorded size, or nil if this token's type is not
 	// present in the save file (registered anyway, for forward
@@ -22,15 +33,25 @@ type CompToken struct {
 
 // LoadComp declares that a Load call may need to register component type
 // T — see [Registry.Load]. The order tokens are passed to Load does not matter.
-func LoadComp[T any]() CompToken {
+// opts must match the ones T is registered with elsewhere (see
+// [Registry.RegComp]); a WithName token also matches data recorded under
+// T's TypeKey, from before the name was assigned.
+func LoadComp[T any](opts ...comp.RegOpt) CompToken {
 	t := reflect.TypeFor[T]()
+	spec := comp.NewRegSpec(opts...)
+	name, aliases := comp.TypeKey(t), spec.Aliases
+	if spec.Name != "" && spec.Name != name {
+		name, aliases = spec.Name, append(aliases, name)
+	}
 	return CompToken{
-		Name: t.String(),
+		Name:       name,
+		Aliases:    aliases,
+		LegacyName: t.String(),
 		Register: func(r *Registry, wantSize *uint32) error {
 			if wantSize != nil && uint32(t.Size()) != *wantSize {
 				return fmt.Errorf("goke: Load: component %q size mismatch: save file has %d bytes, current type has %d", t, *wantSize, t.Size())
 			}
-			r.RegComp(t)
+			r.RegComp(t, opts...)
 			return nil
 		},
 	}
@@ -1,6 +1,7 @@
 package reg
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"reflect"
@@ -23,6 +24,8 @@ type Registry struct {
 	sharedRemover  *ent.Remover
 	paused         bool
 	saving         bool
+	baseline       persist.Baseline // the last snapshot saved or loaded, for SaveDelta/LoadDeltas
+	saves          pendingSaves     // SaveAsync jobs waiting for the next Sync
 }
 
 func (r *Registry) Init(cfg Config) {
@@ -34,8 +37,16 @@ func (r *Registry) Init(cfg Config) {
 	})
 }
 
-func (r *Registry) RegComp(compType reflect.Type) comp.ID {
-	return r.CompDefIndex.Intern(compType).ID
+// RegComp registers compType, optionally under a stable name and aliases
+// (see [comp.DefIndex.InternWith]).
+func (r *Registry) RegComp(compType reflect.Type, opts ...comp.RegOpt) comp.ID {
+	if err := persist.ValidateRefs(compType); err != nil {
+		panic(fmt.Sprintf("goke: cannot register component %s: %v", compType, err))
+	}
+	if len(opts) == 0 {
+		return r.CompDefIndex.Intern(compType).ID
+	}
+	return r.CompDefIndex.InternWith(compType, comp.NewRegSpec(opts...)).ID
 }
 
 func (r *Registry) CreateFactory(opts ...comp.EditOpt) *ent.Factory {
@@ -50,15 +61,52 @@ func (r *Registry) CreateFactory(opts ...comp.EditOpt) *ent.Factory {
 			panic(err)
 		}
 	}
-	return r.EntityManager.CreateFactory(accessSpec)
+	for _, def := range spec.ChunkDefs {
+		if err := accessSpec.Chunk(def); err != nil {
+			panic(err)
+		}
+	}
+	return r.EntityManager.CreateFactory(accessSpec, spec.Shared...)
+}
+
+// SetChunkBytes sizes the chunks of the archetype made of exactly the
+// components opts add for bytes instead of the configured target, keeping
+// the configured cache-set geometry. Fails once that archetype exists.
+func (r *Registry) SetChunkBytes(bytes int, opts ...comp.EditOpt) error {
+	if bytes <= 0 {
+		return fmt.Errorf("goke: SetChunkBytes: chunk size must be positive, got %d", bytes)
+	}
+	var spec comp.EditSpec
+	spec.Init(&r.CompDefIndex, opts...)
+	if len(spec.AddDefs) == 0 && len(spec.ChunkDefs) == 0 {
+		return fmt.Errorf("goke: SetChunkBytes: no components given")
+	}
+	var mask comp.Mask
+	for _, def := range spec.AddDefs {
+		mask = mask.Set(def.ID)
+	}
+	for _, def := range spec.ChunkDefs {
+		mask = mask.Set(def.ID)
+	}
+	g := r.EntityManager.ArchCatalog.Geometry
+	g.Bytes = uintptr(bytes)
+	return r.EntityManager.ArchCatalog.SetGeometryFor(mask, g)
 }
 
 func (r *Registry) Remove(entID uid.UID64) bool {
 	return r.EntityManager.Remove(entID)
 }
 
-func (r *Registry) UpsertComp(entID uid.UID64, compID comp.ID) (unsafe.Pointer, error) {
-	return r.EntityManager.UpsertComp(entID, r.CompDefIndex.ByID(compID))
+// UpsertComp ensures entID has compID and returns its slot for the value to
+// be copied into. A shared component is set from value instead, moving the
+// entity to the archetype holding it, and returns a nil slot. A per-chunk
+// component's slot is its value in the entity's chunk.
+func (r *Registry) UpsertComp(entID uid.UID64, compID comp.ID, value unsafe.Pointer) (unsafe.Pointer, error) {
+	def := r.CompDefIndex.ByID(compID)
+	if def.Shared {
+		return nil, r.EntityManager.SetShared(entID, def, value)
+	}
+	return r.EntityManager.UpsertComp(entID, def)
 }
 
 func (r *Registry) RemoveComp(entID uid.UID64, compID comp.ID) error {
@@ -71,6 +119,30 @@ func (r *Registry) AddMatcher(opts ...comp.AccessOpt) *query.Matcher {
 	return r.MatcherCatalog.AddMatcher(&accessSpec)
 }
 
+// ParseMatcher adds a Matcher for the query expression src (see
+// comp.ParseFilter), returning the Defs of its tracked columns in track
+// order. A malformed expression, an unknown or ambiguous component name,
+// or a contradictory filter is an error, and adds nothing.
+func (r *Registry) ParseMatcher(src string) (*query.Matcher, []comp.Def, error) {
+	opts, err := comp.ParseFilter(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	var accessSpec comp.AccessSpec
+	for _, opt := range opts {
+		if err := opt(&accessSpec, &r.CompDefIndex); err != nil {
+			return nil, nil, fmt.Errorf("query %q: %w", src, err)
+		}
+	}
+	if err := accessSpec.Conflicts(); err != nil {
+		return nil, nil, fmt.Errorf("query %q: %w", src, err)
+	}
+	return r.MatcherCatalog.AddMatcher(&accessSpec), accessSpec.CompInfos, nil
+}
+
+// ReleaseMatcher returns m's slot to the matcher catalog.
+func (r *Registry) ReleaseMatcher(m *query.Matcher) { r.MatcherCatalog.Release(m) }
+
 func (r *Registry) CreateEditor(opts ...comp.EditOpt) *ent.Editor {
 	var spec comp.EditSpec
 	spec.Init(&r.CompDefIndex, opts...)
@@ -122,7 +194,7 @@ func (r *Registry) saveTo(w io.Writer) error {
 	r.saving = true
 	defer func() { r.saving = false }()
 
-	return persist.Save(w, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog)
+	return persist.Save(w, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog, &r.baseline)
 }
 
 // Save writes a full snapshot of the world to the file at path. Requires a
@@ -142,21 +214,117 @@ func (r *Registry) Save(path string) error {
 	return r.saveTo(f)
 }
 
+// SaveOnly writes to the file at path a snapshot of only the archetypes
+// opts select — see [persist.SaveOnly]. Requires a prior Pause (panics
+// otherwise), like Save.
+func (r *Registry) SaveOnly(path string, opts []comp.AccessOpt) error {
+	if !r.paused {
+		panic("goke: SaveOnly called without a prior Pause()")
+	}
+	filter := persist.NewFilter(&r.CompDefIndex, opts...)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r.saving = true
+	defer func() { r.saving = false }()
+	return persist.SaveOnly(f, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog, filter)
+}
+
+// saveDeltaTo writes a delta against the last snapshot — see
+// [persist.SaveDelta].
+func (r *Registry) saveDeltaTo(w io.Writer) error {
+	r.saving = true
+	defer func() { r.saving = false }()
+
+	return persist.SaveDelta(w, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog, &r.baseline)
+}
+
+// SaveDelta writes to the file at path only what changed since the last
+// Save, SaveDelta, Load, or LoadDeltas — entities removed since, and every
+// entity of every chunk whose content changed. Requires a prior Pause
+// (panics otherwise), like Save; returns an error if there is no snapshot
+// to diff against yet.
+func (r *Registry) SaveDelta(path string) error {
+	if !r.paused {
+		panic("goke: SaveDelta called without a prior Pause()")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return r.saveDeltaTo(f)
+}
+
+// SaveAsync queues a full save to w and returns its handle at once. The
+// world is copied at the next [Registry.CaptureSaves] — memcpy-speed — and
+// encoded on a goroutine of its own, so ticking continues meanwhile.
+// Unlike Save it needs no Pause, and it leaves the SaveDelta baseline
+// alone: a snapshot still being written can't be a delta's parent.
+func (r *Registry) SaveAsync(w io.Writer) *SaveJob {
+	j := newSaveJob(w)
+	r.saves.push(j)
+	return j
+}
+
+// AfterSync satisfies orch.Mutator: once a Sync has applied every queued
+// mutation the world is consistent, so pending saves are captured and
+// secondary indexes refreshed — each rebuilt only if its entities were
+// added, removed or written since. Query value summaries need no Sync: the
+// writes they depend on are counted as they happen.
+func (r *Registry) AfterSync() {
+	r.MatcherCatalog.Refresh()
+	r.CaptureSaves()
+}
+
+// CaptureSaves takes one snapshot for every pending SaveAsync job and
+// starts encoding it. Call only while no mutation is in flight — at a Sync
+// point, or between ticks.
+func (r *Registry) CaptureSaves() {
+	jobs := r.saves.drain()
+	if len(jobs) == 0 {
+		return
+	}
+	s, err := persist.Capture(&r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog)
+	for _, j := range jobs {
+		switch {
+		case err != nil:
+			j.finish(err)
+		case j.ctx.Err() != nil:
+			j.finish(j.ctx.Err())
+		default:
+			j.start(s)
+		}
+	}
+}
+
 // loadFrom reads a snapshot written by Save, registering components via
 // comps — see [persist.Load].
 func (r *Registry) loadFrom(rd io.Reader, comps []CompToken) error {
+	return persist.Load(rd, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog, r.compRequests(comps), &r.baseline)
+}
+
+// compRequests adapts comps to persist's registration callbacks.
+func (r *Registry) compRequests(comps []CompToken) []persist.CompRequest {
 	requests := make([]persist.CompRequest, len(comps))
 	for i, c := range comps {
 		c := c
 		requests[i] = persist.CompRequest{
-			Name: c.Name,
+			Name:       c.Name,
+			Aliases:    c.Aliases,
+			LegacyName: c.LegacyName,
 			Register: func(wantSize *uint32) error {
 				return c.Register(r, wantSize)
 			},
 		}
 	}
-
-	return persist.Load(rd, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog, requests)
+	return requests
 }
 
 // Load reads a snapshot written by Save from the file at path, rebuilding
@@ -178,16 +346,134 @@ func (r *Registry) Load(path string, comps []CompToken) error {
 	return r.loadFrom(f, comps)
 }
 
+// LoadOnly is Load restricted to the archetypes opts select — see
+// [persist.LoadOnly]. Same precondition as Load.
+func (r *Registry) LoadOnly(path string, opts []comp.AccessOpt, comps []CompToken) error {
+	if r.CompDefIndex.Count() > 0 {
+		panic("goke: LoadOnly called after other components were already registered — it must run first, before Setup and before any RegComp call")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return persist.LoadOnly(f, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog, r.compRequests(comps), &r.baseline, opts)
+}
+
+// loadMergeFrom merges a snapshot written by Save into the world under
+// fresh IDs — see [persist.LoadMerge].
+func (r *Registry) loadMergeFrom(rd io.Reader, comps []CompToken) (map[uid.UID64]uid.UID64, error) {
+	return persist.LoadMerge(rd, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog, r.compRequests(comps))
+}
+
+// LoadMerge adds the entities of the snapshot at path to the world as it
+// stands — unlike Load, components and entities may already exist — each
+// under a fresh ID, and returns the snapshot's IDs mapped to the new ones.
+// Reference fields (`goke:"ref"`) pointing inside the snapshot are
+// rewritten to match. Requires a prior Pause (panics otherwise), like Save.
+func (r *Registry) LoadMerge(path string, comps []CompToken) (map[uid.UID64]uid.UID64, error) {
+	if !r.paused {
+		panic("goke: LoadMerge called without a prior Pause()")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return r.loadMergeFrom(f, comps)
+}
+
+// loadDeltaFrom applies one delta — see [persist.LoadDelta].
+func (r *Registry) loadDeltaFrom(rd io.Reader) error {
+	return persist.LoadDelta(rd, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog, &r.baseline)
+}
+
+// LoadDeltas applies the deltas at paths, in order, on top of the snapshot
+// last loaded (or saved): each must have been written by SaveDelta right
+// after the one before it — the first, right after that snapshot. Stops at
+// the first error, leaving the deltas before it applied. Requires a prior
+// Pause (panics otherwise), like Save: it rewrites entities in place.
+func (r *Registry) LoadDeltas(paths ...string) error {
+	if !r.paused {
+		panic("goke: LoadDeltas called without a prior Pause()")
+	}
+	for _, path := range paths {
+		if err := r.loadDeltaFile(path); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) loadDeltaFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return r.loadDeltaFrom(f)
+}
+
+// ExportJSON writes the world to w as human-readable JSON — see
+// [persist.ExportJSON]. Requires a prior Pause (panics otherwise), like
+// Save.
+func (r *Registry) ExportJSON(w io.Writer) error {
+	if !r.paused {
+		panic("goke: ExportJSON called without a prior Pause()")
+	}
+	r.saving = true
+	defer func() { r.saving = false }()
+
+	return persist.ExportJSON(w, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog)
+}
+
+// ImportJSON rebuilds the world from a document written by ExportJSON —
+// see [persist.ImportJSON]. Same precondition as Load. The import is no
+// snapshot a SaveDelta could build on.
+func (r *Registry) ImportJSON(rd io.Reader, comps []CompToken) error {
+	if r.CompDefIndex.Count() > 0 {
+		panic("goke: ImportJSON called after other components were already registered — it must run first, before Setup and before any RegComp call")
+	}
+	r.baseline.Reset()
+	return persist.ImportJSON(rd, &r.CompDefIndex, &r.EntityManager.AddressBook, &r.EntityManager.ArchCatalog, r.compRequests(comps))
+}
+
+// SectionError is the damaged-section error Load and Verify report — see
+// [persist.SectionError].
+type SectionError = persist.SectionError
+
+// Verify checks the save file at path without loading it — see
+// [persist.Verify].
+func Verify(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return persist.Verify(f)
+}
+
 // Reset clears all entities, components, and system state, returning the
 // registry to its initial (post-Init) condition. Also clears the paused
-// state. Panics if called while a Save is in progress.
+// state, and fails any SaveAsync still waiting for its snapshot (one
+// already encoding runs on, unaffected). Panics if called while a Save is
+// in progress.
 func (r *Registry) Reset() {
 	if r.saving {
 		panic("goke: Reset called while a Save is in progress")
 	}
+	for _, j := range r.saves.drain() {
+		j.finish(errResetBeforeCapture)
+	}
 	r.EntityManager.Reset()
 	r.CompDefIndex.Reset()
 	r.MatcherCatalog.Reset()
+	r.baseline.Reset()
 	r.paused = false
 }
 
@@ -1,6 +1,10 @@
 package reg_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"path/filepath"
 	"reflect"
 	"testing"
@@ -119,7 +123,7 @@ func TestRegistry_UpsertAndRemoveComp(t *testing.T) {
 	factory.Next()
 	id := factory.IDs[0]
 
-	ptr, err := r.UpsertComp(id, velID)
+	ptr, err := r.UpsertComp(id, velID, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -132,7 +136,7 @@ func TestRegistry_UpsertAndRemoveComp(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if _, err := r.UpsertComp(uid.UID64(999), velID); err == nil {
+	if _, err := r.UpsertComp(uid.UID64(999), velID, nil); err == nil {
 		t.Error("expected an error for UpsertComp on an unknown entity")
 	}
 	if err := r.RemoveComp(uid.UID64(999), velID); err == nil {
@@ -402,3 +406,53 @@ func TestRegistry_PauseResume_Idempotent(t *testing.T) {
 		t.Fatal("expected Paused() false after Resume")
 	}
 }
+
+func TestRegistry_SaveAsync_WaitsForCaptureSaves(t *testing.T) {
+	r := newRegistry(t)
+	r.RegComp(reflect.TypeFor[Position]())
+	var pos iter.ArrayRef[Position]
+	factory := r.CreateFactory(comp.Add(&pos))
+	factory.Create(1)
+	factory.Next()
+
+	var buf bytes.Buffer
+	job := r.SaveAsync(&buf)
+	select {
+	case <-job.Done():
+		t.Fatal("expected the save to stay pending until its snapshot is captured")
+	default:
+	}
+
+	r.AfterSync()
+	if err := job.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Error("expected the save to have written to its writer")
+	}
+}
+
+func TestRegistry_SaveAsync_CanceledBeforeCapture(t *testing.T) {
+	r := newRegistry(t)
+	var buf bytes.Buffer
+	job := r.SaveAsync(&buf)
+	job.Cancel()
+	r.CaptureSaves()
+
+	if err := job.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written, got %d bytes", buf.Len())
+	}
+}
+
+func TestRegistry_Reset_FailsPendingSaveAsync(t *testing.T) {
+	r := newRegistry(t)
+	job := r.SaveAsync(io.Discard)
+	r.Reset()
+
+	if err := job.Wait(); err == nil {
+		t.Error("expected a save still pending at Reset to fail")
+	}
+}
@@ -0,0 +1,80 @@
+package reg
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/kjkrol/goke/v3/internal/persist"
+)
+
+// errResetBeforeCapture fails a SaveAsync whose snapshot was still pending
+// when the registry was Reset.
+var errResetBeforeCapture = errors.New("goke: SaveAsync canceled — the ECS was reset before its snapshot was taken")
+
+// SaveJob is a save started by [Registry.SaveAsync]: pending until the
+// world is captured at a Sync point, then encoded on its own goroutine.
+type SaveJob struct {
+	w      io.Writer
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func newSaveJob(w io.Writer) *SaveJob {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SaveJob{w: w, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+}
+
+// Wait blocks until the save finishes and returns its error — nil once
+// every byte has been written to the writer given to SaveAsync.
+func (j *SaveJob) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// Done returns a channel closed once the save finishes, for use in a
+// select; Wait then returns immediately.
+func (j *SaveJob) Done() <-chan struct{} { return j.done }
+
+// Cancel stops the save as soon as possible. A canceled save that had not
+// finished yet fails with context.Canceled, leaving its writer holding a
+// truncated stream. Safe to call more than once, and after the save ends.
+func (j *SaveJob) Cancel() { j.cancel() }
+
+// start encodes s on a new goroutine.
+func (j *SaveJob) start(s *persist.Snapshot) {
+	go func() {
+		j.finish(s.Encode(j.ctx, j.w))
+	}()
+}
+
+func (j *SaveJob) finish(err error) {
+	j.err = err
+	j.cancel()
+	close(j.done)
+}
+
+// pendingSaves holds the SaveAsync jobs waiting for the next Sync point.
+// SaveAsync may be called from systems running in parallel, so it's
+// guarded.
+type pendingSaves struct {
+	mu   sync.Mutex
+	jobs []*SaveJob
+}
+
+func (p *pendingSaves) push(j *SaveJob) {
+	p.mu.Lock()
+	p.jobs = append(p.jobs, j)
+	p.mu.Unlock()
+}
+
+func (p *pendingSaves) drain() []*SaveJob {
+	p.mu.Lock()
+	jobs := p.jobs
+	p.jobs = nil
+	p.mu.Unlock()
+	return jobs
+}
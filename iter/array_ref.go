@@ -23,3 +23,19 @@ func (c *ArrayRef[T]) At(cur *Cursor) *T {
 	var zero T
 	return (*T)(unsafe.Add(cur.Base, cur.Offsets[c.Idx]+cur.Slot*unsafe.Sizeof(zero)))
 }
+
+// SliceOrNil is Slice for an optional column: nil if its offset is Absent.
+func (c *ArrayRef[T]) SliceOrNil(cur *Cursor) []T {
+	if cur.Offsets[c.Idx] == Absent {
+		return nil
+	}
+	return c.Slice(cur)
+}
+
+// AtOrNil is At for an optional column: nil if its offset is Absent.
+func (c *ArrayRef[T]) AtOrNil(cur *Cursor) *T {
+	if cur.Offsets[c.Idx] == Absent {
+		return nil
+	}
+	return c.At(cur)
+}
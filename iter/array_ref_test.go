@@ -126,3 +126,26 @@ func TestArrayRef_At_RespectsIdx(t *testing.T) {
 		t.Errorf("colB: expected 9, got %d", got)
 	}
 }
+
+func TestArrayRef_OrNil_AbsentOffset(t *testing.T) {
+	buf := make([]byte, 64)
+	cur := &Cursor{
+		Base:    unsafe.Pointer(&buf[0]),
+		Offsets: []uintptr{0, Absent},
+		IDs:     make([]uid.UID64, 2),
+	}
+
+	present, absent := ArrayRef[int32]{Idx: 0}, ArrayRef[int32]{Idx: 1}
+	if len(present.Slice(cur)) != 2 || present.At(cur) == nil {
+		t.Error("expected the present column to be addressable")
+	}
+	if len(present.SliceOrNil(cur)) != 2 || present.AtOrNil(cur) == nil {
+		t.Error("expected SliceOrNil/AtOrNil to address the present column")
+	}
+	if s := absent.SliceOrNil(cur); s != nil {
+		t.Errorf("expected nil SliceOrNil for an Absent column, got %v", s)
+	}
+	if p := absent.AtOrNil(cur); p != nil {
+		t.Errorf("expected nil AtOrNil for an Absent column, got %p", p)
+	}
+}
@@ -0,0 +1,15 @@
+package iter
+
+import "unsafe"
+
+// ChunkRef locates per-chunk component T — one value for the whole chunk —
+// within a Cursor. Idx is its index into Cursor.ChunkOffsets.
+type ChunkRef[T any] struct {
+	Idx int
+}
+
+// Get returns a pointer to the current chunk's T. Unlike a shared value it
+// belongs to this chunk alone and may be written through the pointer.
+func (c *ChunkRef[T]) Get(cur *Cursor) *T {
+	return (*T)(unsafe.Add(cur.Base, cur.ChunkOffsets[c.Idx]))
+}
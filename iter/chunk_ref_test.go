@@ -0,0 +1,17 @@
+package iter
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestChunkRef_Get_OffsetsFromBase(t *testing.T) {
+	var block [4]int32
+	cur := Cursor{Base: unsafe.Pointer(&block[0]), ChunkOffsets: []uintptr{4, 12}}
+
+	ref := ChunkRef[int32]{Idx: 1}
+	*ref.Get(&cur) = 5
+	if block[3] != 5 {
+		t.Fatalf("Get: wrote %v, want block[3] == 5", block)
+	}
+}
@@ -13,9 +13,20 @@ type Cursor struct {
 	Offsets []uintptr
 	Slot    uintptr
 	IDs     []uid.UID64
+	// Shared points at the current block's shared component values, one
+	// per tracked shared component — the same for every entity in it.
+	Shared []unsafe.Pointer
+	// ChunkOffsets locates each tracked per-chunk component's value
+	// relative to Base, the start of the current chunk.
+	ChunkOffsets []uintptr
 }
 
-// Set positions the cursor at (base, slot) without touching Offsets or IDs.
+// Absent is the Cursor offset of a column the current block lacks — an
+// optional component its archetype doesn't have.
+const Absent = ^uintptr(0)
+
+// Set positions the cursor at (base, slot) without touching Offsets, IDs,
+// Shared or ChunkOffsets.
 func (c *Cursor) Set(base unsafe.Pointer, slot uintptr) {
 	c.Base = base
 	c.Slot = slot
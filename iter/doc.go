@@ -8,6 +8,8 @@
 //   - Offsets   — per-component byte offsets from Base, one entry per tracked component
 //   - Slot      — index into IDs (and into each tracked array) for single-entity access
 //   - IDs       — the entity IDs currently addressable via Base/Offsets
+//   - Shared    — per-block shared component values, one per tracked shared component
+//   - ChunkOffsets — offsets from Base of the current chunk's per-chunk component values
 //
 // # ArrayRef[T]
 //
@@ -22,4 +24,20 @@
 // it to the correct index before calling Slice or At. Its zero value (0) is
 // only correct if T's array happens to be the first one in Cursor.Offsets;
 // otherwise it must be assigned explicitly first.
+//
+// An offset of [Absent] marks a column the current block lacks — an
+// optional component. [ArrayRef.SliceOrNil] and [ArrayRef.AtOrNil] return
+// nil for it; Slice and At stay branch-free, so the length of what Slice
+// returns is always len(cur.IDs) and loops ranging cur.IDs keep their
+// bounds-check elimination.
+//
+// # SharedRef[T]
+//
+// [SharedRef][T] reads a shared component — one value per block rather
+// than per entity — through its Idx into Cursor.Shared.
+//
+// # ChunkRef[T]
+//
+// [ChunkRef][T] reads and writes a per-chunk component — one value per
+// chunk — at Base plus Cursor.ChunkOffsets[Idx].
 package iter
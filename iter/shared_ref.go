@@ -0,0 +1,13 @@
+package iter
+
+// SharedRef locates shared component T — one value for the whole block —
+// within a Cursor. Idx is its index into Cursor.Shared.
+type SharedRef[T any] struct {
+	Idx int
+}
+
+// Get returns a pointer to the current block's T. The value is shared by
+// every entity in the block and must not be written through it.
+func (c *SharedRef[T]) Get(cur *Cursor) *T {
+	return (*T)(cur.Shared[c.Idx])
+}
@@ -0,0 +1,16 @@
+package iter
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestSharedRef_Get_RespectsIdx(t *testing.T) {
+	a, b := int32(7), int32(9)
+	cur := Cursor{Shared: []unsafe.Pointer{unsafe.Pointer(&a), unsafe.Pointer(&b)}}
+
+	ref := SharedRef[int32]{Idx: 1}
+	if got := ref.Get(&cur); got != &b || *got != 9 {
+		t.Fatalf("Get: got %v, want pointer to b (9)", got)
+	}
+}
@@ -138,3 +138,95 @@ func TestECS_ParallelExecution_Disjoint(t *testing.T) {
 		t.Errorf("Expected 1000 entities, found %d", count)
 	}
 }
+
+// TestECS_ParallelExecution_WatchedColumns runs the disjoint systems while a
+// WhereRange summarizes Position, so both mark writes to the same tables
+// concurrently; the summaries must still notice the physics writes.
+func TestECS_ParallelExecution_WatchedColumns(t *testing.T) {
+	ecs := goke.New()
+	phys := ecs.RegSys(&PhysicsSystem{})
+	heal := ecs.RegSys(&HealthSystem{})
+
+	var pos goke.Comp[Position]
+	var vel goke.Comp[Velocity]
+	var health goke.Comp[Health]
+	var moved *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		factory := si.NewFactory(&pos, &vel, &health)
+		fc := &factory.Cursor
+		factory.Create(1000)
+		for factory.Next() {
+			velocities := vel.Slice(fc)
+			healths := health.Slice(fc)
+			for i := range factory.IDs {
+				velocities[i] = Velocity{10, 10}
+				healths[i] = Health{50, 100}
+			}
+		}
+		moved = si.NewQueryBuilder(&pos).
+			Where(goke.WhereRange(&pos, func(p *Position) float32 { return p.X }, 5, 15)).
+			Build()
+	}})
+	count := func() int {
+		n := 0
+		for range moved.Entities() {
+			n++
+		}
+		return n
+	}
+	if n := count(); n != 0 {
+		t.Fatalf("expected no entity in range before the tick, found %d", n)
+	}
+
+	ecs.SetPlan(func(ctx goke.RunCtx, d time.Duration) {
+		ctx.RunParallel(d, phys, heal)
+	})
+	ecs.Tick(time.Second)
+
+	if n := count(); n != 1000 {
+		t.Errorf("expected 1000 entities in range after the tick, found %d", n)
+	}
+}
+
+// TestECS_ParallelExecution_IndexedColumns writes indexed columns from
+// systems run in parallel; the next Sync must rebuild both indexes.
+func TestECS_ParallelExecution_IndexedColumns(t *testing.T) {
+	ecs := goke.New()
+	phys := ecs.RegSys(&PhysicsSystem{})
+	heal := ecs.RegSys(&HealthSystem{})
+
+	var pos goke.Comp[Position]
+	var vel goke.Comp[Velocity]
+	var health goke.Comp[Health]
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		factory := si.NewFactory(&pos, &vel, &health)
+		fc := &factory.Cursor
+		factory.Create(1000)
+		for factory.Next() {
+			velocities := vel.Slice(fc)
+			healths := health.Slice(fc)
+			for i := range factory.IDs {
+				velocities[i] = Velocity{10, 10}
+				healths[i] = Health{50, 100}
+			}
+		}
+	}})
+	byHP := ecs.NewHashIndex(func(h *Health) float32 { return h.Current })
+	byX := ecs.NewOrderedIndex(func(p *Position) float32 { return p.X })
+	if n := len(byHP.Get(50)); n != 1000 {
+		t.Fatalf("expected 1000 entities with 50 HP before the tick, found %d", n)
+	}
+
+	ecs.SetPlan(func(ctx goke.RunCtx, d time.Duration) {
+		ctx.RunParallel(d, phys, heal)
+		ctx.Sync()
+	})
+	ecs.Tick(time.Second)
+
+	if n := len(byHP.Get(51)); n != 1000 {
+		t.Errorf("expected 1000 entities with 51 HP after the tick, found %d", n)
+	}
+	if n := len(byX.Range(10, 10)); n != 1000 {
+		t.Errorf("expected 1000 entities at X=10 after the tick, found %d", n)
+	}
+}
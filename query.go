@@ -1,12 +1,15 @@
 package goke
 
 import (
+	"cmp"
+	"fmt"
+	"iter"
+
 	"github.com/kjkrol/uid"
 
 	"github.com/kjkrol/goke/v3/internal/bulk"
 	"github.com/kjkrol/goke/v3/internal/comp"
 	"github.com/kjkrol/goke/v3/internal/query"
-	"github.com/kjkrol/goke/v3/iter"
 )
 
 // Query matches entities by component mask and provides three access
@@ -15,13 +18,16 @@ import (
 // entity). Call All() or Pick() to set the iteration mode and loop with
 // Next(), or call Seek() directly for single-entity access.
 type Query struct {
-	m   *query.Matcher
-	ecs *ECS
+	m    *query.Matcher
+	ecs  *ECS
+	view bool // built by ECS.View: read-only, released by Release
 }
 
 // All prepares the Query for full chunk iteration and returns q.
 // Call Next() to advance through matched entity chunks; read component
 // slices with Comp[T].Slice. Do not call All concurrently on the same Query.
+// If the Query was built with Where, Next skips chunks without a match and
+// Slots lists the matching entities' slots in each chunk.
 func (q *Query) All() *Query { q.m.All(); return q }
 
 // Pick prepares the Query to iterate over the given entities and returns q.
@@ -40,6 +46,15 @@ func (q *Query) Next() bool { return q.m.Next() }
 // exist or has been recycled.
 func (q *Query) Seek(entID uid.UID64) bool { return q.m.Seek(entID) }
 
+// Contains reports whether entID is alive and currently matches the
+// Query's include/exclude filter — unlike Seek, which ignores it. O(1): the
+// match is cached per archetype.
+func (q *Query) Contains(entID uid.UID64) bool { return q.m.Contains(entID) }
+
+// SeekMatching is Seek checked against the Query's filter: it positions the
+// Cursor only if entID is alive and matches, and reports whether it did.
+func (q *Query) SeekMatching(entID uid.UID64) bool { return q.m.SeekMatching(entID) }
+
 // SeekH positions q's Cursor at entID's storage slot, assuming entID is
 // alive and shares the archetype already cached by a prior Seek call on q —
 // call Seek once, then SeekH for the rest of a batch from that archetype.
@@ -53,11 +68,140 @@ func (q *Query) Clear() { q.m.Clear() }
 
 // Cursor returns the Query's current cursor, populated by Next or Seek.
 // Pass it to Comp[T].Slice (All-mode) or Comp[T].At (Pick/Seek-mode).
-func (q *Query) Cursor() *iter.Cursor { return &q.m.Cursor }
+func (q *Query) Cursor() *Cursor { return &q.m.Cursor }
+
+// Chunks iterates the Query in All-mode, yielding its Cursor once per
+// matched chunk — the range-over-func form of All/Next:
+//
+//	for cur := range q.Chunks() {
+//		positions := pos.Slice(cur)
+//		for i := range cur.IDs { ... }
+//	}
+//
+// The Cursor is valid only inside the loop body. Like All, do not run it
+// concurrently on the same Query. With Where predicates, range q.Slots()
+// instead of cur.IDs.
+func (q *Query) Chunks() iter.Seq[*Cursor] {
+	return func(yield func(*Cursor) bool) {
+		cur := &q.m.Cursor
+		for q.m.All(); q.m.Next(); {
+			if !yield(cur) {
+				return
+			}
+		}
+	}
+}
+
+// Entities iterates the Query in All-mode one entity at a time, yielding
+// its ID and the Cursor positioned on it — read components with
+// Comp[T].At. With Where predicates, only matching entities are yielded.
+// Prefer Chunks in hot loops: slicing once per chunk is cheaper than At
+// per entity.
+func (q *Query) Entities() iter.Seq2[uid.UID64, *Cursor] {
+	return func(yield func(uid.UID64, *Cursor) bool) {
+		cur := &q.m.Cursor
+		for q.m.All(); q.m.Next(); {
+			if slots := q.m.Slots; slots != nil {
+				for _, s := range slots {
+					cur.Slot = uintptr(s)
+					if !yield(cur.IDs[s], cur) {
+						return
+					}
+				}
+				continue
+			}
+			for i, id := range cur.IDs {
+				cur.Slot = uintptr(i)
+				if !yield(id, cur) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Picked iterates the given entities in Pick-mode, yielding each one that
+// matches the Query with the Cursor positioned on it; the others are
+// skipped. Idx reports the current entity's index into selected.
+func (q *Query) Picked(selected []uid.UID64) iter.Seq2[uid.UID64, *Cursor] {
+	return func(yield func(uid.UID64, *Cursor) bool) {
+		cur := &q.m.Cursor
+		for q.m.Pick(selected); q.m.Next(); {
+			if !yield(q.m.Entity, cur) {
+				return
+			}
+		}
+	}
+}
+
+// PickSet prepares the Query to iterate set's matching entities one chunk
+// at a time and returns q — Pick for large unsorted batches, without
+// re-resolving offsets at every archetype change. Each Next fills the
+// Cursor for the whole chunk, as in All-mode, and Slots with the selected
+// entities' slots in it:
+//
+//	for q.PickSet(set); q.Next(); {
+//		positions, ids := pos.Slice(q.Cursor()), q.Cursor().IDs
+//		for _, s := range q.Slots() { positions[s].X += 1; _ = ids[s] }
+//	}
+//
+// ChunkSnapshot is valid too, so BeginMigrate works on the picked subset.
+// Do not call PickSet concurrently on the same Query.
+func (q *Query) PickSet(set *EntitySet) *Query { q.m.PickSet(set); return q }
+
+// Slots returns the selected entities' slots in the current PickSet chunk,
+// or the matching ones in an All-mode chunk of a Query built with Where,
+// ascending; nil in plain All-mode. Valid until the next Next().
+func (q *Query) Slots() []uint32 { return q.m.Slots }
+
+// PickedSet iterates set in PickSet-mode, yielding the Cursor and the
+// selected slots once per chunk — the range-over-func form of PickSet/Next.
+func (q *Query) PickedSet(set *EntitySet) iter.Seq2[*Cursor, []uint32] {
+	return func(yield func(*Cursor, []uint32) bool) {
+		cur := &q.m.Cursor
+		for q.m.PickSet(set); q.m.Next(); {
+			if !yield(cur, q.m.Slots) {
+				return
+			}
+		}
+	}
+}
+
+// NewEntitySet returns an EntitySet holding ids, for Query.PickSet. Reuse it
+// — and EntitySet.Reset — across ticks to keep its grouping and buffers.
+func NewEntitySet(ids []uid.UID64) *EntitySet { return query.NewEntitySet(ids) }
+
+// Count returns how many entities match the Query, summed from the matched
+// archetypes' lengths — O(matched archetypes), without iterating.
+func (q *Query) Count() int { return q.m.Count() }
+
+// IsEmpty reports whether no entity matches the Query — e.g. "is any
+// entity a Winner" — without iterating.
+func (q *Query) IsEmpty() bool { return q.m.IsEmpty() }
+
+// Single returns the one entity matching the Query and q's Cursor
+// positioned on it, as by Seek — read components with Comp[T].At. It
+// returns ErrNoMatch or ErrMultipleMatches if the Query matches none or
+// several.
+func (q *Query) Single() (uid.UID64, *Cursor, error) {
+	id, err := q.m.Single()
+	if err != nil {
+		return 0, nil, fmt.Errorf("goke: Single: %w", err)
+	}
+	return id, &q.m.Cursor, nil
+}
+
+var (
+	// ErrNoMatch is returned by Query.Single when no entity matches.
+	ErrNoMatch = query.ErrNoMatch
+	// ErrMultipleMatches is returned by Query.Single when several entities match.
+	ErrMultipleMatches = query.ErrMultipleMatches
+)
 
 // ChunkSnapshot captures the chunk most recently advanced to by Next() in
-// All-mode — used internally by BeginMigrate. Valid only between a Next()
-// that returned true and the following Next(); undefined in Pick/Seek mode.
+// All- or PickSet-mode — used internally by BeginMigrate. Valid only between
+// a Next() that returned true and the following Next(); undefined in
+// Pick/Seek mode.
 func (q *Query) ChunkSnapshot() ChunkSnapshot { return q.m.ChunkSnapshot() }
 
 // Entity returns the current entity in Pick-mode iteration.
@@ -66,13 +210,43 @@ func (q *Query) Entity() uid.UID64 { return q.m.Entity }
 // Idx returns the current index into the slice passed to Pick.
 func (q *Query) Idx() int { return q.m.Idx }
 
+// Touch marks the value summaries kept by WhereRange stale for every Query,
+// so the next scan recomputes them. Writes through a Query's Cursor, an
+// Editor or a command buffer are noticed without it; call Touch after
+// writing through a pointer kept from an earlier scan.
+func (q *Query) Touch() { q.m.Touch() }
+
 // ----------------- BUILDER -----------------
 
 // QueryBuilder assembles a Query's access options. Start with
-// NewQueryBuilder, optionally chain Include/Exclude, and finish with Build.
+// NewQueryBuilder, optionally chain Maybe/Include/Exclude/Where, and finish
+// with Build.
 type QueryBuilder struct {
-	ecs  *ECS
-	opts []Opt
+	ecs   *ECS
+	opts  []Opt
+	preds []Predicate
+	view  bool
+}
+
+// Where filters All-mode iteration by component value, built via Where[T],
+// WhereRange[T] or WhereChunk[T]: an entity matches if it satisfies every predicate. The
+// predicates' components must be tracked by the Query. Pick, Seek,
+// Contains and Count ignore them.
+func (b *QueryBuilder) Where(preds ...Predicate) *QueryBuilder {
+	b.preds = append(b.preds, preds...)
+	return b
+}
+
+// Maybe tracks the given components as optional data columns: entities
+// lacking them still match, and their Maybe[T].Slice (or At) returns nil in
+// chunks of archetypes without T — e.g. "all entities with Pos, and Vel if
+// present". Whether a column is there is fixed per archetype, so checking
+// once per chunk is enough.
+func (b *QueryBuilder) Maybe(comps ...Optional) *QueryBuilder {
+	for _, c := range comps {
+		b.opts = append(b.opts, c.asOptional())
+	}
+	return b
 }
 
 // Include adds required (filter-only, no data access) component types,
@@ -88,15 +262,48 @@ func (b *QueryBuilder) Exclude(opts ...Opt) *QueryBuilder {
 	return b
 }
 
-// Build creates the Query from the accumulated options.
+// Build creates the Query from the accumulated options. For a builder from
+// ECS.View, it panics unless the ECS is paused.
 func (b *QueryBuilder) Build() *Query {
-	return &Query{m: b.ecs.registry.AddMatcher(b.opts...), ecs: b.ecs}
+	if b.view && !b.ecs.Paused() {
+		panic("goke: View built while the ECS is running — call Pause() first")
+	}
+	m := b.ecs.registry.AddMatcher(b.opts...)
+	if b.view {
+		m.ReadOnly()
+		b.ecs.views++
+	}
+	m.Where(b.preds...)
+	return &Query{m: m, ecs: b.ecs, view: b.view}
+}
+
+// Release frees q: its matcher stops tracking new archetypes and its slot
+// is reused by the next Query built, so tools can create and drop queries
+// freely. q must not be used afterwards, nor released twice; release a
+// system's Query only once nothing will run it again.
+func (q *Query) Release() {
+	if q.m == nil {
+		panic("goke: Query released twice")
+	}
+	q.ecs.registry.ReleaseMatcher(q.m)
+	if q.view {
+		q.ecs.views--
+	}
+	q.m = nil
+}
+
+// mustWrite panics if q is a read-only View.
+func (q *Query) mustWrite(op string) {
+	if q.view {
+		panic("goke: " + op + " on a read-only View")
+	}
 }
 
 // NewEditorBuilder starts an EditorBuilder, adding the given components
 // (equivalent to Add[T] for each). The resulting Editor applies to entities
 // matched by q.
 func (q *Query) NewEditorBuilder(comps ...Addable) *EditorBuilder {
+	q.mustWrite("NewEditorBuilder")
 	b := &EditorBuilder{ecs: q.ecs, opts: make([]EditOpt, 0, len(comps))}
 	for _, c := range comps {
 		b.opts = append(b.opts, c.asAdd())
@@ -107,6 +314,7 @@ func (q *Query) NewEditorBuilder(comps ...Addable) *EditorBuilder {
 // NewValueEditorBuilder starts a ValueEditorBuilder for a single added
 // component. The resulting ValueEditor applies to entities matched by q.
 func (q *Query) NewValueEditorBuilder(addable Addable) *ValueEditorBuilder {
+	q.mustWrite("NewValueEditorBuilder")
 	return &ValueEditorBuilder{ecs: q.ecs, opts: []EditOpt{addable.asAdd()}}
 }
 
@@ -122,6 +330,7 @@ type MigrateBuf struct {
 // destination op; safe to call multiple times per chunk for a mixed batch.
 // Valid only until the next Next() call.
 func (q *Query) BeginMigrate(cb *CmdBuf) *MigrateBuf {
+	q.mustWrite("BeginMigrate")
 	n := len(q.Cursor().IDs)
 	return &MigrateBuf{cb: cb, snap: q.ChunkSnapshot(), ids: cb.raw.ReserveIDs(n)}
 }
@@ -138,6 +347,28 @@ func (b *MigrateBuf) Commit(op bulk.Migrator) {
 	b.cb.raw.CommitReserved(op, b.snap, b.ids)
 }
 
+// Predicate filters a Query's entities by component value; see
+// QueryBuilder.Where.
+type Predicate = query.Predicate
+
+// Where returns a Predicate matching entities whose T, read through c,
+// satisfies pred — e.g. Where(&hp, func(h *Health) bool { return h.Value < 10 }).
+// Every entity of every matched chunk is tested.
+func Where[T any](c *Comp[T], pred func(*T) bool) Predicate {
+	return query.PredicateFunc(func(cur *Cursor) bool { return pred(c.At(cur)) })
+}
+
+// WhereRange returns a Predicate matching entities whose key, read from
+// their T through c, lies in [lo, hi] — e.g. Pos.X in [a, b]. It keeps the
+// min and max key of each chunk, computed on first scan and reused until
+// the chunk changes structurally or its T may have been written — through
+// a Query that is not a View, an Editor or a command buffer — so chunks
+// whose range misses [lo, hi] are skipped without testing their entities.
+// A WhereRange belongs to one Query: building a second with it panics.
+func WhereRange[T any, K cmp.Ordered](c *Comp[T], key func(*T) K, lo, hi K) Predicate {
+	return query.NewRange(&c.col.Idx, func(cur *Cursor) K { return key(c.At(cur)) }, lo, hi)
+}
+
 // Include adds a required component type T to the Query's filter.
 // Only entities that possess this component will be matched.
 func Include[T any]() Opt { return comp.Include[T]() }
@@ -145,3 +376,20 @@ func Include[T any]() Opt { return comp.Include[T]() }
 // Exclude adds an exclusion for component type T to the Query's filter.
 // Entities that possess this component will not be matched.
 func Exclude[T any]() Opt { return comp.Exclude[T]() }
+
+// AnyOf matches entities satisfying at least one of opts — e.g.
+// AnyOf(Include[Circle](), Include[Rect](), Include[Polygon]()) — so one
+// Query covers what would otherwise take a loop per shape. opts are filter
+// opts: Include, Exclude, or nested AnyOf/OneOf/AllOf; a tracked column
+// panics, since the group leaves open which archetypes hold it — use
+// QueryBuilder.Maybe for that. Groups are evaluated once per archetype,
+// when it is matched, so iteration costs the same as any other Query.
+func AnyOf(opts ...Opt) Opt { return comp.AnyOf(opts...) }
+
+// OneOf matches entities satisfying exactly one of opts. See [AnyOf].
+func OneOf(opts ...Opt) Opt { return comp.OneOf(opts...) }
+
+// AllOf matches entities satisfying every one of opts — a conjunction to
+// nest inside AnyOf or OneOf, e.g.
+// AnyOf(AllOf(Include[A](), Exclude[B]()), Include[C]()). See [AnyOf].
+func AllOf(opts ...Opt) Opt { return comp.AllOf(opts...) }
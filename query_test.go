@@ -1,7 +1,9 @@
 package goke_test
 
 import (
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/kjkrol/goke/v3"
 	"github.com/kjkrol/uid"
@@ -359,3 +361,338 @@ type position struct {
 type velocity struct {
 	VX, VY float64
 }
+
+func TestQueryBuilder_Maybe(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[position]
+	var vel goke.Comp[velocity]
+	var maybeVel goke.Maybe[velocity]
+	var still, moving []uid.UID64
+	var query *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		f := si.NewFactory(&pos)
+		f.Create(3)
+		for f.Next() {
+			still = append(still, f.IDs...)
+		}
+		f = si.NewFactory(&pos, &vel)
+		f.Create(2)
+		for f.Next() {
+			for i := range vel.Slice(&f.Cursor) {
+				vel.Slice(&f.Cursor)[i] = velocity{VX: 1}
+			}
+			moving = append(moving, f.IDs...)
+		}
+		lone := si.NewFactory(&vel) // no position: must not match
+		lone.Create(1)
+		lone.Next()
+
+		query = si.NewQueryBuilder(&pos).Maybe(&maybeVel).Build()
+	}})
+
+	cursor := query.Cursor()
+	seen := make(map[uid.UID64]bool)
+	for query.All(); query.Next(); {
+		positions, velocities := pos.Slice(cursor), maybeVel.Slice(cursor)
+		assert.Len(t, positions, len(cursor.IDs))
+		for i, id := range cursor.IDs {
+			seen[id] = velocities != nil
+			if velocities != nil {
+				positions[i].X += velocities[i].VX
+			}
+		}
+	}
+	assert.Len(t, seen, len(still)+len(moving))
+	for _, id := range still {
+		assert.False(t, seen[id], "entity without velocity read one")
+	}
+	for _, id := range moving {
+		assert.True(t, seen[id], "entity with velocity read none")
+		if assert.True(t, query.Seek(id)) {
+			assert.Equal(t, 1.0, pos.At(cursor).X)
+		}
+	}
+	if assert.True(t, query.Seek(still[0])) {
+		assert.Nil(t, maybeVel.At(cursor))
+	}
+}
+
+func TestQueryBuilder_AnyOfOneOf(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[position]
+	var eA, eB, eC uid.UID64
+	var anyQuery, oneQuery *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		spawn := func(comps ...goke.Addable) uid.UID64 {
+			f := si.NewFactory(comps...)
+			f.Create(1)
+			f.Next()
+			return f.IDs[0]
+		}
+		eA = spawn(new(goke.Comp[position]), new(goke.Comp[velocity]))
+		eB = spawn(new(goke.Comp[position]), new(goke.Comp[complexComponent]))
+		eC = spawn(new(goke.Comp[position]), new(goke.Comp[velocity]), new(goke.Comp[complexComponent]))
+		spawn(new(goke.Comp[position]))
+
+		shapes := func() []goke.Opt {
+			return []goke.Opt{goke.Include[velocity](), goke.Include[complexComponent]()}
+		}
+		anyQuery = si.NewQueryBuilder(&pos).Include(goke.AnyOf(shapes()...)).Build()
+		oneQuery = si.NewQueryBuilder(&pos).Include(goke.OneOf(shapes()...)).Build()
+	}})
+
+	collect := func(q *goke.Query) map[uid.UID64]bool {
+		found := make(map[uid.UID64]bool)
+		for q.All(); q.Next(); {
+			assert.Len(t, pos.Slice(q.Cursor()), len(q.Cursor().IDs))
+			for _, id := range q.Cursor().IDs {
+				found[id] = true
+			}
+		}
+		return found
+	}
+	assert.Equal(t, map[uid.UID64]bool{eA: true, eB: true, eC: true}, collect(anyQuery))
+	assert.Equal(t, map[uid.UID64]bool{eA: true, eB: true}, collect(oneQuery))
+}
+
+func TestQuery_RangeIterators(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[position]
+	var vel goke.Comp[velocity]
+	var ids []uid.UID64
+	var query *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for cur := range si.NewFactory(&pos).Batches(3000) {
+			for i := range pos.Slice(cur) {
+				pos.Slice(cur)[i].X = float64(len(ids) + i)
+			}
+			ids = append(ids, cur.IDs...)
+		}
+		lone := si.NewFactory(&vel) // no position: must not match
+		lone.Create(1)
+		lone.Next()
+		query = si.NewQueryBuilder(&pos).Build()
+	}})
+
+	chunks, seen := 0, 0
+	for cur := range query.Chunks() {
+		chunks++
+		positions := pos.Slice(cur)
+		for i := range cur.IDs {
+			positions[i].Y = 1
+			seen++
+		}
+	}
+	assert.Greater(t, chunks, 1)
+	assert.Equal(t, len(ids), seen)
+
+	n := 0
+	for id, cur := range query.Entities() {
+		assert.Equal(t, ids[n], id)
+		assert.Equal(t, position{X: float64(n), Y: 1}, *pos.At(cur))
+		n++
+	}
+	assert.Equal(t, len(ids), n)
+
+	picked := []uid.UID64{ids[7], uid.UID64(1 << 40), ids[2]}
+	var got []uid.UID64
+	for id, cur := range query.Picked(picked) {
+		assert.Equal(t, picked[query.Idx()], id)
+		assert.Equal(t, float64(slices.Index(ids, id)), pos.At(cur).X)
+		got = append(got, id)
+	}
+	assert.Equal(t, []uid.UID64{ids[7], ids[2]}, got)
+
+	for range query.Chunks() {
+		break // breaking out early must leave the Query reusable
+	}
+	seen = 0
+	for cur := range query.Chunks() {
+		seen += len(cur.IDs)
+	}
+	assert.Equal(t, len(ids), seen)
+}
+
+func TestQuery_CountIsEmptySingle(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[position]
+	var winners, enemies, nobody *goke.Query
+	var winner uid.UID64
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		si.NewFactory(&pos, new(goke.Comp[velocity])).SpawnAll(40)
+		for cur := range si.NewFactory(&pos, new(goke.Comp[complexComponent])).Batches(1) {
+			winner = cur.IDs[0]
+			pos.Slice(cur)[0].X = 42
+		}
+		winners = si.NewQueryBuilder(&pos).Include(goke.Include[complexComponent]()).Build()
+		enemies = si.NewQueryBuilder().Include(goke.Include[velocity]()).Build()
+		nobody = si.NewQueryBuilder().Include(goke.Include[complexComponent]()).Exclude(goke.Exclude[position]()).Build()
+	}})
+
+	assert.Equal(t, 40, enemies.Count())
+	assert.False(t, enemies.IsEmpty())
+	_, _, err := enemies.Single()
+	assert.ErrorIs(t, err, goke.ErrMultipleMatches)
+
+	id, cur, err := winners.Single()
+	if assert.NoError(t, err) {
+		assert.Equal(t, winner, id)
+		assert.Equal(t, 42.0, pos.At(cur).X)
+	}
+	assert.Equal(t, 1, winners.Count())
+
+	assert.True(t, nobody.IsEmpty())
+	assert.Zero(t, nobody.Count())
+	_, _, err = nobody.Single()
+	assert.ErrorIs(t, err, goke.ErrNoMatch)
+}
+
+func TestQuery_ContainsSeekMatching(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[position]
+	var still, moving uid.UID64
+	var query *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		still = si.NewFactory(&pos).SpawnAll(1)[0]
+		moving = si.NewFactory(&pos, new(goke.Comp[velocity])).SpawnAll(1)[0]
+		query = si.NewQueryBuilder(&pos).Exclude(goke.Exclude[velocity]()).Build()
+	}})
+
+	assert.True(t, query.Contains(still))
+	assert.False(t, query.Contains(moving))
+	assert.True(t, query.Seek(moving), "Seek ignores the filter")
+	assert.False(t, query.SeekMatching(moving))
+	if assert.True(t, query.SeekMatching(still)) {
+		pos.At(query.Cursor()).X = 5
+	}
+	for cur := range query.Chunks() {
+		assert.Equal(t, 5.0, pos.Slice(cur)[0].X)
+	}
+}
+
+func TestQuery_PickSet(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[position]
+	var plain, moving []uid.UID64
+	var query *goke.Query
+	var remover *goke.Remover
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		plain = si.NewFactory(&pos).SpawnAll(4)
+		moving = si.NewFactory(&pos, new(goke.Comp[velocity])).SpawnAll(4)
+		query = si.NewQueryBuilder(&pos).Build()
+		remover = si.Remover()
+	}})
+
+	set := goke.NewEntitySet([]uid.UID64{moving[3], plain[0], moving[1], plain[2]})
+	var chunks int
+	for cur, slots := range query.PickedSet(set) {
+		chunks++
+		positions := pos.Slice(cur)
+		for _, s := range slots {
+			positions[s].X = 1
+		}
+	}
+	assert.Equal(t, 2, chunks)
+	var marked int
+	for _, c := range query.Entities() {
+		if pos.At(c).X == 1 {
+			marked++
+		}
+	}
+	assert.Equal(t, 4, marked)
+
+	sys := ecs.RegSys(goke.SystemFn{OnUpdate: func(cb *goke.CmdBuf, d time.Duration) {
+		for query.PickSet(set); query.Next(); {
+			buf := query.BeginMigrate(cb)
+			for _, s := range query.Slots() {
+				buf.Add(query.Cursor().IDs[s])
+			}
+			buf.Commit(remover)
+		}
+	}})
+	ecs.SetPlan(func(s goke.RunCtx, d time.Duration) {
+		s.Run(sys, d)
+		s.Sync()
+	})
+	ecs.Tick(time.Millisecond)
+
+	assert.Equal(t, 4, query.Count())
+	for _, id := range set.IDs() {
+		assert.False(t, query.Contains(id))
+	}
+}
+
+func TestQueryBuilder_Where(t *testing.T) {
+	ecs := goke.New()
+	var pos goke.Comp[position]
+	var ids []uid.UID64
+	var near, right *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		ids = si.NewFactory(&pos).SpawnAll(4)
+		right = si.NewQueryBuilder(&pos).
+			Where(goke.WhereRange(&pos, func(p *position) float64 { return p.X }, 2, 10)).
+			Build()
+		near = si.NewQueryBuilder(&pos).
+			Where(goke.Where(&pos, func(p *position) bool { return p.Y < 1 })).
+			Build()
+	}})
+	for i, id := range ids {
+		right.Seek(id)
+		*pos.At(right.Cursor()) = position{X: float64(i), Y: float64(i)}
+	}
+
+	collect := func(q *goke.Query) []uid.UID64 {
+		var out []uid.UID64
+		for id := range q.Entities() {
+			out = append(out, id)
+		}
+		return out
+	}
+	assert.Equal(t, ids[2:], collect(right))
+	assert.Equal(t, ids[:1], collect(near))
+	for q := right.All(); q.Next(); {
+		assert.Equal(t, []uint32{2, 3}, q.Slots())
+	}
+
+	sys := ecs.RegSys(goke.SystemFn{OnUpdate: func(cb *goke.CmdBuf, d time.Duration) {
+		for cur := range near.Chunks() {
+			for _, s := range near.Slots() {
+				pos.Slice(cur)[s].X = 5
+			}
+		}
+	}})
+	ecs.SetPlan(func(s goke.RunCtx, d time.Duration) {
+		s.Run(sys, d)
+		s.Sync()
+	})
+	ecs.Tick(time.Millisecond)
+
+	assert.Equal(t, []uid.UID64{ids[0], ids[2], ids[3]}, collect(right), "Sync marks summaries stale")
+	assert.Equal(t, 4, right.Count(), "Count stays mask-only")
+}
+
+func TestWhereRange_SeesWritesThroughAnotherQuery(t *testing.T) {
+	ecs := goke.New()
+	var pos, wpos goke.Comp[position]
+	var ids []uid.UID64
+	var inRange, writer *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		ids = si.NewFactory(&pos).SpawnAll(4)
+		inRange = si.NewQueryBuilder(&pos).
+			Where(goke.WhereRange(&pos, func(p *position) float64 { return p.X }, 5, 10)).
+			Build()
+		writer = si.NewQueryBuilder(&wpos).Build()
+	}})
+	for range inRange.Entities() {
+		t.Fatal("expected no match before the write")
+	}
+
+	assert.True(t, writer.Seek(ids[2]))
+	wpos.At(writer.Cursor()).X = 7
+
+	var got []uid.UID64
+	for id := range inRange.Entities() {
+		got = append(got, id)
+	}
+	assert.Equal(t, ids[2:3], got)
+}
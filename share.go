@@ -0,0 +1,38 @@
+package goke
+
+import (
+	"github.com/kjkrol/goke/v3/internal/comp"
+	"github.com/kjkrol/goke/v3/iter"
+)
+
+// Shared gives read access to a component registered with [WithShared],
+// stored once per archetype instead of once per entity — a MeshRef, a
+// Material, a Faction's config. Entities with different values live in
+// different archetypes, so every chunk holds a single value.
+//
+// Pass &shared to NewQueryBuilder to require T and bind the handle; Get
+// then returns the current chunk's value, the same for every entity in it,
+// so read it once per chunk, outside the inner loop. Spawn entities with a
+// value by passing Share(value) to NewFactory; change it with
+// CmdBuf.AddOne, or Share(value) in NewEditorBuilder, which moves the
+// entities to the archetype holding the new value.
+type Shared[T any] struct {
+	ref iter.SharedRef[T]
+}
+
+// Get returns the current chunk's (or Pick/Seek entity's) T. It is shared
+// with every other entity holding the same value: read it, never write
+// through it.
+func (s *Shared[T]) Get(cur *Cursor) *T { return s.ref.Get(cur) }
+
+func (s *Shared[T]) asTrack() Opt { return comp.TrackShared[T](&s.ref) }
+
+// Share sets shared component T to value: passed to NewFactory, it spawns
+// entities with value; to NewEditorBuilder, it moves the edited entities
+// to the archetype holding value.
+func Share[T any](value T) Addable { return shareOpt(comp.Share(value)) }
+
+// shareOpt adapts Share's EditOpt to Addable.
+type shareOpt EditOpt
+
+func (o shareOpt) asAdd() EditOpt { return EditOpt(o) }
@@ -0,0 +1,129 @@
+package goke_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kjkrol/uid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kjkrol/goke/v3"
+)
+
+type faction struct{ Team, Color uint32 }
+
+// factionsOf reads every entity's faction through q, once per chunk.
+func factionsOf(q *goke.Query, f *goke.Shared[faction]) map[uid.UID64]faction {
+	out := map[uid.UID64]faction{}
+	for cur := range q.Chunks() {
+		v := *f.Get(cur)
+		for _, id := range cur.IDs {
+			out[id] = v
+		}
+	}
+	return out
+}
+
+func TestShared_OneValuePerChunk(t *testing.T) {
+	ecs := goke.New()
+	factionID := ecs.RegComp[faction](goke.WithShared())
+
+	red, blue := faction{Team: 1, Color: 0xff0000}, faction{Team: 2, Color: 0x0000ff}
+	var pos goke.Comp[Position]
+	var fac goke.Shared[faction]
+	var reds, blues []uid.UID64
+	var q, plainQ *goke.Query
+	var toBlue *goke.Editor
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for cur := range si.NewFactory(&pos, goke.Share(red)).Batches(4) {
+			reds = append(reds, cur.IDs...)
+		}
+		for cur := range si.NewFactory(&pos, goke.Share(blue)).Batches(2) {
+			blues = append(blues, cur.IDs...)
+		}
+		for range si.NewFactory(&pos).Batches(3) {
+		}
+		q = si.NewQueryBuilder(&pos, &fac).Build()
+		plainQ = si.NewQueryBuilder(&pos).Exclude(goke.Exclude[faction]()).Build()
+		toBlue = q.NewEditorBuilder(goke.Share(blue)).Build()
+	}})
+
+	got := factionsOf(q, &fac)
+	require.Len(t, got, 6)
+	for _, id := range reds {
+		assert.Equal(t, red, got[id])
+	}
+	for _, id := range blues {
+		assert.Equal(t, blue, got[id])
+	}
+	assert.Equal(t, 3, plainQ.Count())
+
+	// A single entity is moved by AddOne; a batch by an Editor.
+	var step int
+	sys := ecs.RegSys(goke.SystemFn{OnUpdate: func(cb *goke.CmdBuf, d time.Duration) {
+		switch step {
+		case 0:
+			cb.AddOne(reds[0], factionID, faction{Team: 3})
+		case 1:
+			for cur := range q.Chunks() {
+				buf := q.BeginMigrate(cb)
+				for _, id := range cur.IDs {
+					buf.Add(id)
+				}
+				buf.Commit(toBlue)
+			}
+		case 2:
+			cb.RemoveCompOne(blues[0], factionID)
+		}
+	}})
+	ecs.SetPlan(func(ctx goke.RunCtx, d time.Duration) {
+		ctx.Run(sys, d)
+		ctx.Sync()
+	})
+
+	ecs.Tick(time.Millisecond)
+	got = factionsOf(q, &fac)
+	assert.Equal(t, faction{Team: 3}, got[reds[0]])
+	assert.Equal(t, red, got[reds[1]])
+
+	step = 1
+	ecs.Tick(time.Millisecond)
+	for id, f := range factionsOf(q, &fac) {
+		assert.Equal(t, blue, f, "entity %v", id)
+	}
+
+	step = 2
+	ecs.Tick(time.Millisecond)
+	assert.Equal(t, 5, q.Count())
+	assert.Equal(t, 4, plainQ.Count())
+
+	// Save and ExportJSON keep each group's value.
+	want := factionsOf(q, &fac)
+	path := filepath.Join(t.TempDir(), "save.bin")
+	var doc bytes.Buffer
+	ecs.Pause()
+	require.NoError(t, ecs.Save(path))
+	require.NoError(t, ecs.ExportJSON(&doc))
+	loads := map[string]func(*goke.ECS) error{
+		"Save": func(e *goke.ECS) error {
+			return e.Load(path, goke.LoadComp[Position](), goke.LoadComp[faction](goke.WithShared()))
+		},
+		"ExportJSON": func(e *goke.ECS) error {
+			return e.ImportJSON(&doc, goke.LoadComp[Position](), goke.LoadComp[faction](goke.WithShared()))
+		},
+	}
+	for name, load := range loads {
+		ecs2 := goke.New()
+		require.NoError(t, load(ecs2), name)
+		var pos2 goke.Comp[Position]
+		var fac2 goke.Shared[faction]
+		var q2 *goke.Query
+		ecs2.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+			q2 = si.NewQueryBuilder(&pos2, &fac2).Build()
+		}})
+		assert.Equal(t, want, factionsOf(q2, &fac2), name)
+	}
+}
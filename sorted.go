@@ -0,0 +1,68 @@
+package goke
+
+import (
+	"cmp"
+	"iter"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/query"
+)
+
+// Sorted is a view of a Query's entities in the order of a key read from one
+// of its tracked components — draw order by ZIndex, turn order by
+// Initiative. Create via SortBy; call Sort once per tick (or whenever the
+// keys may have changed), then loop with Next or range over Entities.
+//
+// Sort keeps the previous order and only refreshes the keys, so when few of
+// them changed it is a short insertion sort; entities joining the Query
+// trigger a full rebuild. Ties are broken by entity ID.
+type Sorted[K cmp.Ordered] struct {
+	s *query.Sorted[K]
+	q *Query
+}
+
+// SortBy returns a Sorted view of q ordered by key applied to each entity's
+// T, read through c — which must be a column q tracks:
+//
+//	byZ := goke.SortBy(q, &z, func(z *ZIndex) int32 { return z.Value })
+//	for id, cur := range byZ.Entities() { draw(id, sprite.At(cur)) }
+//
+// Like the Query itself, a Sorted must not be used concurrently.
+func SortBy[T any, K cmp.Ordered](q *Query, c *Comp[T], key func(*T) K) *Sorted[K] {
+	return &Sorted[K]{
+		s: query.NewSorted(q.m, func(cur *Cursor) K { return key(c.At(cur)) }),
+		q: q,
+	}
+}
+
+// Sort brings the order up to date with the current keys and rewinds Next.
+func (s *Sorted[K]) Sort() { s.s.Sort() }
+
+// Len returns the number of entities ordered by the last Sort.
+func (s *Sorted[K]) Len() int { return s.s.Len() }
+
+// Next positions the Query's Cursor on the next entity in key order — read
+// components with Comp[T].At. Entities that stopped matching since Sort are
+// skipped. Returns false when exhausted.
+func (s *Sorted[K]) Next() bool { return s.s.Next() }
+
+// Entity returns the entity Next last positioned the Cursor on.
+func (s *Sorted[K]) Entity() uid.UID64 { return s.s.Entity }
+
+// Cursor returns the Query's Cursor, positioned by Next.
+func (s *Sorted[K]) Cursor() *Cursor { return s.q.Cursor() }
+
+// Entities sorts, then iterates the entities in key order, yielding each
+// one's ID and the Cursor positioned on it — the range-over-func form of
+// Sort/Next.
+func (s *Sorted[K]) Entities() iter.Seq2[uid.UID64, *Cursor] {
+	return func(yield func(uid.UID64, *Cursor) bool) {
+		cur := s.q.Cursor()
+		for s.s.Sort(); s.s.Next(); {
+			if !yield(s.s.Entity, cur) {
+				return
+			}
+		}
+	}
+}
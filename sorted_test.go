@@ -0,0 +1,43 @@
+package goke_test
+
+import (
+	"testing"
+
+	"github.com/kjkrol/goke/v3"
+	"github.com/kjkrol/uid"
+	"github.com/stretchr/testify/assert"
+)
+
+type zIndex struct{ Z int32 }
+
+func TestSortBy(t *testing.T) {
+	ecs := goke.New()
+	var z goke.Comp[zIndex]
+	var ids []uid.UID64
+	var query *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		ids = si.NewFactory(&z).SpawnAll(4)
+		query = si.NewQueryBuilder(&z).Build()
+	}})
+	for i, id := range ids {
+		query.Seek(id)
+		z.At(query.Cursor()).Z = int32(len(ids) - 1 - i)
+	}
+
+	byZ := goke.SortBy(query, &z, func(v *zIndex) int32 { return v.Z })
+	var order []uid.UID64
+	for id, cur := range byZ.Entities() {
+		order = append(order, id)
+		assert.Equal(t, int32(len(order)-1), z.At(cur).Z)
+	}
+	assert.Equal(t, []uid.UID64{ids[3], ids[2], ids[1], ids[0]}, order)
+
+	query.Seek(ids[3])
+	z.At(query.Cursor()).Z = 10
+	order = order[:0]
+	for byZ.Sort(); byZ.Next(); {
+		order = append(order, byZ.Entity())
+	}
+	assert.Equal(t, []uid.UID64{ids[2], ids[1], ids[0], ids[3]}, order)
+	assert.Equal(t, 4, byZ.Len())
+}
@@ -0,0 +1,51 @@
+package goke
+
+import (
+	"iter"
+
+	"github.com/kjkrol/uid"
+
+	"github.com/kjkrol/goke/v3/internal/colstore"
+	"github.com/kjkrol/goke/v3/internal/comp"
+)
+
+// Sparse gives typed access to a component registered with [WithSparse],
+// kept in a sparse set keyed by entity instead of in archetype chunks.
+// Adding or removing it (CmdBuf.AddOne, RemoveCompOne) never moves the
+// entity between archetypes, which suits high-churn markers like Stunned.
+//
+// Pass &sparse to NewQueryBuilder to require T, or to QueryBuilder.Maybe to
+// only read it; either binds the handle. Queries test sparse components per
+// entity, so Next lists the matching slots of each chunk in Query.Slots,
+// as under Where. Values are read by entity ID; a pointer from Get stays
+// valid until the next Sync.
+type Sparse[T any] struct {
+	set *colstore.SparseSet
+}
+
+func (s *Sparse[T]) bind(store any) { s.set = store.(*colstore.SparseSet) }
+
+func (s *Sparse[T]) asTrack() Opt    { return comp.TrackSparse[T](s.bind) }
+func (s *Sparse[T]) asOptional() Opt { return comp.OptionalSparse[T](s.bind) }
+
+// Get returns a pointer to id's T, or nil if id lacks it. For a zero-size
+// T the pointer only signals presence.
+func (s *Sparse[T]) Get(id uid.UID64) *T { return (*T)(s.set.Get(id)) }
+
+// Has reports whether id has T.
+func (s *Sparse[T]) Has(id uid.UID64) bool { return s.set.Has(id) }
+
+// Len returns the number of entities with T.
+func (s *Sparse[T]) Len() int { return s.set.Len() }
+
+// All iterates every entity with T and its value, in storage order. The
+// set must not change during the loop.
+func (s *Sparse[T]) All() iter.Seq2[uid.UID64, *T] {
+	return func(yield func(uid.UID64, *T) bool) {
+		for i, id := range s.set.IDs() {
+			if !yield(id, (*T)(s.set.At(i))) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package goke_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kjkrol/uid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kjkrol/goke/v3"
+)
+
+type stunned struct{ Ticks int }
+
+func TestSparse_AddRemoveWithoutMigration(t *testing.T) {
+	ecs := goke.New()
+	stunID := ecs.RegComp[stunned](goke.WithSparse())
+
+	var pos goke.Comp[Position]
+	var stun goke.Sparse[stunned]
+	var ids []uid.UID64
+	var stunnedQ, freeQ, allQ *goke.Query
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		for cur := range si.NewFactory(&pos).Batches(6) {
+			for i := range pos.Slice(cur) {
+				pos.Slice(cur)[i].X = float32(len(ids) + i)
+			}
+			ids = append(ids, cur.IDs...)
+		}
+		stunnedQ = si.NewQueryBuilder(&pos, &stun).Build()
+		freeQ = si.NewQueryBuilder(&pos).Exclude(goke.Exclude[stunned]()).Build()
+		allQ = si.NewQueryBuilder(&pos).Maybe(&stun).Build()
+	}})
+
+	var add bool
+	sys := ecs.RegSys(goke.SystemFn{OnUpdate: func(cb *goke.CmdBuf, d time.Duration) {
+		for _, id := range ids[:3] {
+			if add {
+				cb.AddOne(id, stunID, stunned{Ticks: 2})
+			} else {
+				cb.RemoveCompOne(id, stunID)
+			}
+		}
+	}})
+	ecs.SetPlan(func(ctx goke.RunCtx, d time.Duration) {
+		ctx.Run(sys, d)
+		ctx.Sync()
+	})
+
+	add = true
+	ecs.Tick(time.Millisecond)
+
+	assert.Equal(t, 3, stun.Len())
+	assert.Equal(t, 3, stunnedQ.Count())
+	assert.Equal(t, 3, freeQ.Count())
+	assert.Equal(t, 6, allQ.Count())
+	var got []uid.UID64
+	for id, cur := range stunnedQ.Entities() {
+		got = append(got, id)
+		assert.Equal(t, 2, stun.Get(id).Ticks)
+		assert.Equal(t, float32(len(got)-1), pos.At(cur).X)
+	}
+	assert.Equal(t, ids[:3], got)
+	for id := range freeQ.Entities() {
+		assert.Nil(t, stun.Get(id))
+	}
+
+	// Every chunk slot is intact: the entities never left their archetype.
+	n := 0
+	for cur := range allQ.Chunks() {
+		for i := range cur.IDs {
+			assert.Equal(t, ids[n], cur.IDs[i])
+			n++
+		}
+	}
+	assert.Equal(t, 6, n)
+
+	add = false
+	ecs.Tick(time.Millisecond)
+	assert.Zero(t, stun.Len())
+	assert.True(t, stunnedQ.IsEmpty())
+	assert.Equal(t, 6, freeQ.Count())
+}
+
+func TestSparse_RemovedWithEntity(t *testing.T) {
+	ecs := goke.New()
+	stunID := ecs.RegComp[stunned](goke.WithSparse())
+
+	var pos goke.Comp[Position]
+	var stun goke.Sparse[stunned]
+	var ids []uid.UID64
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		f := si.NewFactory(&pos)
+		f.Create(2)
+		f.Next()
+		ids = slices.Clone(f.IDs)
+		si.NewQueryBuilder(&pos).Maybe(&stun).Build()
+	}})
+
+	sys := ecs.RegSys(goke.SystemFn{OnUpdate: func(cb *goke.CmdBuf, d time.Duration) {
+		cb.AddOne(ids[0], stunID, stunned{Ticks: 1})
+		cb.AddOne(ids[1], stunID, stunned{Ticks: 1})
+		cb.RemoveOne(ids[0])
+	}})
+	ecs.SetPlan(func(ctx goke.RunCtx, d time.Duration) {
+		ctx.Run(sys, d)
+		ctx.Sync()
+	})
+	ecs.Tick(time.Millisecond)
+
+	require.Equal(t, 1, stun.Len())
+	for id, v := range stun.All() {
+		assert.Equal(t, ids[1], id)
+		assert.Equal(t, 1, v.Ticks)
+	}
+}
+
+func TestSparse_RejectedByFactory(t *testing.T) {
+	ecs := goke.New()
+	ecs.RegComp[stunned](goke.WithSparse())
+
+	var s goke.Comp[stunned]
+	assert.Panics(t, func() {
+		ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+			si.NewFactory(&s)
+		}})
+	})
+}
+
+func TestSparse_SaveLoad(t *testing.T) {
+	ecs := goke.New()
+	stunID := ecs.RegComp[stunned](goke.WithSparse())
+
+	var pos goke.Comp[Position]
+	var ids []uid.UID64
+	ecs.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		f := si.NewFactory(&pos)
+		f.Create(3)
+		f.Next()
+		ids = slices.Clone(f.IDs)
+	}})
+	sys := ecs.RegSys(goke.SystemFn{OnUpdate: func(cb *goke.CmdBuf, d time.Duration) {
+		cb.AddOne(ids[0], stunID, stunned{Ticks: 4})
+		cb.AddOne(ids[2], stunID, stunned{Ticks: 7})
+	}})
+	ecs.SetPlan(func(ctx goke.RunCtx, d time.Duration) {
+		ctx.Run(sys, d)
+		ctx.Sync()
+	})
+	ecs.Tick(time.Millisecond)
+
+	path := filepath.Join(t.TempDir(), "save.bin")
+	ecs.Pause()
+	require.NoError(t, ecs.Save(path))
+
+	ecs2 := goke.New()
+	require.NoError(t, ecs2.Load(path, goke.LoadComp[Position](), goke.LoadComp[stunned](goke.WithSparse())))
+	var pos2 goke.Comp[Position]
+	var stun2 goke.Sparse[stunned]
+	ecs2.Setup(goke.SystemFn{OnInit: func(si *goke.SysInit) {
+		si.NewQueryBuilder(&pos2).Maybe(&stun2).Build()
+	}})
+
+	assert.Equal(t, 2, stun2.Len())
+	assert.Equal(t, &stunned{Ticks: 4}, stun2.Get(ids[0]))
+	assert.False(t, stun2.Has(ids[1]))
+	assert.Equal(t, &stunned{Ticks: 7}, stun2.Get(ids[2]))
+}
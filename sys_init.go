@@ -30,8 +30,8 @@ func (s *SysInit) NewFactory(comps ...Addable) *Factory {
 }
 
 // RegComp registers component type T (idempotent, safe to call lazily)
-// from within Init.
-func (s *SysInit) RegComp[T any]() CompID { return s.ecs.RegComp[T]() }
+// from within Init. See [ECS.RegComp] for opts.
+func (s *SysInit) RegComp[T any](opts ...CompOpt) CompID { return s.ecs.RegComp[T](opts...) }
 
 // Remover returns the shared Remover for whole-entity removal — pass it to
 // MigrateBuf.Commit. It carries no per-call configuration (removes any